@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -34,8 +35,16 @@ func main() {
 
 	// 4. Execute (Cobra CLI)
 	if err := a.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
-		os.Exit(1)
+		code := 1
+		var exitErr *app.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.Code
+		} else {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		}
+		cancel()
+		gracefulShutdown()
+		os.Exit(code)
 	}
 }
 
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"go.uber.org/zap"
@@ -16,68 +17,157 @@ type Agent struct {
 	ai                  AIClient
 	security            SecurityChecker
 	domSubAgent         DOMSubAgent
-	maxSteps            int
+	limits              BudgetLimits
+	budget              *budget
 	interactive         bool
 	screenshots         bool
 	currentTask         *domain.Task
 	stepCount           int
 	consecutiveFailures int
 	lastFailedAction    string
+	completeReminders   int
 	progressCallback    ProgressCallback
+	observers           []Observer
+	secrets             SecretStore
 }
 
 // New создает новый Agent
-func New(ctx context.Context, browser BrowserController, ai AIClient, security SecurityChecker, domSubAgent DOMSubAgent, maxSteps int, interactive, screenshots bool) (*Agent, error) {
+func New(ctx context.Context, browser BrowserController, ai AIClient, security SecurityChecker, domSubAgent DOMSubAgent, limits BudgetLimits, interactive, screenshots bool) (*Agent, error) {
 	logger.Info(ctx, "✅ Agent initialized",
-		zap.Int("max_steps", maxSteps),
+		zap.Int("max_steps", limits.MaxSteps),
+		zap.Duration("max_duration", limits.MaxDuration),
+		zap.Int("max_input_tokens", limits.MaxInputTokens),
+		zap.Int("max_output_tokens", limits.MaxOutputTokens),
 		zap.Bool("interactive", interactive))
 
 	return &Agent{
 		browser: browser, ai: ai, security: security, domSubAgent: domSubAgent,
-		maxSteps: maxSteps, interactive: interactive, screenshots: screenshots,
+		limits: limits, interactive: interactive, screenshots: screenshots,
 	}, nil
 }
 
 // SetProgressCallback устанавливает callback для вывода прогресса
 func (a *Agent) SetProgressCallback(cb ProgressCallback) { a.progressCallback = cb }
 
+// SetSecrets подключает хранилище секретов
+func (a *Agent) SetSecrets(s SecretStore) { a.secrets = s }
+
 // emitProgress отправляет событие прогресса
 func (a *Agent) emitProgress(event ProgressEvent) {
-	if a.progressCallback != nil {
-		a.progressCallback(event)
+	if a.progressCallback == nil {
+		return
+	}
+	event.Reasoning, event.Result = a.redact(event.Reasoning), a.redact(event.Result)
+	if len(event.Params) > 0 {
+		params := make(map[string]string, len(event.Params))
+		for k, v := range event.Params {
+			params[k] = a.redact(v)
+		}
+		event.Params = params
+	}
+	a.progressCallback(event)
+}
+
+// redact заменяет значения секретов плейсхолдерами {{secret:name}}
+func (a *Agent) redact(text string) string {
+	if a.secrets == nil {
+		return text
 	}
+	return a.secrets.Redact(text)
 }
 
-// Execute выполняет задачу (без лимита шагов)
+// redactedError ошибка с текстом без значений секретов; errors.Is/As видят исходную
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactErr ошибка, текст которой не содержит значений секретов
+func (a *Agent) redactErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	if msg := a.redact(err.Error()); msg != err.Error() {
+		return &redactedError{msg: msg, err: err}
+	}
+	return err
+}
+
+// Execute выполняет задачу в рамках бюджета шагов, времени и токенов
 func (a *Agent) Execute(ctx context.Context, task *domain.Task) error {
 	logger.Info(ctx, "🚀 Starting task", zap.String("task_id", task.ID))
 
 	a.currentTask = task
-	a.stepCount = 0
+	a.stepCount, a.completeReminders = 0, 0
+	a.budget = newBudget(a.limits)
 	a.ai.NewConversation()
 
 	if err := task.Start(); err != nil {
 		return fmt.Errorf("start task: %w", err)
 	}
+	a.notifyTaskStart(ctx, task)
+	defer a.notifyTaskEnd(ctx, task)
+
+	if err := a.browser.StartTask(ctx, task.ID); err != nil {
+		_ = task.Fail(err)
+		return fmt.Errorf("prepare browser: %w", err)
+	}
+
+	ctx, cancel := a.budget.withDeadline(ctx)
+	defer cancel()
 
 	for {
+		if err := a.budget.beginStep(); err != nil {
+			return a.failBudget(ctx, task, err)
+		}
 		a.stepCount++
 		logger.Info(ctx, "📍 Step", zap.Int("step", a.stepCount))
 
 		complete, err := a.executeStep(ctx)
 		if err != nil {
+			// Дедлайн задачи прерывает запрос к модели - сообщаем об этом как о бюджете
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				if budgetErr := a.budget.checkTime(); budgetErr != nil {
+					return a.failBudget(ctx, task, budgetErr)
+				}
+			}
+			if errors.Is(err, ErrBudgetExceeded) {
+				return a.failBudget(ctx, task, err)
+			}
 			_ = task.Fail(err)
 			return fmt.Errorf("step %d: %w", a.stepCount, err)
 		}
 
 		if complete {
 			_ = task.Complete(task.Result)
-			logger.Info(ctx, "✅ Task completed", zap.Int("steps", a.stepCount))
+			logger.Info(ctx, "✅ Task completed",
+				zap.Int("steps", a.stepCount),
+				zap.Int("input_tokens", task.Usage.InputTokens),
+				zap.Int("output_tokens", task.Usage.OutputTokens),
+				zap.Int("cache_read_tokens", task.Usage.CacheReadTokens),
+				zap.Int("cache_creation_tokens", task.Usage.CacheCreationTokens))
 			return nil
 		}
 	}
 }
 
+// addUsage учитывает расход токенов модели (основной или Sub-Agent) в задаче и бюджете
+func (a *Agent) addUsage(u domain.TokenUsage) error {
+	a.currentTask.Usage.Add(u)
+	return a.budget.addUsage(u)
+}
+
+// failBudget завершает задачу из-за исчерпания бюджета
+func (a *Agent) failBudget(ctx context.Context, task *domain.Task, err error) error {
+	logger.Warn(ctx, "⛔ Budget exceeded", zap.Int("step", a.stepCount), zap.Error(err))
+	a.emitProgress(ProgressEvent{Type: "budget", Step: a.stepCount, Result: err.Error(), Budget: a.budget.status()})
+	_ = task.Fail(err)
+	return err
+}
+
 // Close закрывает агента
 func (a *Agent) Close(ctx context.Context) error {
 	logger.Info(ctx, "🚫 Closing Agent")
@@ -0,0 +1,405 @@
+package agent_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/agent"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/ai"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm/replay"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/trace"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/jsonschema"
+)
+
+// fakeBrowser браузер без страницы: результат действия задаёт тест, выполненные действия запоминаются
+type fakeBrowser struct {
+	mu       sync.Mutex
+	html     string
+	actions  []domain.Action
+	dialog   *domain.Dialog
+	onAction func(action domain.Action) (*domain.ActionResult, error)
+}
+
+func (b *fakeBrowser) StartTask(context.Context, string) error { return nil }
+
+func (b *fakeBrowser) GetPageContext(context.Context) (*domain.PageContext, error) {
+	return &domain.PageContext{URL: "https://example.com", Title: "Example"}, nil
+}
+
+func (b *fakeBrowser) ExecuteAction(_ context.Context, action domain.Action) (*domain.ActionResult, error) {
+	b.mu.Lock()
+	b.actions = append(b.actions, action)
+	b.mu.Unlock()
+	if b.onAction != nil {
+		return b.onAction(action)
+	}
+	return &domain.ActionResult{Success: true, Action: string(action.Type), Message: "ok"}, nil
+}
+
+// executed типы выполненных действий по порядку
+func (b *fakeBrowser) executed() []domain.ActionType {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	types := make([]domain.ActionType, len(b.actions))
+	for i, a := range b.actions {
+		types[i] = a.Type
+	}
+	return types
+}
+
+func (b *fakeBrowser) GetHTML(context.Context) (string, error)                  { return b.html, nil }
+func (b *fakeBrowser) CaptureScreenshot(context.Context) ([]byte, error)        { return nil, nil }
+func (b *fakeBrowser) FindElementsLive(context.Context, string) (string, error) { return "", nil }
+func (b *fakeBrowser) PageMarkdown(context.Context) (string, error)             { return "", nil }
+func (b *fakeBrowser) ExpandRef(selector string) string                         { return selector }
+func (b *fakeBrowser) FormSubmitLabel([]domain.FormField) string                { return "" }
+func (b *fakeBrowser) OpenDialog() *domain.Dialog                               { return b.dialog }
+func (b *fakeBrowser) DialogPolicy() string                                     { return "" }
+func (b *fakeBrowser) Close(context.Context) error                              { return nil }
+
+// scriptedAI отдаёт решения по очереди и запоминает результаты инструментов
+type scriptedAI struct {
+	decisions []*domain.Decision
+	results   []domain.ToolResult
+}
+
+func (c *scriptedAI) NewConversation()                                 {}
+func (c *scriptedAI) AddUserMessage(string, *domain.PageContext) error { return nil }
+func (c *scriptedAI) AddToolResults(results []domain.ToolResult) {
+	c.results = append(c.results, results...)
+}
+func (c *scriptedAI) AddNote(string)              {}
+func (c *scriptedAI) Close(context.Context) error { return nil }
+
+func (c *scriptedAI) DecideNextAction(context.Context) (*domain.Decision, error) {
+	if len(c.decisions) == 0 {
+		return nil, errors.New("no more decisions")
+	}
+	d := c.decisions[0]
+	c.decisions = c.decisions[1:]
+	return d, nil
+}
+
+// fakeSubAgent Sub-Agent с заданным расходом токенов на каждый вызов
+type fakeSubAgent struct {
+	usage     domain.TokenUsage
+	data      interface{}
+	diagnosis string
+}
+
+func (s *fakeSubAgent) Analyze(context.Context, string, string, string) (string, domain.TokenUsage, error) {
+	return "analysis", s.usage, nil
+}
+
+func (s *fakeSubAgent) AnalyzeError(context.Context, string, string, string, string) (string, domain.TokenUsage, error) {
+	return s.diagnosis, s.usage, nil
+}
+
+func (s *fakeSubAgent) ExtractData(context.Context, string, string, *jsonschema.Schema) (interface{}, domain.TokenUsage, error) {
+	return s.data, s.usage, nil
+}
+
+// fakeSecurity блокирует действия, в селекторе которых есть blocked, и запоминает проверенные
+type fakeSecurity struct {
+	blocked string
+	checked []domain.Action
+}
+
+func (s *fakeSecurity) CheckAction(_ context.Context, action domain.Action, _ *domain.PageContext) error {
+	s.checked = append(s.checked, action)
+	if s.blocked != "" && strings.Contains(action.Selector, s.blocked) {
+		return errors.New("dangerous action blocked")
+	}
+	return nil
+}
+
+func (s *fakeSecurity) Close(context.Context) error { return nil }
+
+// recordingProvider запоминает запросы к модели поверх провайдера сценария
+type recordingProvider struct {
+	llm.Provider
+	requests []*llm.ChatRequest
+}
+
+func (p *recordingProvider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	p.requests = append(p.requests, req)
+	return p.Provider.Chat(ctx, req)
+}
+
+// stepRecorder наблюдатель, запоминающий шаги задачи
+type stepRecorder struct{ steps []agent.StepRecord }
+
+func (r *stepRecorder) OnTaskStart(context.Context, *domain.Task) {}
+func (r *stepRecorder) OnStep(_ context.Context, step *agent.StepRecord) {
+	r.steps = append(r.steps, *step)
+}
+func (r *stepRecorder) OnTaskEnd(context.Context, *domain.Task) {}
+
+// secretStore секреты с заданными значениями
+type secretStore map[string]string
+
+func (s secretStore) Names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (s secretStore) Redact(text string) string {
+	for name, v := range s {
+		text = strings.ReplaceAll(text, v, "{{secret:"+name+"}}")
+	}
+	return text
+}
+
+func call(id string, action domain.Action) domain.ToolCall {
+	return domain.ToolCall{ID: id, Action: action}
+}
+
+// TestTraceRedactsActionResults секрет, показанный на странице, не попадает в трейс прогона:
+// ни в сообщение и QueryResult результата, ни в контекст ошибки и её анализ, ни в текст ошибки действия
+func TestTraceRedactsActionResults(t *testing.T) {
+	const secret = "sk-live-4242424242"
+	ctx := context.Background()
+
+	browser := &fakeBrowser{
+		html: "<p>API key: " + secret + "</p>",
+		onAction: func(action domain.Action) (*domain.ActionResult, error) {
+			switch action.Type {
+			case domain.ActionTypeReadPage:
+				page := "# Settings\nAPI key: " + secret
+				return &domain.ActionResult{Success: true, Message: page, QueryResult: page}, nil
+			case domain.ActionTypeClick:
+				return &domain.ActionResult{
+					Message: "element not found",
+					Error:   errors.New("no element " + secret),
+					ErrorContext: &domain.ErrorContext{
+						FailedSelector:  "text=" + secret,
+						SimilarElements: []string{`<code> "` + secret + `"`},
+					},
+				}, nil
+			default:
+				return nil, errors.New("type into field showing " + secret)
+			}
+		},
+	}
+	ai := &scriptedAI{decisions: []*domain.Decision{
+		{Calls: []domain.ToolCall{
+			call("t1", domain.Action{Type: domain.ActionTypeReadPage}),
+			call("t2", domain.Action{Type: domain.ActionTypeClick, Selector: "#copy"}),
+		}},
+		// Вторая неудача подряд - анализ ошибки Sub-Agent по живой странице
+		{Calls: []domain.ToolCall{call("t3", domain.Action{Type: domain.ActionTypeClick, Selector: "#copy"})}},
+		{Calls: []domain.ToolCall{call("t4", domain.Action{Type: domain.ActionTypeType, Selector: "#key", Value: "x"})}},
+	}}
+	sub := &fakeSubAgent{diagnosis: "the key " + secret + " is inside <code>"}
+
+	a, err := agent.New(ctx, browser, ai, nil, sub, agent.BudgetLimits{MaxSteps: 5}, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.SetSecrets(secretStore{"api_key": secret})
+	dir := t.TempDir()
+	a.AddObserver(trace.NewRecorder(dir))
+
+	task := domain.NewTask("copy the API key")
+	if err := a.Execute(ctx, task); err == nil {
+		t.Fatal("task with a failing action succeeded")
+	}
+	if got := browser.executed(); len(got) != 4 {
+		t.Fatalf("executed = %v, want 4 actions", got)
+	}
+
+	files, err := os.ReadDir(filepath.Join(dir, task.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var all strings.Builder
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(dir, task.ID, f.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), secret) {
+			t.Errorf("%s contains the secret:\n%s", f.Name(), data)
+		}
+		all.Write(data)
+	}
+	if !strings.Contains(all.String(), "{{secret:api_key}}") {
+		t.Error("trace has no placeholder: redacted values are not recorded at all")
+	}
+
+	for _, r := range ai.results {
+		if strings.Contains(r.Content, secret) {
+			t.Errorf("tool result %s contains the secret: %q", r.ToolUseID, r.Content)
+		}
+	}
+	if task.Error == nil || strings.Contains(task.Error.Error(), secret) {
+		t.Errorf("task error = %v", task.Error)
+	}
+}
+
+// TestSubAgentUsageCounted токены Sub-Agent входят в расход задачи и её бюджет
+func TestSubAgentUsageCounted(t *testing.T) {
+	ctx := context.Background()
+	schema := map[string]interface{}{"type": "array"}
+	extract := func(id string) *domain.Decision {
+		return &domain.Decision{
+			Calls: []domain.ToolCall{call(id, domain.Action{Type: domain.ActionTypeExtractData, Schema: schema})},
+			Usage: domain.TokenUsage{InputTokens: 100, OutputTokens: 10},
+		}
+	}
+	sub := &fakeSubAgent{
+		usage: domain.TokenUsage{InputTokens: 400, CacheReadTokens: 100, OutputTokens: 50},
+		data:  []interface{}{map[string]interface{}{"name": "a"}},
+	}
+
+	t.Run("usage", func(t *testing.T) {
+		ai := &scriptedAI{decisions: []*domain.Decision{
+			extract("t1"),
+			{Complete: true, Result: "done", Usage: domain.TokenUsage{InputTokens: 100, OutputTokens: 10}},
+		}}
+		a, err := agent.New(ctx, &fakeBrowser{}, ai, nil, sub, agent.BudgetLimits{}, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		task := domain.NewTask("collect names")
+		if err := a.Execute(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+		want := domain.TokenUsage{InputTokens: 600, CacheReadTokens: 100, OutputTokens: 70}
+		if task.Usage != want {
+			t.Errorf("task usage = %+v, want %+v", task.Usage, want)
+		}
+	})
+
+	t.Run("budget", func(t *testing.T) {
+		ai := &scriptedAI{decisions: []*domain.Decision{extract("t1"), extract("t2")}}
+		a, err := agent.New(ctx, &fakeBrowser{}, ai, nil, sub, agent.BudgetLimits{MaxInputTokens: 1000}, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		task := domain.NewTask("collect names")
+		err = a.Execute(ctx, task)
+		var be *agent.BudgetExceededError
+		if !errors.As(err, &be) || be.Kind != agent.BudgetKindInputTokens || be.Used != 1200 {
+			t.Fatalf("Execute = %v, want input tokens budget error after the second extract_data", err)
+		}
+		if task.Status != domain.TaskStatusFailed {
+			t.Errorf("task status = %s", task.Status)
+		}
+	})
+}
+
+// toolResults результаты инструментов в последнем сообщении запроса по tool_use id
+func toolResults(req *llm.ChatRequest) map[string]string {
+	results := map[string]string{}
+	last := req.Messages[len(req.Messages)-1]
+	for _, b := range last.Content {
+		if b.IsToolResult() {
+			var text []string
+			for _, c := range b.Content {
+				text = append(text, c.Text)
+			}
+			results[b.ToolUseID] = strings.Join(text, "\n")
+		}
+	}
+	return results
+}
+
+// TestExecuteReplay сценарий модели проходит через ai.Client в Agent.Execute: параллельные
+// вызовы одного ответа выполняются по порядку, заблокированный вызов не выполняется
+// и останавливает остальные вызовы шага, complete_task завершает задачу
+func TestExecuteReplay(t *testing.T) {
+	ctx := context.Background()
+	provider := &recordingProvider{Provider: replay.NewFromScript(&replay.Script{Agent: []replay.Response{
+		{
+			Text: "Открываю страницу и ищу",
+			ToolCalls: []replay.ToolCall{
+				{ID: "t1", Name: "navigate", Input: map[string]interface{}{"url": "https://example.com/account"}},
+				{ID: "t2", Name: "type_text", Input: map[string]interface{}{"selector": "input[name=q]", "text": "settings"}},
+			},
+			Usage: &replay.Usage{InputTokens: 1000, OutputTokens: 40},
+		},
+		{
+			Text: "Удаляю аккаунт",
+			ToolCalls: []replay.ToolCall{
+				{ID: "t3", Name: "click", Input: map[string]interface{}{"selector": "button.delete-account"}},
+				{ID: "t4", Name: "press_enter"},
+			},
+			Usage: &replay.Usage{InputTokens: 1200, OutputTokens: 30, CacheReadInputTokens: 900},
+		},
+		{
+			ToolCalls: []replay.ToolCall{
+				{ID: "t5", Name: "complete_task", Input: map[string]interface{}{"result": "Удаление аккаунта заблокировано, настройки открыты"}},
+			},
+			Usage: &replay.Usage{InputTokens: 1300, OutputTokens: 20},
+		},
+	}})}
+	client, err := ai.New(ctx, provider, "replay", 1024, nil, ai.CompactionConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	browser := &fakeBrowser{}
+	security := &fakeSecurity{blocked: "delete"}
+	a, err := agent.New(ctx, browser, client, security, nil, agent.BudgetLimits{MaxSteps: 10}, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := &stepRecorder{}
+	a.AddObserver(steps)
+
+	task := domain.NewTask("Открой настройки аккаунта")
+	if err := a.Execute(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+
+	if task.Status != domain.TaskStatusCompleted || task.Result != "Удаление аккаунта заблокировано, настройки открыты" {
+		t.Errorf("task = %s %q", task.Status, task.Result)
+	}
+	if want := (domain.TokenUsage{InputTokens: 3500, OutputTokens: 90, CacheReadTokens: 900}); task.Usage != want {
+		t.Errorf("usage = %+v, want %+v", task.Usage, want)
+	}
+	// Заблокированный click и следующий за ним press_enter браузер не получил
+	want := []domain.ActionType{domain.ActionTypeNavigate, domain.ActionTypeType}
+	if got := browser.executed(); !reflect.DeepEqual(got, want) {
+		t.Errorf("executed = %v, want %v", got, want)
+	}
+	if len(security.checked) != 3 {
+		t.Errorf("security checked %d actions, want 3 (press_enter is skipped before the check)", len(security.checked))
+	}
+
+	if len(steps.steps) != 3 {
+		t.Fatalf("steps = %d, want 3", len(steps.steps))
+	}
+	calls := steps.steps[1].Calls
+	if len(calls) != 2 || calls[0].SecurityVerdict != agent.VerdictBlocked || calls[1].Skipped == "" {
+		t.Errorf("step 2 calls = %+v", calls)
+	}
+	for _, c := range steps.steps[0].Calls {
+		if c.SecurityVerdict != agent.VerdictAllowed || c.Result == nil || !c.Result.Success {
+			t.Errorf("step 1 call %s = %+v", c.ToolUseID, c)
+		}
+	}
+
+	// Модель получает ответ на каждый вызов шага одним сообщением
+	if len(provider.requests) != 3 {
+		t.Fatalf("requests = %d, want 3", len(provider.requests))
+	}
+	if got := toolResults(provider.requests[1]); len(got) != 2 || got["t1"] != "ok" || got["t2"] != "ok" {
+		t.Errorf("step 1 results = %q", got)
+	}
+	got := toolResults(provider.requests[2])
+	if !strings.HasPrefix(got["t3"], "Blocked: ") || !strings.HasPrefix(got["t4"], "Skipped: ") {
+		t.Errorf("step 2 results = %q", got)
+	}
+}
@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+)
+
+// ErrBudgetExceeded базовая ошибка исчерпания бюджета задачи
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// BudgetKind вид лимита
+type BudgetKind string
+
+const (
+	BudgetKindSteps        BudgetKind = "steps"
+	BudgetKindTime         BudgetKind = "time"
+	BudgetKindInputTokens  BudgetKind = "input_tokens"
+	BudgetKindOutputTokens BudgetKind = "output_tokens"
+)
+
+// BudgetLimits лимиты на одну задачу (0 = без лимита). MaxInputTokens считает
+// все входные токены, включая прочитанные из кэша промпта и записанные в него
+type BudgetLimits struct {
+	MaxSteps        int
+	MaxDuration     time.Duration
+	MaxInputTokens  int
+	MaxOutputTokens int
+}
+
+// BudgetExceededError ошибка превышения конкретного лимита
+type BudgetExceededError struct {
+	Kind  BudgetKind
+	Limit int64
+	Used  int64
+}
+
+func (e *BudgetExceededError) Error() string {
+	if e.Kind == BudgetKindTime {
+		return fmt.Sprintf("budget exceeded: time %s of %s",
+			time.Duration(e.Used).Round(time.Second), time.Duration(e.Limit))
+	}
+	return fmt.Sprintf("budget exceeded: %s %d of %d", e.Kind, e.Used, e.Limit)
+}
+
+// Is позволяет проверять errors.Is(err, ErrBudgetExceeded)
+func (e *BudgetExceededError) Is(target error) bool { return target == ErrBudgetExceeded }
+
+// BudgetStatus текущий расход бюджета (для ProgressEvent)
+type BudgetStatus struct {
+	Steps            int
+	MaxSteps         int
+	Elapsed          time.Duration
+	MaxDuration      time.Duration
+	InputTokens      int // все входные токены, включая кэш
+	MaxInputTokens   int
+	OutputTokens     int
+	MaxOutputTokens  int
+	CacheReadTokens  int // входные токены, прочитанные из кэша промпта
+	CacheWriteTokens int // входные токены, записанные в кэш промпта
+}
+
+// budget отслеживает расход шагов, времени и токенов в рамках задачи
+type budget struct {
+	limits    BudgetLimits
+	startedAt time.Time
+	steps     int
+	usage     domain.TokenUsage
+}
+
+func newBudget(limits BudgetLimits) *budget {
+	return &budget{limits: limits, startedAt: time.Now()}
+}
+
+// withDeadline ограничивает контекст задачи по времени
+func (b *budget) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if b.limits.MaxDuration <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, b.startedAt.Add(b.limits.MaxDuration))
+}
+
+// beginStep учитывает новый шаг и проверяет лимиты шагов и времени
+func (b *budget) beginStep() error {
+	if b.limits.MaxSteps > 0 && b.steps >= b.limits.MaxSteps {
+		return &BudgetExceededError{Kind: BudgetKindSteps, Limit: int64(b.limits.MaxSteps), Used: int64(b.steps)}
+	}
+	if err := b.checkTime(); err != nil {
+		return err
+	}
+	if err := b.checkTokens(); err != nil {
+		return err
+	}
+	b.steps++
+	return nil
+}
+
+// addUsage учитывает токены ответа модели и проверяет лимиты токенов
+func (b *budget) addUsage(u domain.TokenUsage) error {
+	b.usage.Add(u)
+	return b.checkTokens()
+}
+
+// checkTokens проверяет лимиты токенов. Нужна и в начале шага: Sub-Agent мог
+// исчерпать лимит там, где ошибку бюджета некому вернуть
+func (b *budget) checkTokens() error {
+	// С кэшем промпта почти весь вход приходит как cache read - без него лимит бы не двигался
+	if input := b.usage.TotalInput(); b.limits.MaxInputTokens > 0 && input > b.limits.MaxInputTokens {
+		return &BudgetExceededError{Kind: BudgetKindInputTokens, Limit: int64(b.limits.MaxInputTokens), Used: int64(input)}
+	}
+	if b.limits.MaxOutputTokens > 0 && b.usage.OutputTokens > b.limits.MaxOutputTokens {
+		return &BudgetExceededError{Kind: BudgetKindOutputTokens, Limit: int64(b.limits.MaxOutputTokens), Used: int64(b.usage.OutputTokens)}
+	}
+	return nil
+}
+
+// checkTime проверяет лимит времени
+func (b *budget) checkTime() error {
+	elapsed := time.Since(b.startedAt)
+	if b.limits.MaxDuration > 0 && elapsed >= b.limits.MaxDuration {
+		return &BudgetExceededError{Kind: BudgetKindTime, Limit: int64(b.limits.MaxDuration), Used: int64(elapsed)}
+	}
+	return nil
+}
+
+func (b *budget) status() *BudgetStatus {
+	return &BudgetStatus{
+		Steps: b.steps, MaxSteps: b.limits.MaxSteps,
+		Elapsed: time.Since(b.startedAt), MaxDuration: b.limits.MaxDuration,
+		InputTokens: b.usage.TotalInput(), MaxInputTokens: b.limits.MaxInputTokens,
+		OutputTokens: b.usage.OutputTokens, MaxOutputTokens: b.limits.MaxOutputTokens,
+		CacheReadTokens: b.usage.CacheReadTokens, CacheWriteTokens: b.usage.CacheCreationTokens,
+	}
+}
@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+)
+
+func TestBudgetSteps(t *testing.T) {
+	b := newBudget(BudgetLimits{MaxSteps: 2})
+	for i := 0; i < 2; i++ {
+		if err := b.beginStep(); err != nil {
+			t.Fatalf("step %d: %v", i+1, err)
+		}
+	}
+	err := b.beginStep()
+	var be *BudgetExceededError
+	if !errors.As(err, &be) || be.Kind != BudgetKindSteps || be.Used != 2 || be.Limit != 2 {
+		t.Fatalf("third step = %v, want steps budget error", err)
+	}
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Error("steps error is not ErrBudgetExceeded")
+	}
+	if got := b.status().Steps; got != 2 {
+		t.Errorf("status steps = %d, want 2", got)
+	}
+}
+
+func TestBudgetUnlimited(t *testing.T) {
+	b := newBudget(BudgetLimits{})
+	for i := 0; i < 100; i++ {
+		if err := b.beginStep(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.addUsage(domain.TokenUsage{InputTokens: 1 << 30, OutputTokens: 1 << 30}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := b.withDeadline(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("deadline set without MaxDuration")
+	}
+}
+
+func TestBudgetDeadline(t *testing.T) {
+	b := newBudget(BudgetLimits{MaxDuration: time.Minute})
+	ctx, cancel := b.withDeadline(context.Background())
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || !deadline.Equal(b.startedAt.Add(time.Minute)) {
+		t.Errorf("deadline = %v, %v", deadline, ok)
+	}
+	if err := b.beginStep(); err != nil {
+		t.Fatal(err)
+	}
+
+	b.startedAt = time.Now().Add(-2 * time.Minute)
+	err := b.beginStep()
+	var be *BudgetExceededError
+	if !errors.As(err, &be) || be.Kind != BudgetKindTime || time.Duration(be.Limit) != time.Minute {
+		t.Fatalf("step after deadline = %v, want time budget error", err)
+	}
+	if !errors.Is(b.checkTime(), ErrBudgetExceeded) {
+		t.Error("time error is not ErrBudgetExceeded")
+	}
+	if b.steps != 1 {
+		t.Errorf("steps = %d: step over the deadline counted", b.steps)
+	}
+}
+
+// TestBudgetCacheTokens с кэшем промпта почти весь вход - чтение из кэша: лимит считает его
+func TestBudgetCacheTokens(t *testing.T) {
+	b := newBudget(BudgetLimits{MaxInputTokens: 1000, MaxOutputTokens: 100})
+	if err := b.addUsage(domain.TokenUsage{InputTokens: 10, CacheReadTokens: 600, CacheCreationTokens: 300, OutputTokens: 50}); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.status(); s.InputTokens != 910 || s.CacheReadTokens != 600 || s.CacheWriteTokens != 300 {
+		t.Errorf("status = %+v", s)
+	}
+
+	err := b.addUsage(domain.TokenUsage{InputTokens: 5, CacheReadTokens: 100})
+	var be *BudgetExceededError
+	if !errors.As(err, &be) || be.Kind != BudgetKindInputTokens || be.Used != 1015 {
+		t.Fatalf("addUsage = %v, want input tokens budget error", err)
+	}
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Error("input tokens error is not ErrBudgetExceeded")
+	}
+	// Лимит, исчерпанный вне шага (анализ ошибки Sub-Agent), останавливает следующий шаг
+	if err := b.beginStep(); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("step after exceeded tokens = %v", err)
+	}
+}
+
+func TestBudgetOutputTokens(t *testing.T) {
+	b := newBudget(BudgetLimits{MaxOutputTokens: 100})
+	err := b.addUsage(domain.TokenUsage{InputTokens: 1 << 20, OutputTokens: 101})
+	var be *BudgetExceededError
+	if !errors.As(err, &be) || be.Kind != BudgetKindOutputTokens || be.Used != 101 || be.Limit != 100 {
+		t.Fatalf("addUsage = %v, want output tokens budget error", err)
+	}
+	if want := "budget exceeded: output_tokens 101 of 100"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
@@ -2,15 +2,27 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/jsonschema"
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
 )
 
+const (
+	// maxAutoDialogs сколько диалогов подряд агент принимает сам после одного действия
+	maxAutoDialogs = 5
+	// maxCompleteReminders сколько раз напоминать вызвать complete_task, если модель
+	// закончила ход без него, а задача требует data по схеме
+	maxCompleteReminders = 3
+)
+
 // isNegativeResult проверяет что результат негативный
 func isNegativeResult(result string) bool {
 	lower := strings.ToLower(result)
@@ -27,17 +39,39 @@ func isNegativeResult(result string) bool {
 	return false
 }
 
-func (a *Agent) executeStep(ctx context.Context) (bool, error) {
-	a.emitProgress(ProgressEvent{Type: "step", Step: a.stepCount, MaxSteps: a.maxSteps})
+func (a *Agent) executeStep(ctx context.Context) (complete bool, err error) {
+	a.emitProgress(ProgressEvent{Type: "step", Step: a.stepCount, MaxSteps: a.limits.MaxSteps, Budget: a.budget.status()})
+
+	rec := &StepRecord{TaskID: a.currentTask.ID, Step: a.stepCount, StartedAt: time.Now()}
+	defer func() {
+		if err != nil {
+			rec.Error = err.Error()
+		}
+		a.notifyStep(ctx, rec)
+	}()
 
 	pageCtx, err := a.browser.GetPageContext(ctx)
 	if err != nil {
 		return false, fmt.Errorf("page: %w", err)
 	}
+	rec.PageContext = pageCtx
+	a.captureSnapshot(ctx, rec)
 
 	msg := ""
 	if a.stepCount == 1 {
 		msg = a.currentTask.Description
+		if a.currentTask.ExtractSchema != nil {
+			schema, _ := json.Marshal(a.currentTask.ExtractSchema)
+			msg += "\n\nСобери данные через extract_data. Схема задана пользователем (передавать schema не нужно):\n" + string(schema)
+		}
+		if a.currentTask.OutputSchema != nil {
+			schema, _ := json.Marshal(a.currentTask.OutputSchema)
+			msg += "\n\nЗаверши задачу через complete_task с data строго по схеме:\n" + string(schema)
+		}
+		if names := a.secretNames(); len(names) > 0 {
+			msg += "\n\nСекреты пользователя (вводи плейсхолдер как есть - значение подставится при вводе): " +
+				strings.Join(names, ", ")
+		}
 	}
 	a.ai.AddUserMessage(msg, pageCtx)
 	a.emitProgress(ProgressEvent{Type: "waiting"}) // Показываем что ждём ответа
@@ -46,18 +80,40 @@ func (a *Agent) executeStep(ctx context.Context) (bool, error) {
 	if err != nil {
 		return false, fmt.Errorf("AI: %w", err)
 	}
+	rec.Reasoning, rec.Usage = d.Reasoning, d.Usage
+	if err := a.addUsage(d.Usage); err != nil {
+		return false, err
+	}
 
 	// Показываем рассуждения если есть
 	if d.Reasoning != "" {
-		a.emitProgress(ProgressEvent{Type: "thinking", Reasoning: d.Reasoning, Tool: string(d.Action.Type)})
+		a.emitProgress(ProgressEvent{Type: "thinking", Reasoning: d.Reasoning, Tool: callNames(d.Calls)})
 	}
 
-	if d.Complete {
-		// Проверяем что результат не негативный
-		if isNegativeResult(d.Result) && a.stepCount < 20 {
-			// Отклоняем негативный complete_task и заставляем продолжить
-			logger.Warn(ctx, "⚠️ Rejecting negative complete_task", zap.String("result", d.Result))
-			a.ai.AddToolResult(d.ToolUseID, "ОТКЛОНЕНО! Нельзя завершать с негативным результатом. Продолжай работу - попробуй другие способы!", true)
+	if len(d.Calls) == 0 {
+		if !d.Complete {
+			return false, nil
+		}
+		// Без complete_task нет data - задачу со схемой результата так не завершить
+		if a.currentTask.OutputSchema != nil {
+			a.completeReminders++
+			if a.completeReminders > maxCompleteReminders {
+				return false, fmt.Errorf("model ended %d turns without complete_task, output schema requires data", a.completeReminders)
+			}
+			logger.Warn(ctx, "⚠️ End turn without complete_task, output schema requires data",
+				zap.Int("reminder", a.completeReminders))
+			schema, _ := json.Marshal(a.currentTask.OutputSchema)
+			a.ai.AddNote("Задача не завершена: ответ текстом не принимается. Вызови complete_task с data строго по схеме:\n" + string(schema))
+			return false, nil
+		}
+		if err := a.validateExtracted(); err != nil {
+			a.completeReminders++
+			if a.completeReminders > maxCompleteReminders {
+				return false, fmt.Errorf("extracted data does not match extract schema: %w", err)
+			}
+			logger.Warn(ctx, "⚠️ End turn with extracted data not matching schema", zap.Error(err))
+			a.ai.AddNote("Задача не завершена: собранные данные не соответствуют схеме: " + err.Error() +
+				"\nПродолжи сбор через extract_data.")
 			return false, nil
 		}
 		a.currentTask.Result = d.Result
@@ -65,70 +121,298 @@ func (a *Agent) executeStep(ctx context.Context) (bool, error) {
 		return true, nil
 	}
 
+	// На каждый tool_use модели должен прийти ровно один tool_result в одном user сообщении
+	results := make([]domain.ToolResult, 0, len(d.Calls))
+	var (
+		done       bool
+		stopReason string
+		callErr    error
+	)
+	for _, call := range d.Calls {
+		cr := CallRecord{ToolUseID: call.ID, Action: call.Action, SecurityVerdict: VerdictSkipped}
+		res := domain.ToolResult{ToolUseID: call.ID, Content: "Skipped: " + stopReason, IsError: true}
+		if stopReason == "" {
+			started := time.Now()
+			res, done, stopReason, callErr = a.executeCall(ctx, call, pageCtx, &cr)
+			cr.Duration = time.Since(started)
+		} else {
+			cr.Skipped = stopReason
+		}
+		rec.Calls = append(rec.Calls, cr)
+		// Проверяем что ToolUseID не пустой
+		if call.ID == "" {
+			logger.Warn(ctx, "⚠️ Empty ToolUseID, skipping tool result")
+			continue
+		}
+		results = append(results, res)
+	}
+	a.ai.AddToolResults(results)
+	return done, callErr
+}
+
+// executeCall выполняет один вызов инструмента. Непустой stopReason означает
+// что остальные вызовы шага выполнять нельзя
+func (a *Agent) executeCall(ctx context.Context, call domain.ToolCall, pageCtx *domain.PageContext, cr *CallRecord) (domain.ToolResult, bool, string, error) {
+	res := domain.ToolResult{ToolUseID: call.ID}
+
+	// С пустыми аргументами инструмент сделал бы не то, что просила модель
+	if call.Err != "" {
+		res.Content, res.IsError = "Invalid tool call: "+call.Err+"\nRepeat the call with valid JSON arguments.", true
+		cr.Error = call.Err
+		return res, false, "previous tool call had invalid arguments", nil
+	}
+
+	if call.Action.Type == domain.ActionTypeCompleteTask {
+		result := call.Action.Value
+		// Проверяем что результат не негативный
+		if isNegativeResult(result) && a.stepCount < 20 {
+			// Отклоняем негативный complete_task и заставляем продолжить
+			logger.Warn(ctx, "⚠️ Rejecting negative complete_task", zap.String("result", result))
+			res.Content, res.IsError = "ОТКЛОНЕНО! Нельзя завершать с негативным результатом. Продолжай работу - попробуй другие способы!", true
+			cr.Error = "negative complete_task rejected"
+			return res, false, "complete_task rejected", nil
+		}
+		data := a.outputData(call.Action.Data)
+		if err := a.validateOutput(data); err != nil {
+			logger.Warn(ctx, "⚠️ Rejecting complete_task data", zap.Error(err))
+			res.Content, res.IsError = "ОТКЛОНЕНО! data не соответствует схеме результата задачи: "+err.Error()+
+				"\nИсправь data и вызови complete_task снова.", true
+			cr.Error = "complete_task data rejected: " + err.Error()
+			return res, false, "complete_task rejected", nil
+		}
+		if err := a.validateExtracted(); err != nil {
+			logger.Warn(ctx, "⚠️ Rejecting complete_task, extracted data does not match schema", zap.Error(err))
+			res.Content, res.IsError = "ОТКЛОНЕНО! Собранные extract_data данные не соответствуют схеме: "+err.Error()+
+				"\nПродолжи сбор и вызови complete_task снова.", true
+			cr.Error = "complete_task rejected: " + err.Error()
+			return res, false, "complete_task rejected", nil
+		}
+		a.currentTask.Result, a.currentTask.Data = result, data
+		a.emitProgress(ProgressEvent{Type: "result", Result: result, Success: true})
+		res.Content = "Task completed"
+		return res, true, "task already completed", nil
+	}
+
 	if a.security != nil {
-		if err := a.security.CheckAction(ctx, d.Action, pageCtx); err != nil {
-			return a.handleSecurityError(ctx, d, err)
+		// ref:N сам по себе ничего не говорит правилам - проверяем с описанием элемента
+		checked := call.Action
+		checked.Selector = a.browser.ExpandRef(checked.Selector)
+		// Принятие диалога проверяем по его тексту: "Удалить запись?" опасно так же, как кнопка "Удалить"
+		if d := a.browser.OpenDialog(); d != nil && checked.Type == domain.ActionTypeHandleDialog {
+			checked.Selector = d.Message
+		}
+		// Форму проверяем целиком одним запросом: правила видят все поля и значения сразу,
+		// а при отправке - и текст кнопки, которую fill_form нажмёт
+		if checked.Type == domain.ActionTypeFillForm {
+			checked.Selector, checked.Value = a.formText(checked.Fields)
+			if checked.Submit {
+				checked.Target = a.browser.FormSubmitLabel(checked.Fields)
+			}
+		}
+		if err := a.security.CheckAction(ctx, checked, pageCtx); err != nil {
+			return a.handleSecurityError(res, err, cr)
 		}
+		cr.SecurityVerdict = VerdictAllowed
 	}
 
-	a.emitToolProgress(d)
-	r, err := a.executeAction(ctx, d.Action)
+	a.emitToolProgress(call.Action)
+	r, err := a.executeAction(ctx, call.Action)
 	if err != nil {
-		a.ai.AddToolResult(d.ToolUseID, "Error: "+err.Error(), true)
-		return false, err
+		// Ошибка и результат уходят в историю диалога и в трейс - без значений секретов
+		err = a.redactErr(err)
+		// Файлы, скачанные до прерывания, остаются в задаче
+		if r != nil {
+			a.redactResult(r)
+			cr.Result = r
+			a.currentTask.Downloads = append(a.currentTask.Downloads, r.Downloads...)
+		}
+		cr.Error = err.Error()
+		res.Content, res.IsError = "Error: "+err.Error(), true
+		return res, false, "previous action returned an error", err
 	}
 
-	a.emitProgress(ProgressEvent{Type: "result", Tool: string(d.Action.Type), Result: r.Message, Success: r.Success})
-	a.handleActionResult(ctx, d, r)
-	return false, nil
+	if a.browser.DialogPolicy() == "accept" {
+		a.acceptDialog(ctx, r, pageCtx)
+	}
+
+	a.emitProgress(ProgressEvent{Type: "result", Tool: string(call.Action.Type), Result: r.Message, Success: r.Success})
+	res = a.handleActionResult(ctx, call, r, cr)
+	cr.Result = r
+	a.currentTask.Downloads = append(a.currentTask.Downloads, r.Downloads...)
+	for _, d := range r.Dialogs {
+		// Открытый диалог попадёт в задачу из результата handle_dialog
+		if d.Outcome != "" {
+			a.currentTask.Dialogs = append(a.currentTask.Dialogs, d)
+		}
+	}
+	if !r.Success {
+		return res, false, fmt.Sprintf("previous action %s failed", call.Action.Type), nil
+	}
+	return res, false, "", nil
+}
+
+// acceptDialog принимает открытые диалоги по политике accept. Опасный диалог проходит
+// то же подтверждение, что и handle_dialog; отказ оставляет решение модели
+func (a *Agent) acceptDialog(ctx context.Context, r *domain.ActionResult, pageCtx *domain.PageContext) {
+	// Ответ на диалог может открыть следующий - но не бесконечно
+	for i := 0; i < maxAutoDialogs; i++ {
+		d := a.browser.OpenDialog()
+		if d == nil {
+			return
+		}
+		accept := domain.Action{Type: domain.ActionTypeHandleDialog, Accept: true, Value: d.DefaultPrompt}
+		if a.security != nil {
+			checked := accept
+			checked.Selector = d.Message
+			if err := a.security.CheckAction(ctx, checked, pageCtx); err != nil {
+				logger.Warn(ctx, "⚠️ Dialog not accepted automatically", zap.Error(err))
+				r.Message += "\n⚠️ Dialog was not accepted automatically (" + err.Error() + "). Call handle_dialog"
+				return
+			}
+		}
+		hr, err := a.browser.ExecuteAction(ctx, accept)
+		if err != nil {
+			if hr != nil {
+				r.Downloads = append(r.Downloads, hr.Downloads...)
+			}
+			r.Message += "\nAccept dialog failed: " + err.Error()
+			return
+		}
+		r.Message += "\n" + hr.Message
+		r.Success = hr.Success
+		r.Dialogs = append(r.Dialogs, hr.Dialogs...)
+		r.Downloads = append(r.Downloads, hr.Downloads...)
+	}
 }
 
-func (a *Agent) handleSecurityError(ctx context.Context, d *domain.Decision, err error) (bool, error) {
+// secretNames плейсхолдеры доступных секретов
+func (a *Agent) secretNames() []string {
+	if a.secrets == nil {
+		return nil
+	}
+	names := a.secrets.Names()
+	for i, name := range names {
+		names[i] = "{{secret:" + name + "}}"
+	}
+	return names
+}
+
+// formText поля формы для проверки безопасности: ref:N раскрываются в описание элемента
+func (a *Agent) formText(fields []domain.FormField) (names, values string) {
+	n := make([]string, 0, len(fields))
+	v := make([]string, 0, len(fields))
+	for _, f := range fields {
+		n = append(n, a.browser.ExpandRef(f.Field))
+		v = append(v, f.Value)
+	}
+	return strings.Join(n, "; "), strings.Join(v, "; ")
+}
+
+// outputData разбирает data, переданную строкой с JSON (так делают некоторые модели),
+// если схема результата не ждёт строку
+func (a *Agent) outputData(data interface{}) interface{} {
+	s, ok := data.(string)
+	if !ok || a.currentTask.OutputSchema == nil || !json.Valid([]byte(s)) {
+		return data
+	}
+	schema, err := jsonschema.New(a.currentTask.OutputSchema)
+	if err != nil || schema.AcceptsString() {
+		return data
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return data
+	}
+	return v
+}
+
+// validateOutput проверяет data из complete_task по схеме результата задачи
+func (a *Agent) validateOutput(data interface{}) error {
+	if a.currentTask.OutputSchema == nil {
+		return nil
+	}
+	schema, err := jsonschema.New(a.currentTask.OutputSchema)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return errors.New("data is required")
+	}
+	return schema.Validate(data)
+}
+
+// validateExtracted проверяет данные, собранные extract_data за задачу, по схеме пользователя
+func (a *Agent) validateExtracted() error {
+	if a.currentTask.ExtractSchema == nil {
+		return nil
+	}
+	schema, err := jsonschema.New(a.currentTask.ExtractSchema)
+	if err != nil {
+		return err
+	}
+	if a.currentTask.Extracted == nil {
+		return errors.New("no data extracted yet")
+	}
+	return schema.Validate(a.currentTask.Extracted)
+}
+
+func (a *Agent) handleSecurityError(res domain.ToolResult, err error, cr *CallRecord) (domain.ToolResult, bool, string, error) {
+	cr.SecurityReason = err.Error()
 	if err.Error() == "action rejected by user" {
+		cr.SecurityVerdict = VerdictCancelled
 		a.emitProgress(ProgressEvent{Type: "error", Result: "Отменено", Success: false})
-		return true, fmt.Errorf("cancelled")
+		res.Content, res.IsError = "Cancelled by user", true
+		return res, true, "cancelled by user", fmt.Errorf("cancelled")
 	}
-	a.ai.AddToolResult(d.ToolUseID, "Blocked: "+err.Error(), true)
-	return false, nil
+	cr.SecurityVerdict = VerdictBlocked
+	res.Content, res.IsError = "Blocked: "+err.Error(), true
+	return res, false, "previous action was blocked", nil
 }
 
-func (a *Agent) emitToolProgress(d *domain.Decision) {
+// callNames перечисляет инструменты шага для вывода прогресса
+func callNames(calls []domain.ToolCall) string {
+	names := make([]string, 0, len(calls))
+	for _, c := range calls {
+		names = append(names, string(c.Action.Type))
+	}
+	return strings.Join(names, ", ")
+}
+
+func (a *Agent) emitToolProgress(action domain.Action) {
 	p := map[string]string{}
-	if d.Action.Selector != "" {
-		p["selector"] = d.Action.Selector
+	if action.Selector != "" {
+		p["selector"] = action.Selector
 	}
-	if d.Action.URL != "" {
-		p["url"] = d.Action.URL
+	if action.URL != "" {
+		p["url"] = action.URL
 	}
-	a.emitProgress(ProgressEvent{Type: "tool", Tool: string(d.Action.Type), Params: p})
+	a.emitProgress(ProgressEvent{Type: "tool", Tool: string(action.Type), Params: p})
 }
 
-func (a *Agent) handleActionResult(ctx context.Context, d *domain.Decision, r *domain.ActionResult) {
+func (a *Agent) handleActionResult(ctx context.Context, call domain.ToolCall, r *domain.ActionResult, cr *CallRecord) domain.ToolResult {
+	// Результат уходит в историю диалога и в отчёт - без значений секретов
+	a.redactResult(r)
 	if !r.Success {
-		a.handleFailedAction(ctx, d, r)
+		cr.Analysis = a.handleFailedAction(ctx, call.Action, r)
 	} else {
 		a.consecutiveFailures, a.lastFailedAction = 0, ""
 	}
 
-	// Проверяем что ToolUseID не пустой
-	if d.ToolUseID == "" {
-		logger.Warn(ctx, "⚠️ Empty ToolUseID, skipping tool result")
-		return
-	}
-
+	res := domain.ToolResult{ToolUseID: call.ID, Content: r.Message, IsError: !r.Success}
 	switch {
 	case !r.Success && r.ErrorContext != nil:
-		a.ai.AddToolResult(d.ToolUseID, formatErrorContextMessage(r), true)
+		res.Content = domain.FormatErrorContextMessage(r.Message, r.ErrorContext)
 	case r.ScreenshotB64 != "":
-		a.ai.AddToolResultWithImage(d.ToolUseID, r.Message, r.ScreenshotB64, !r.Success)
-	default:
-		a.ai.AddToolResult(d.ToolUseID, r.Message, !r.Success)
+		res.ImageB64 = r.ScreenshotB64
 	}
+	return res
 }
 
-func (a *Agent) handleFailedAction(ctx context.Context, d *domain.Decision, r *domain.ActionResult) {
+// handleFailedAction учитывает неудачу и при повторных ошибках добавляет анализ Sub-Agent.
+// Возвращает текст анализа если он был
+func (a *Agent) handleFailedAction(ctx context.Context, action domain.Action, r *domain.ActionResult) string {
 	a.consecutiveFailures++
-	a.lastFailedAction = fmt.Sprintf("%s sel=%s", d.Action.Type, d.Action.Selector)
+	a.lastFailedAction = fmt.Sprintf("%s sel=%s", action.Type, action.Selector)
 
 	logger.Info(ctx, "❌ Action failed",
 		zap.String("action", a.lastFailedAction),
@@ -148,7 +432,12 @@ func (a *Agent) handleFailedAction(ctx context.Context, d *domain.Decision, r *d
 		html, _ := a.browser.GetHTML(ctx)
 		live, _ := a.browser.FindElementsLive(ctx, "")
 
-		if analysis, err := a.domSubAgent.AnalyzeError(ctx, html, live, a.lastFailedAction, r.Message); err == nil && analysis != "" {
+		analysis, usage, err := a.domSubAgent.AnalyzeError(ctx, html, live, a.lastFailedAction, r.Message)
+		// Лимит токенов проверится в начале следующего шага: ошибку действия анализ не заменяет
+		_ = a.addUsage(usage)
+		if err == nil && analysis != "" {
+			// Анализ строится по живой странице и добавляется к уже очищенному результату
+			analysis = a.redact(analysis)
 			// Показываем результат анализа Sub-Agent
 			a.emitProgress(ProgressEvent{
 				Type:    "subagent_result",
@@ -157,6 +446,8 @@ func (a *Agent) handleFailedAction(ctx context.Context, d *domain.Decision, r *d
 				Success: true,
 			})
 			r.Message = fmt.Sprintf("%s\n\n🧠 АНАЛИЗ:\n%s", r.Message, analysis)
+			return analysis
 		}
 	}
+	return ""
 }
@@ -2,12 +2,14 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"go.uber.org/zap"
 
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/jsonschema"
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
 )
 
@@ -23,10 +25,13 @@ func (a *Agent) executeAction(ctx context.Context, action domain.Action) (*domai
 		return a.executeQueryDOM(ctx, action)
 	case domain.ActionTypeAnalyzePage:
 		return a.executeAnalyzePage(ctx, action)
+	case domain.ActionTypeExtractData:
+		return a.executeExtractData(ctx, action)
 	default:
 		result, err := a.browser.ExecuteAction(ctx, action)
 		if err != nil {
-			return nil, err
+			// При прерывании результат может нести уже скачанные файлы
+			return result, err
 		}
 		if a.interactive {
 			logger.Info(ctx, "⏸️  Interactive mode - press Enter to continue")
@@ -47,7 +52,10 @@ func (a *Agent) executeAnalyzePage(ctx context.Context, action domain.Action) (*
 	html, _ := a.browser.GetHTML(ctx)
 	liveElements, _ := a.browser.FindElementsLive(ctx, "")
 
-	analysis, err := a.domSubAgent.Analyze(ctx, html, liveElements, action.Question)
+	analysis, usage, err := a.domSubAgent.Analyze(ctx, html, liveElements, action.Question)
+	if budgetErr := a.addUsage(usage); budgetErr != nil {
+		return nil, budgetErr
+	}
 	if err != nil {
 		return &domain.ActionResult{
 			Success: false, Action: string(action.Type),
@@ -96,3 +104,63 @@ func (a *Agent) executeQueryDOM(ctx context.Context, action domain.Action) (*dom
 		Message: result, QueryResult: result,
 	}, nil
 }
+
+// executeExtractData заполняет JSON Schema данными текущей страницы через Sub-Agent
+// и добавляет их к структурированному результату задачи
+func (a *Agent) executeExtractData(ctx context.Context, action domain.Action) (*domain.ActionResult, error) {
+	logger.Info(ctx, "📊 Extract data", zap.String("instruction", action.Query))
+
+	failed := func(msg string) (*domain.ActionResult, error) {
+		return &domain.ActionResult{Success: false, Action: string(action.Type), Message: msg}, nil
+	}
+
+	// Схема пользователя важнее схемы модели: по ней проверяется итоговый результат
+	raw := a.currentTask.ExtractSchema
+	if raw == nil {
+		raw = action.Schema
+	}
+	if raw == nil {
+		return failed("Нужна JSON Schema: передай schema в extract_data")
+	}
+	schema, err := jsonschema.New(raw)
+	if err != nil {
+		return failed("Некорректная схема: " + err.Error())
+	}
+
+	a.emitProgress(ProgressEvent{
+		Type: "subagent", Tool: "extract_data",
+		Result: "📊 Извлекаю данные по схеме...",
+	})
+
+	page, err := a.browser.PageMarkdown(ctx)
+	if err != nil {
+		return failed("Не удалось прочитать страницу: " + err.Error())
+	}
+	data, usage, err := a.domSubAgent.ExtractData(ctx, page, action.Query, schema)
+	if budgetErr := a.addUsage(usage); budgetErr != nil {
+		return nil, budgetErr
+	}
+	if err != nil {
+		return failed("Ошибка извлечения: " + err.Error())
+	}
+
+	added := a.currentTask.AddExtracted(data)
+	pretty, _ := json.MarshalIndent(data, "", "  ")
+	summary := fmt.Sprintf("Извлечено новых записей: %d, всего собрано за задачу: %d", added, a.currentTask.ExtractedCount())
+	// Схема пользователя описывает итог задачи целиком (minItems/maxItems и т.п.)
+	if err := a.validateExtracted(); err != nil {
+		summary += "\n⚠️ Собранные данные пока не соответствуют схеме: " + err.Error()
+	}
+
+	a.emitProgress(ProgressEvent{
+		Type: "subagent_result", Tool: "extract_data",
+		Result: summary, Success: true,
+	})
+
+	compact, _ := json.Marshal(data)
+	return &domain.ActionResult{
+		Success: true, Action: string(action.Type),
+		Message:     "📊 " + summary + "\n" + truncateForProgress(string(compact), 4000),
+		QueryResult: string(pretty),
+	}, nil
+}
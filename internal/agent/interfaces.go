@@ -4,14 +4,22 @@ import (
 	"context"
 
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/jsonschema"
 )
 
 // BrowserController интерфейс браузера
 type BrowserController interface {
+	StartTask(ctx context.Context, taskID string) error
 	GetPageContext(ctx context.Context) (*domain.PageContext, error)
 	ExecuteAction(ctx context.Context, action domain.Action) (*domain.ActionResult, error)
 	GetHTML(ctx context.Context) (string, error)
+	CaptureScreenshot(ctx context.Context) ([]byte, error)
 	FindElementsLive(ctx context.Context, query string) (string, error)
+	PageMarkdown(ctx context.Context) (string, error)
+	ExpandRef(selector string) string
+	FormSubmitLabel(fields []domain.FormField) string
+	OpenDialog() *domain.Dialog
+	DialogPolicy() string
 	Close(ctx context.Context) error
 }
 
@@ -19,16 +27,18 @@ type BrowserController interface {
 type AIClient interface {
 	NewConversation()
 	AddUserMessage(task string, pageContext *domain.PageContext) error
-	AddToolResult(toolUseID, result string, isError bool)
-	AddToolResultWithImage(toolUseID, result, imageB64 string, isError bool)
+	AddToolResults(results []domain.ToolResult)
+	AddNote(text string)
 	DecideNextAction(ctx context.Context) (*domain.Decision, error)
 	Close(ctx context.Context) error
 }
 
-// DOMSubAgent интерфейс для DOM sub-agent
+// DOMSubAgent интерфейс для DOM sub-agent. Каждый метод возвращает расход токенов
+// своих запросов к модели - он входит в расход задачи и её бюджет
 type DOMSubAgent interface {
-	Analyze(ctx context.Context, html, liveElements, question string) (string, error)
-	AnalyzeError(ctx context.Context, html, liveElements, failedAction, errorMsg string) (string, error)
+	Analyze(ctx context.Context, html, liveElements, question string) (string, domain.TokenUsage, error)
+	AnalyzeError(ctx context.Context, html, liveElements, failedAction, errorMsg string) (string, domain.TokenUsage, error)
+	ExtractData(ctx context.Context, page, instruction string, schema *jsonschema.Schema) (interface{}, domain.TokenUsage, error)
 }
 
 // SecurityChecker интерфейс проверки безопасности
@@ -37,6 +47,13 @@ type SecurityChecker interface {
 	Close(ctx context.Context) error
 }
 
+// SecretStore секреты задач: модель знает только имена, значения вырезаются
+// из вывода прогресса, результатов инструментов и снимков для наблюдателей
+type SecretStore interface {
+	Names() []string
+	Redact(text string) string
+}
+
 // ProgressCallback функция для вывода прогресса
 type ProgressCallback func(event ProgressEvent)
 
@@ -50,4 +67,5 @@ type ProgressEvent struct {
 	Params    map[string]string
 	Result    string
 	Success   bool
+	Budget    *BudgetStatus // расход бюджета задачи (для "step" и "budget")
 }
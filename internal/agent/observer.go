@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
+)
+
+// Observer получает подробную информацию о ходе выполнения задачи
+// (в отличие от ProgressCallback, который нужен только для вывода в терминал)
+type Observer interface {
+	OnTaskStart(ctx context.Context, task *domain.Task)
+	OnStep(ctx context.Context, step *StepRecord)
+	OnTaskEnd(ctx context.Context, task *domain.Task)
+}
+
+// Вердикты проверки безопасности
+const (
+	VerdictAllowed   = "allowed"
+	VerdictBlocked   = "blocked"
+	VerdictCancelled = "cancelled"
+	VerdictSkipped   = "skipped" // проверка не выполнялась
+)
+
+// StepRecord всё что произошло на одном шаге агента
+type StepRecord struct {
+	TaskID      string
+	Step        int
+	StartedAt   time.Time
+	Duration    time.Duration
+	PageContext *domain.PageContext
+	HTML        string // HTML страницы на момент запроса к модели
+	Screenshot  []byte // PNG видимой части страницы на момент запроса к модели
+	Reasoning   string
+	Usage       domain.TokenUsage
+	Calls       []CallRecord
+	Error       string
+}
+
+// CallRecord выполнение одного вызова инструмента
+type CallRecord struct {
+	ToolUseID       string
+	Action          domain.Action
+	SecurityVerdict string
+	SecurityReason  string
+	Result          *domain.ActionResult
+	Analysis        string // анализ ошибки от Sub-Agent
+	Skipped         string // причина пропуска вызова
+	Error           string
+	Duration        time.Duration
+}
+
+// AddObserver подключает наблюдателя за выполнением задач
+func (a *Agent) AddObserver(o Observer) { a.observers = append(a.observers, o) }
+
+func (a *Agent) notifyTaskStart(ctx context.Context, task *domain.Task) {
+	for _, o := range a.observers {
+		o.OnTaskStart(ctx, task)
+	}
+}
+
+func (a *Agent) notifyStep(ctx context.Context, step *StepRecord) {
+	step.Duration = time.Since(step.StartedAt)
+	for _, o := range a.observers {
+		o.OnStep(ctx, step)
+	}
+}
+
+func (a *Agent) notifyTaskEnd(ctx context.Context, task *domain.Task) {
+	for _, o := range a.observers {
+		o.OnTaskEnd(ctx, task)
+	}
+}
+
+// captureSnapshot сохраняет HTML и скриншот страницы для наблюдателей. Всё текстовое,
+// что уходит наблюдателям (HTML, контекст страницы), - без значений секретов
+func (a *Agent) captureSnapshot(ctx context.Context, step *StepRecord) {
+	if len(a.observers) == 0 {
+		return
+	}
+	step.PageContext = a.redactPage(step.PageContext)
+	html, err := a.browser.GetHTML(ctx)
+	if err != nil {
+		logger.Debug(ctx, "⚠️ Snapshot HTML failed", zap.Error(err))
+	}
+	shot, err := a.browser.CaptureScreenshot(ctx)
+	if err != nil {
+		logger.Debug(ctx, "⚠️ Snapshot screenshot failed", zap.Error(err))
+	}
+	step.HTML, step.Screenshot = a.redact(html), shot
+}
+
+// redactPage копия контекста страницы без значений секретов: значения полей
+// попадают в текст и состояния элементов (value="...")
+func (a *Agent) redactPage(pctx *domain.PageContext) *domain.PageContext {
+	if pctx == nil || a.secrets == nil {
+		return pctx
+	}
+	cp := *pctx
+	cp.URL, cp.Title, cp.VisibleText = a.redact(pctx.URL), a.redact(pctx.Title), a.redact(pctx.VisibleText)
+	cp.InteractiveElems = make([]domain.Element, len(pctx.InteractiveElems))
+	for i, e := range pctx.InteractiveElems {
+		e.Text, e.Selector, e.Href = a.redact(e.Text), a.redact(e.Selector), a.redact(e.Href)
+		if len(e.States) > 0 {
+			states := make([]string, len(e.States))
+			for j, s := range e.States {
+				states[j] = a.redact(s)
+			}
+			e.States = states
+		}
+		cp.InteractiveElems[i] = e
+	}
+	return &cp
+}
+
+// redactResult убирает значения секретов из результата действия: кроме сообщения, текст
+// страницы попадает в QueryResult (read_page, легенда скриншота, list_tabs), в ошибку
+// и в контекст ошибки (похожие элементы берутся из живого текста страницы)
+func (a *Agent) redactResult(r *domain.ActionResult) {
+	if r == nil || a.secrets == nil {
+		return
+	}
+	r.Message, r.QueryResult = a.redact(r.Message), a.redact(r.QueryResult)
+	r.Error = a.redactErr(r.Error)
+	if ec := r.ErrorContext; ec != nil {
+		cp := *ec
+		cp.FailedSelector, cp.Suggestion = a.redact(ec.FailedSelector), a.redact(ec.Suggestion)
+		cp.SimilarElements = make([]string, len(ec.SimilarElements))
+		for i, s := range ec.SimilarElements {
+			cp.SimilarElements[i] = a.redact(s)
+		}
+		r.ErrorContext = &cp
+	}
+}
@@ -4,46 +4,39 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/anthropics/anthropic-sdk-go"
-	"github.com/anthropics/anthropic-sdk-go/option"
 	"go.uber.org/zap"
 
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
 )
 
-// Client интеграция с Claude API
+// Client основной AI клиент агента поверх LLM провайдера
 type Client struct {
-	anthropic    anthropic.Client
+	provider     llm.Provider
 	model        string
 	maxTokens    int
-	temperature  float64
+	temperature  *float64
+	compaction   CompactionConfig
 	conversation *Conversation
 }
 
 // New создает новый AI клиент
-func New(ctx context.Context, apiKey, baseURL, model string, maxTokens int, temperature float64) (*Client, error) {
-	if apiKey == "" {
-		return nil, fmt.Errorf("ANTHROPIC_API_KEY is required")
+func New(ctx context.Context, provider llm.Provider, model string, maxTokens int, temperature *float64, compaction CompactionConfig) (*Client, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("llm provider is required")
 	}
 
-	opts := []option.RequestOption{option.WithAPIKey(apiKey)}
-	if baseURL != "" {
-		opts = append(opts, option.WithBaseURL(baseURL))
-	}
-
-	client := anthropic.NewClient(opts...)
-
 	logger.Info(ctx, "✅ AI Client initialized",
 		zap.String("model", model),
-		zap.String("base_url", baseURL),
 		zap.Int("max_tokens", maxTokens))
 
 	return &Client{
-		anthropic:   client,
+		provider:    provider,
 		model:       model,
 		maxTokens:   maxTokens,
 		temperature: temperature,
+		compaction:  compaction,
 	}, nil
 }
 
@@ -57,14 +50,14 @@ func (c *Client) AddUserMessage(task string, pageContext *domain.PageContext) er
 	return c.conversation.AddUserMessage(task, pageContext)
 }
 
-// AddToolResult добавляет результат выполнения tool
-func (c *Client) AddToolResult(toolUseID, result string, isError bool) {
-	c.conversation.AddToolResult(toolUseID, result, isError)
+// AddToolResults добавляет результаты всех tool вызовов шага одним сообщением
+func (c *Client) AddToolResults(results []domain.ToolResult) {
+	c.conversation.AddToolResults(results)
 }
 
-// AddToolResultWithImage добавляет результат tool с изображением
-func (c *Client) AddToolResultWithImage(toolUseID, result, imageB64 string, isError bool) {
-	c.conversation.AddToolResultWithImage(toolUseID, result, imageB64, isError)
+// AddNote добавляет указание агента модели в следующий user turn
+func (c *Client) AddNote(text string) {
+	c.conversation.AddNote(text)
 }
 
 // Close закрывает клиент
@@ -0,0 +1,288 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/ai/prompts"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
+)
+
+const (
+	// charsPerToken грубая оценка длины токена для текста
+	charsPerToken = 4
+	// imageTokens оценка стоимости одного скриншота
+	imageTokens = 1600
+	// summaryMaxTokens лимит ответа модели при суммаризации истории
+	summaryMaxTokens = 1024
+	// minSummarizeTokens ранняя история меньше этого не пересказывается: в ней почти только
+	// прошлое краткое содержание, и новый пересказ на каждом шаге её не сократит
+	minSummarizeTokens = 2 * summaryMaxTokens
+	// transcriptResultLimit сколько символов результата инструмента попадает в журнал для суммаризации
+	transcriptResultLimit = 500
+	// compactEvery старые контексты страниц и скриншоты сокращаются раз в столько шагов, а не
+	// на каждом: между сжатиями префикс истории не меняется и читается из кэша промпта
+	compactEvery = 4
+)
+
+// CompactionConfig настройки сжатия истории диалога (0 = стратегия выключена).
+// Контексты страниц и скриншоты сокращаются пачками раз в compactEvery шагов,
+// поэтому полностью в истории может быть до Keep+compactEvery-1 штук
+type CompactionConfig struct {
+	// KeepPageContexts сколько последних контекстов страницы хранить полностью,
+	// старые сокращаются до URL и заголовка
+	KeepPageContexts int
+	// KeepImages сколько последних user сообщений хранят скриншоты
+	KeepImages int
+	// SummarizeAboveTokens при превышении оценки токенов ранняя история
+	// заменяется кратким содержанием от модели
+	SummarizeAboveTokens int
+	// KeepRecentMessages сколько последних сообщений суммаризация не трогает
+	// (0 = пересказывается всё до последнего хода)
+	KeepRecentMessages int
+}
+
+// pageRef положение блока с контекстом страницы в истории
+type pageRef struct {
+	msg, block int
+	compact    string // сокращённый текст, пусто если блок уже сокращён
+}
+
+// compactPageText сокращённая версия контекста страницы для старых сообщений
+func compactPageText(task string, pctx *domain.PageContext) string {
+	page := "No page context"
+	if pctx != nil {
+		page = fmt.Sprintf("URL: %s\nTitle: %s", pctx.URL, pctx.Title)
+	}
+	return fmt.Sprintf("Task: %s\n\nPage context (outdated, details removed):\n%s", task, page)
+}
+
+// EstimateTokens грубо оценивает размер истории в токенах
+func EstimateTokens(msgs []llm.Message) int {
+	total := 0
+	for _, m := range msgs {
+		total += estimateBlocks(m.Content)
+	}
+	return total
+}
+
+func estimateBlocks(blocks []llm.ContentBlock) int {
+	total := 0
+	for _, b := range blocks {
+		switch {
+		case b.IsImage():
+			total += imageTokens
+		case b.IsToolUse():
+			input, _ := json.Marshal(b.ToolInput)
+			total += (len(b.ToolName) + len(input)) / charsPerToken
+		case b.IsToolResult():
+			total += estimateBlocks(b.Content)
+		default:
+			total += len(b.Text) / charsPerToken
+		}
+	}
+	return total
+}
+
+// compactPages сокращает все контексты страницы кроме последних keep
+func (c *Conversation) compactPages(keep int) int {
+	if keep <= 0 || len(c.pages) <= keep {
+		return 0
+	}
+	compacted := 0
+	for i := range c.pages[:len(c.pages)-keep] {
+		ref := &c.pages[i]
+		if ref.compact == "" {
+			continue
+		}
+		c.messages[ref.msg].Content[ref.block] = llm.TextBlock(ref.compact)
+		ref.compact = ""
+		compacted++
+	}
+	return compacted
+}
+
+// stripImages удаляет скриншоты из всех user сообщений кроме последних keep
+func (c *Conversation) stripImages(keep int) int {
+	if keep <= 0 {
+		return 0
+	}
+	stripped, seen := 0, 0
+	for i := len(c.messages) - 1; i >= 0; i-- {
+		if c.messages[i].Role != "user" {
+			continue
+		}
+		seen++
+		if seen <= keep {
+			continue
+		}
+		stripped += stripBlockImages(c.messages[i].Content)
+	}
+	return stripped
+}
+
+func stripBlockImages(blocks []llm.ContentBlock) int {
+	stripped := 0
+	for i := range blocks {
+		switch {
+		case blocks[i].IsImage():
+			blocks[i] = llm.TextBlock("[screenshot removed from history]")
+			stripped++
+		case blocks[i].IsToolResult():
+			stripped += stripBlockImages(blocks[i].Content)
+		}
+	}
+	return stripped
+}
+
+// summaryCut ищет границу суммаризации: последнее user сообщение, перед которым
+// как минимум одна пара user/assistant, а после - не меньше keepRecent сообщений.
+// При keepRecent <= 0 граница - последний ход: пересказывается всё до него
+func (c *Conversation) summaryCut(keepRecent int) int {
+	start := len(c.messages) - keepRecent
+	if keepRecent <= 0 {
+		start = len(c.messages) - 1
+	}
+	for i := start; i >= 2; i-- {
+		if c.messages[i].Role == "user" && c.messages[i-1].Role == "assistant" {
+			return i
+		}
+	}
+	return -1
+}
+
+// replaceWithSummary заменяет сообщения до cut кратким содержанием. tool_result
+// в первом оставшемся сообщении теряют свои tool_use и превращаются в текст
+func (c *Conversation) replaceWithSummary(cut int, summary string) {
+	rest := c.messages[cut:]
+	first := make([]llm.ContentBlock, 0, len(rest[0].Content)+1)
+	first = append(first, llm.TextBlock(fmt.Sprintf("Task: %s\n\nSummary of previous steps:\n%s", c.task, summary)))
+	for _, b := range rest[0].Content {
+		if b.IsToolResult() {
+			b = llm.TextBlock("Result of previous action: " + blocksText(b.Content))
+		}
+		first = append(first, b)
+	}
+
+	messages := make([]llm.Message, 0, len(rest))
+	messages = append(messages, llm.Message{Role: "user", Content: first})
+	c.messages = append(messages, rest[1:]...)
+
+	pages := c.pages[:0]
+	for _, ref := range c.pages {
+		if ref.msg < cut {
+			continue
+		}
+		ref.msg -= cut
+		if ref.msg == 0 {
+			ref.block++ // перед блоками первого сообщения добавлено краткое содержание
+		}
+		pages = append(pages, ref)
+	}
+	c.pages = pages
+}
+
+// transcript превращает сообщения в текстовый журнал для суммаризации
+func transcript(msgs []llm.Message) string {
+	var sb strings.Builder
+	for _, m := range msgs {
+		for _, b := range m.Content {
+			switch {
+			case b.IsText() && b.Text != "":
+				fmt.Fprintf(&sb, "[%s] %s\n", m.Role, b.Text)
+			case b.IsToolUse():
+				input, _ := json.Marshal(b.ToolInput)
+				fmt.Fprintf(&sb, "[tool call] %s %s\n", b.ToolName, input)
+			case b.IsToolResult():
+				status := "ok"
+				if b.IsError {
+					status = "error"
+				}
+				fmt.Fprintf(&sb, "[tool result, %s] %s\n", status, truncateText(blocksText(b.Content), transcriptResultLimit))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// blocksText собирает текст из блоков, игнорируя изображения
+func blocksText(blocks []llm.ContentBlock) string {
+	var parts []string
+	for _, b := range blocks {
+		if b.IsText() && b.Text != "" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// compact применяет стратегии сжатия истории перед запросом к модели.
+// Возвращает расход токенов на суммаризацию
+func (c *Client) compact(ctx context.Context) llm.Usage {
+	conv, cfg := c.conversation, c.compaction
+	before := EstimateTokens(conv.GetMessages())
+
+	// Любая правка старого сообщения сбрасывает кэш истории после него - правим пачкой
+	if conv.turns%compactEvery == 0 {
+		if n := conv.compactPages(cfg.KeepPageContexts); n > 0 {
+			logger.Debug(ctx, "🗜️ Old page contexts compacted", zap.Int("count", n))
+		}
+		if n := conv.stripImages(cfg.KeepImages); n > 0 {
+			logger.Debug(ctx, "🗜️ Old screenshots removed", zap.Int("count", n))
+		}
+	}
+
+	var usage llm.Usage
+	tokens := EstimateTokens(conv.GetMessages())
+	if cfg.SummarizeAboveTokens > 0 && tokens > cfg.SummarizeAboveTokens {
+		u, err := c.summarize(ctx)
+		if err != nil {
+			logger.Warn(ctx, "⚠️ History summarization failed", zap.Error(err))
+		}
+		usage = u
+		tokens = EstimateTokens(conv.GetMessages())
+	}
+
+	if tokens < before {
+		logger.Info(ctx, "🗜️ Conversation compacted",
+			zap.Int("tokens_before", before),
+			zap.Int("tokens_after", tokens),
+			zap.Int("messages", len(conv.GetMessages())))
+	}
+	return usage
+}
+
+// summarize просит модель кратко пересказать раннюю историю и заменяет её пересказом
+func (c *Client) summarize(ctx context.Context) (llm.Usage, error) {
+	conv := c.conversation
+	cut := conv.summaryCut(c.compaction.KeepRecentMessages)
+	if cut < 0 || EstimateTokens(conv.messages[:cut]) < minSummarizeTokens {
+		return llm.Usage{}, nil
+	}
+
+	logger.Info(ctx, "📝 Summarizing early history", zap.Int("messages", cut))
+	query := fmt.Sprintf("Задача: %s\n\nЖурнал шагов:\n%s", conv.task, transcript(conv.messages[:cut]))
+	resp, err := c.provider.Chat(ctx, &llm.ChatRequest{
+		Kind:      llm.KindSummarize,
+		Model:     c.model,
+		MaxTokens: summaryMaxTokens,
+		System:    prompts.Summarize,
+		Messages:  []llm.Message{{Role: "user", Content: []llm.ContentBlock{llm.TextBlock(query)}}},
+	})
+	if err != nil {
+		return llm.Usage{}, err
+	}
+
+	summary := blocksText(resp.Content)
+	if summary == "" {
+		return resp.Usage, fmt.Errorf("empty summary")
+	}
+	conv.replaceWithSummary(cut, summary)
+	return resp.Usage, nil
+}
@@ -0,0 +1,272 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
+)
+
+// recordingProvider запоминает историю каждого запроса (по сообщению в JSON)
+// и всегда отвечает одним вызовом take_screenshot
+type recordingProvider struct {
+	requests [][]string
+}
+
+func (p *recordingProvider) Chat(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	msgs := make([]string, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, string(data))
+	}
+	p.requests = append(p.requests, msgs)
+	return &llm.ChatResponse{
+		StopReason: llm.StopReasonToolUse,
+		Content: []llm.ContentBlock{{
+			Type: "tool_use", ToolUseID: fmt.Sprintf("toolu_%d", len(p.requests)),
+			ToolName: "take_screenshot", ToolInput: map[string]interface{}{},
+		}},
+	}, nil
+}
+
+func (p *recordingProvider) ChatWithVision(context.Context, *llm.VisionRequest) (*llm.ChatResponse, error) {
+	return nil, fmt.Errorf("unexpected vision call")
+}
+
+// isPrefix true если сообщения prev без изменений стоят в начале cur
+func isPrefix(prev, cur []string) bool {
+	if len(prev) > len(cur) {
+		return false
+	}
+	for i := range prev {
+		if prev[i] != cur[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestCompactionKeepsHistoryPrefixStable проверяет, что с настройками сжатия по умолчанию
+// история предыдущего запроса остаётся байт-в-байт префиксом следующего на всех шагах,
+// кроме шагов пакетного сжатия - иначе кэш промпта только пишется и не читается
+func TestCompactionKeepsHistoryPrefixStable(t *testing.T) {
+	const steps = 13
+	ctx := context.Background()
+	provider := &recordingProvider{}
+	client, err := New(ctx, provider, "test-model", 1024, nil, CompactionConfig{KeepPageContexts: 3, KeepImages: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.NewConversation()
+
+	for step := 1; step <= steps; step++ {
+		page := &domain.PageContext{
+			URL:         fmt.Sprintf("https://example.com/page/%d", step),
+			Title:       fmt.Sprintf("Page %d", step),
+			VisibleText: strings.Repeat(fmt.Sprintf("content of page %d. ", step), 20),
+		}
+		task := ""
+		if step == 1 {
+			task = "open pages one by one"
+		}
+		if err := client.AddUserMessage(task, page); err != nil {
+			t.Fatal(err)
+		}
+		d, err := client.DecideNextAction(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results := make([]domain.ToolResult, 0, len(d.Calls))
+		for _, call := range d.Calls {
+			results = append(results, domain.ToolResult{ToolUseID: call.ID, Content: "Screenshot taken", ImageB64: "aW1n"})
+		}
+		client.AddToolResults(results)
+	}
+
+	rewrites := 0
+	for i := 1; i < len(provider.requests); i++ {
+		step := i + 1
+		if isPrefix(provider.requests[i-1], provider.requests[i]) {
+			continue
+		}
+		rewrites++
+		if step%compactEvery != 0 {
+			t.Errorf("step %d: history prefix changed outside of a compaction step", step)
+		}
+	}
+	if want := steps / compactEvery; rewrites != want {
+		t.Errorf("history rewritten %d times in %d steps, want %d", rewrites, steps, want)
+	}
+
+	// Сжатие при этом работает: в последнем запросе старые страницы сокращены
+	last := strings.Join(provider.requests[len(provider.requests)-1], "\n")
+	if !strings.Contains(last, "Page context (outdated, details removed)") {
+		t.Error("old page contexts were never compacted")
+	}
+	if strings.Contains(last, "content of page 1.") {
+		t.Error("first page context is still in full")
+	}
+}
+
+// conversationOf история с заданной последовательностью ролей: u - user, a - assistant
+func conversationOf(roles string) *Conversation {
+	c := NewConversation()
+	for i, r := range roles {
+		role := "user"
+		if r == 'a' {
+			role = "assistant"
+		}
+		c.messages = append(c.messages, llm.Message{Role: role, Content: []llm.ContentBlock{llm.TextBlock(fmt.Sprintf("m%d", i))}})
+	}
+	return c
+}
+
+func TestSummaryCut(t *testing.T) {
+	tests := []struct {
+		name       string
+		roles      string
+		keepRecent int
+		want       int
+	}{
+		{"keeps at least keepRecent", "uauauauau", 4, 4},
+		{"walks back to a user turn", "uauauauau", 2, 6},
+		{"history ends on assistant", "uauauaua", 3, 4},
+		{"keeps more when needed", "uaauauau", 5, 3},
+		{"zero summarizes up to the last turn", "uauauau", 0, 6},
+		{"no pair before the cut", "uauau", 4, -1},
+		{"too short", "ua", 0, -1},
+		{"keepRecent covers everything", "uauauau", 10, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := conversationOf(tt.roles)
+			got := c.summaryCut(tt.keepRecent)
+			if got != tt.want {
+				t.Fatalf("summaryCut(%d) = %d, want %d", tt.keepRecent, got, tt.want)
+			}
+			if got >= 0 && tt.keepRecent > 0 && len(tt.roles)-got < tt.keepRecent {
+				t.Errorf("kept %d messages, want at least %d", len(tt.roles)-got, tt.keepRecent)
+			}
+		})
+	}
+}
+
+func TestReplaceWithSummary(t *testing.T) {
+	c := NewConversation()
+	page := func(n int) *domain.PageContext {
+		return &domain.PageContext{URL: fmt.Sprintf("https://example.com/%d", n), Title: fmt.Sprintf("Page %d", n)}
+	}
+	// Шаги: контекст страницы -> ответ модели с вызовом -> результат вызова вместе со следующей страницей
+	for step := 1; step <= 3; step++ {
+		task := ""
+		if step == 1 {
+			task = "find the price"
+		}
+		if err := c.AddUserMessage(task, page(step)); err != nil {
+			t.Fatal(err)
+		}
+		id := fmt.Sprintf("toolu_%d", step)
+		c.AddAssistantMessage(&llm.ChatResponse{Content: []llm.ContentBlock{{
+			Type: "tool_use", ToolUseID: id, ToolName: "click", ToolInput: map[string]interface{}{"selector": "#next"},
+		}}})
+		c.AddToolResults([]domain.ToolResult{{ToolUseID: id, Content: fmt.Sprintf("clicked %d", step)}})
+	}
+	// u(page1) a u(result1, page2) a u(result2, page3) a u(result3)
+	if len(c.messages) != 7 || len(c.pages) != 3 {
+		t.Fatalf("messages = %d, pages = %d", len(c.messages), len(c.pages))
+	}
+
+	c.replaceWithSummary(4, "opened pages 1 and 2")
+
+	if len(c.messages) != 3 {
+		t.Fatalf("messages after summary = %d, want 3", len(c.messages))
+	}
+	first := c.messages[0]
+	if first.Role != "user" || len(first.Content) != 3 {
+		t.Fatalf("first message = %+v", first)
+	}
+	if got := first.Content[0].Text; !strings.Contains(got, "find the price") || !strings.Contains(got, "opened pages 1 and 2") {
+		t.Errorf("summary block = %q", got)
+	}
+	// tool_result без своего tool_use в истории стал текстом
+	if b := first.Content[1]; b.IsToolResult() || b.Text != "Result of previous action: clicked 2" {
+		t.Errorf("orphan tool result = %+v", b)
+	}
+
+	// Контексты страниц 1 и 2 ушли в пересказ, ссылка на страницу 3 сдвинулась вместе с сообщениями
+	if len(c.pages) != 1 {
+		t.Fatalf("pages = %+v, want 1", c.pages)
+	}
+	if ref := c.pages[0]; ref.msg != 0 || ref.block != 2 {
+		t.Errorf("page ref = %+v, want msg 0 block 2", ref)
+	}
+	if got := c.messages[0].Content[2].Text; !strings.Contains(got, "https://example.com/3") {
+		t.Errorf("page ref points to %q", got)
+	}
+	// Сокращение по сдвинутой ссылке заменяет именно контекст страницы 3
+	if err := c.AddUserMessage("", page(4)); err != nil {
+		t.Fatal(err)
+	}
+	if n := c.compactPages(1); n != 1 || !strings.Contains(c.messages[0].Content[2].Text, "outdated") {
+		t.Errorf("compacted %d, block = %q", n, c.messages[0].Content[2].Text)
+	}
+	if got := c.messages[2].Content[1].Text; !strings.Contains(got, "https://example.com/4") || strings.Contains(got, "outdated") {
+		t.Errorf("last page context = %q", got)
+	}
+}
+
+// summaryProvider отвечает пересказом и считает запросы суммаризации
+type summaryProvider struct {
+	recordingProvider
+	summaries int
+}
+
+func (p *summaryProvider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	if req.Kind == llm.KindSummarize {
+		p.summaries++
+		return &llm.ChatResponse{Content: []llm.ContentBlock{llm.TextBlock("summary")}}, nil
+	}
+	return p.recordingProvider.Chat(ctx, req)
+}
+
+// TestSummarizeSkipsPreviousSummary пока хвост истории сам превышает порог, пересказ
+// не повторяется на каждом шаге: перед границей только прошлое краткое содержание
+func TestSummarizeSkipsPreviousSummary(t *testing.T) {
+	ctx := context.Background()
+	big := strings.Repeat("x", 3000*charsPerToken)
+	tests := []struct {
+		name      string
+		prefix    string // текст первого сообщения
+		summaries int
+	}{
+		{"only previous summary", "Task: t\n\nSummary of previous steps:\nopened the page", 0},
+		{"early history", big, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &summaryProvider{}
+			client, err := New(ctx, provider, "test-model", 1024, nil, CompactionConfig{SummarizeAboveTokens: 3000, KeepRecentMessages: 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			client.NewConversation()
+			conv := conversationOf("uauau")
+			conv.messages[0].Content = []llm.ContentBlock{llm.TextBlock(tt.prefix)}
+			conv.messages[2].Content = []llm.ContentBlock{llm.TextBlock(big)}
+			conv.messages[4].Content = []llm.ContentBlock{llm.TextBlock(big)}
+			client.conversation = conv
+
+			client.compact(ctx)
+			if provider.summaries != tt.summaries {
+				t.Errorf("summarized %d times, want %d", provider.summaries, tt.summaries)
+			}
+		})
+	}
+}
@@ -4,60 +4,80 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
-	"github.com/anthropics/anthropic-sdk-go"
 	"go.uber.org/zap"
 
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
 )
 
 type Conversation struct {
-	messages []anthropic.MessageParam
+	messages []llm.Message
+	task     string
+	pages    []pageRef // блоки с контекстом страницы, в порядке добавления
+	turns    int       // сколько раз добавлялся контекст страницы (шагов агента)
 }
 
 func NewConversation() *Conversation {
-	return &Conversation{messages: make([]anthropic.MessageParam, 0)}
+	return &Conversation{messages: make([]llm.Message, 0)}
 }
 
 func (c *Conversation) AddUserMessage(task string, ctx *domain.PageContext) error {
+	if task != "" {
+		c.task = task
+	}
 	text := fmt.Sprintf("Task: %s\n\nCurrent page context:\n%s", task, c.formatContext(ctx))
-	c.messages = append(c.messages, anthropic.NewUserMessage(anthropic.NewTextBlock(text)))
+	ref := pageRef{compact: compactPageText(task, ctx)}
+
+	// Контекст страницы идёт в тот же user turn что и результаты инструментов
+	if n := len(c.messages); n > 0 && c.messages[n-1].Role == "user" {
+		ref.msg, ref.block = n-1, len(c.messages[n-1].Content)
+		c.messages[n-1].Content = append(c.messages[n-1].Content, llm.TextBlock(text))
+	} else {
+		ref.msg, ref.block = n, 0
+		c.messages = append(c.messages, llm.Message{Role: "user", Content: []llm.ContentBlock{llm.TextBlock(text)}})
+	}
+	c.pages = append(c.pages, ref)
+	c.turns++
 	return nil
 }
 
-func (c *Conversation) AddAssistantMessage(msg *anthropic.Message) {
-	c.messages = append(c.messages, msg.ToParam())
+// AddNote добавляет текст в текущий user turn; контекст страницы следующего шага
+// допишется в то же сообщение
+func (c *Conversation) AddNote(text string) {
+	if n := len(c.messages); n > 0 && c.messages[n-1].Role == "user" {
+		c.messages[n-1].Content = append(c.messages[n-1].Content, llm.TextBlock(text))
+		return
+	}
+	c.messages = append(c.messages, llm.Message{Role: "user", Content: []llm.ContentBlock{llm.TextBlock(text)}})
 }
 
-func (c *Conversation) AddToolResult(toolID, result string, isError bool) {
-	c.messages = append(c.messages, anthropic.NewUserMessage(anthropic.NewToolResultBlock(toolID, result, isError)))
+func (c *Conversation) AddAssistantMessage(resp *llm.ChatResponse) {
+	c.messages = append(c.messages, llm.Message{Role: "assistant", Content: resp.Content})
 }
 
-func (c *Conversation) AddToolResultWithImage(toolID, result, imgB64 string, isError bool) {
-	if imgB64 == "" {
-		c.AddToolResult(toolID, result, isError)
+// AddToolResults добавляет по одному tool_result на каждый вызов в одном user сообщении
+func (c *Conversation) AddToolResults(results []domain.ToolResult) {
+	if len(results) == 0 {
 		return
 	}
-
-	img := anthropic.ImageBlockParam{Type: "image", Source: anthropic.ImageBlockParamSourceUnion{
-		OfBase64: &anthropic.Base64ImageSourceParam{Type: "base64", MediaType: "image/png", Data: imgB64},
-	}}
-	txt := anthropic.TextBlockParam{Type: "text", Text: result}
-
-	block := anthropic.ToolResultBlockParam{
-		ToolUseID: toolID, IsError: anthropic.Bool(isError),
-		Content: []anthropic.ToolResultBlockParamContentUnion{{OfImage: &img}, {OfText: &txt}},
+	blocks := make([]llm.ContentBlock, 0, len(results))
+	for _, r := range results {
+		if r.ImageB64 != "" {
+			blocks = append(blocks, llm.ToolResultBlock(r.ToolUseID, r.IsError, llm.ImageBlock(r.ImageB64, "image/png"), llm.TextBlock(r.Content)))
+			continue
+		}
+		blocks = append(blocks, llm.ToolResultBlock(r.ToolUseID, r.IsError, llm.TextBlock(r.Content)))
 	}
-
-	c.messages = append(c.messages, anthropic.MessageParam{
-		Role:    anthropic.MessageParamRoleUser,
-		Content: []anthropic.ContentBlockParamUnion{{OfToolResult: &block}},
-	})
+	c.messages = append(c.messages, llm.Message{Role: "user", Content: blocks})
 }
 
-func (c *Conversation) GetMessages() []anthropic.MessageParam { return c.messages }
-func (c *Conversation) Clear()                                { c.messages = make([]anthropic.MessageParam, 0) }
+func (c *Conversation) GetMessages() []llm.Message { return c.messages }
+func (c *Conversation) Clear() {
+	c.messages, c.task, c.pages, c.turns = make([]llm.Message, 0), "", nil, 0
+}
 
 func (c *Conversation) formatContext(pctx *domain.PageContext) string {
 	if pctx == nil {
@@ -76,6 +96,10 @@ func (c *Conversation) formatContext(pctx *domain.PageContext) string {
 	if len(pctx.InteractiveElems) > 0 {
 		out += "Interactive elements:\n"
 		for _, e := range pctx.InteractiveElems {
+			if e.Role != "" {
+				out += formatAXElement(e)
+				continue
+			}
 			out += fmt.Sprintf("- %s [%s]: \"%s\" (%s)\n", e.Type, e.Tag, truncateText(e.Text, 50), e.Selector)
 		}
 	}
@@ -83,13 +107,22 @@ func (c *Conversation) formatContext(pctx *domain.PageContext) string {
 	if pctx.VisibleText != "" {
 		text := pctx.VisibleText
 		if len(text) > 2000 {
-			text = text[:2000] + "..."
+			text = text[:2000] + "... (полный текст: read_page)"
 		}
 		out += fmt.Sprintf("Visible: %s\n", text)
 	}
 	return out
 }
 
+// formatAXElement узел дерева доступности: отступ по вложенности, роль, имя и состояния
+func formatAXElement(e domain.Element) string {
+	states := ""
+	if len(e.States) > 0 {
+		states = " [" + strings.Join(e.States, ", ") + "]"
+	}
+	return fmt.Sprintf("%s- %s \"%s\"%s (%s)\n", strings.Repeat("  ", e.Depth), e.Role, truncateText(e.Text, 50), states, e.Selector)
+}
+
 func truncateText(s string, max int) string {
 	if len(s) > max {
 		return s[:max] + "..."
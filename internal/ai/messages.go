@@ -4,36 +4,40 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/anthropics/anthropic-sdk-go"
 	"go.uber.org/zap"
 
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/ai/tools"
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
 )
 
-// DecideNextAction отправляет запрос в Claude и получает решение
+// DecideNextAction отправляет запрос модели и получает решение
 func (c *Client) DecideNextAction(ctx context.Context) (*domain.Decision, error) {
-	logger.Info(ctx, "🤔 Asking Claude for next action")
-
-	systemPrompt := c.buildSystemPrompt()
-
-	response, err := c.anthropic.Messages.New(ctx, anthropic.MessageNewParams{
-		Model:     anthropic.Model(c.model),
-		MaxTokens: int64(c.maxTokens),
-		System: []anthropic.TextBlockParam{
-			{Text: systemPrompt},
-		},
-		Messages: c.conversation.GetMessages(),
-		Tools:    tools.BrowserTools(),
+	logger.Info(ctx, "🤔 Asking model for next action")
+	compactUsage := c.compact(ctx)
+
+	response, err := c.provider.Chat(ctx, &llm.ChatRequest{
+		Kind:        llm.KindAgent,
+		Model:       c.model,
+		MaxTokens:   c.maxTokens,
+		System:      c.buildSystemPrompt(),
+		Messages:    c.conversation.GetMessages(),
+		Tools:       tools.BrowserTools(),
+		Temperature: c.temperature,
+		Cache:       true,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get response from Claude: %w", err)
+		return nil, fmt.Errorf("failed to get response from model: %w", err)
 	}
 
-	logger.Info(ctx, "📩 Received response from Claude",
-		zap.String("stop_reason", string(response.StopReason)),
-		zap.Int("content_blocks", len(response.Content)))
+	logger.Info(ctx, "📩 Received response from model",
+		zap.String("stop_reason", response.StopReason),
+		zap.Int("content_blocks", len(response.Content)),
+		zap.Int("input_tokens", response.Usage.InputTokens),
+		zap.Int("output_tokens", response.Usage.OutputTokens),
+		zap.Int("cache_read_tokens", response.Usage.CacheReadInputTokens),
+		zap.Int("cache_creation_tokens", response.Usage.CacheCreationInputTokens))
 
 	// Сохраняем ответ в историю
 	c.conversation.AddAssistantMessage(response)
@@ -43,6 +47,17 @@ func (c *Client) DecideNextAction(ctx context.Context) (*domain.Decision, error)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse decision: %w", err)
 	}
+	decision.Usage = tokenUsage(response.Usage)
+	decision.Usage.Add(tokenUsage(compactUsage))
 
 	return decision, nil
 }
+
+func tokenUsage(u llm.Usage) domain.TokenUsage {
+	return domain.TokenUsage{
+		InputTokens:         u.InputTokens,
+		OutputTokens:        u.OutputTokens,
+		CacheReadTokens:     u.CacheReadInputTokens,
+		CacheCreationTokens: u.CacheCreationInputTokens,
+	}
+}
@@ -5,45 +5,51 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/anthropics/anthropic-sdk-go"
 	"go.uber.org/zap"
 
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
 )
 
-func (c *Client) parseDecision(ctx context.Context, resp *anthropic.Message) (*domain.Decision, error) {
-	d := &domain.Decision{Complete: resp.StopReason == anthropic.StopReasonEndTurn}
+func (c *Client) parseDecision(ctx context.Context, resp *llm.ChatResponse) (*domain.Decision, error) {
+	d := &domain.Decision{Complete: resp.StopReason == llm.StopReasonEndTurn}
 
 	for _, block := range resp.Content {
-		switch content := block.AsAny().(type) {
-		case anthropic.TextBlock:
-			d.Reasoning = content.Text
-			logger.Debug(ctx, "💭 Reasoning", zap.String("text", content.Text))
-		case anthropic.ToolUseBlock:
-			logger.Info(ctx, "🔧 Tool", zap.String("tool", content.Name))
-			d.ToolUseID = content.ID
-			action, err := parseToolUse(content)
+		switch {
+		case block.IsText():
+			d.Reasoning = block.Text
+			logger.Debug(ctx, "💭 Reasoning", zap.String("text", block.Text))
+		case block.IsToolUse():
+			logger.Info(ctx, "🔧 Tool", zap.String("tool", block.ToolName))
+			if block.InputError != "" {
+				logger.Warn(ctx, "⚠️ Tool arguments not parsed", zap.String("tool", block.ToolName), zap.String("error", block.InputError))
+				d.Calls = append(d.Calls, domain.ToolCall{
+					ID: block.ToolUseID, Action: domain.Action{Type: domain.ActionType(block.ToolName)}, Err: block.InputError,
+				})
+				continue
+			}
+			action, err := parseToolUse(block)
 			if err != nil {
 				return nil, err
 			}
-			d.Action = *action
-			if content.Name == "complete_task" {
+			d.Calls = append(d.Calls, domain.ToolCall{ID: block.ToolUseID, Action: *action})
+			if block.ToolName == "complete_task" {
 				d.Complete = true
-				var in struct {
-					Result string `json:"result"`
-				}
-				json.Unmarshal([]byte(content.JSON.Input.Raw()), &in)
-				d.Result = in.Result
+				d.Result = action.Value
 			}
 		}
 	}
 	return d, nil
 }
 
-func parseToolUse(t anthropic.ToolUseBlock) (*domain.Action, error) {
-	raw, a := []byte(t.JSON.Input.Raw()), &domain.Action{}
-	switch t.Name {
+func parseToolUse(t llm.ContentBlock) (*domain.Action, error) {
+	raw, err := json.Marshal(t.ToolInput)
+	if err != nil {
+		return nil, fmt.Errorf("tool %s input: %w", t.ToolName, err)
+	}
+	a := &domain.Action{}
+	switch t.ToolName {
 	case "navigate":
 		var in struct {
 			URL string `json:"url"`
@@ -56,6 +62,31 @@ func parseToolUse(t anthropic.ToolUseBlock) (*domain.Action, error) {
 		}
 		json.Unmarshal(raw, &in)
 		a.Type, a.Selector = domain.ActionTypeClick, in.Selector
+	case "hover":
+		var in struct{ Selector string }
+		json.Unmarshal(raw, &in)
+		a.Type, a.Selector = domain.ActionTypeHover, in.Selector
+	case "drag":
+		var in struct {
+			Selector string `json:"selector"`
+			Target   string `json:"target"`
+			X        *int   `json:"x"`
+			Y        *int   `json:"y"`
+			OffsetX  int    `json:"offset_x"`
+			OffsetY  int    `json:"offset_y"`
+		}
+		json.Unmarshal(raw, &in)
+		a.Type, a.Selector, a.Target = domain.ActionTypeDrag, in.Selector, in.Target
+		a.DX, a.DY = in.OffsetX, in.OffsetY
+		if in.X != nil && in.Y != nil {
+			a.X, a.Y, a.AtPoint = *in.X, *in.Y, true
+		}
+	case "go_back":
+		a.Type = domain.ActionTypeGoBack
+	case "go_forward":
+		a.Type = domain.ActionTypeGoForward
+	case "reload":
+		a.Type = domain.ActionTypeReload
 	case "click_at_position":
 		var in struct{ X, Y int }
 		json.Unmarshal(raw, &in)
@@ -68,24 +99,97 @@ func parseToolUse(t anthropic.ToolUseBlock) (*domain.Action, error) {
 		var in struct{ Direction string }
 		json.Unmarshal(raw, &in)
 		a.Type, a.Direction = domain.ActionTypeScroll, in.Direction
+	case "scroll_into_view":
+		var in struct{ Selector string }
+		json.Unmarshal(raw, &in)
+		a.Type, a.Selector = domain.ActionTypeScrollIntoView, in.Selector
 	case "wait":
 		var in struct{ Selector string }
 		json.Unmarshal(raw, &in)
 		a.Type, a.Selector = domain.ActionTypeWait, in.Selector
+	case "select_option":
+		var in struct {
+			Selector string `json:"selector"`
+			Value    string `json:"value"`
+			Label    string `json:"label"`
+			Index    int    `json:"index"`
+		}
+		json.Unmarshal(raw, &in)
+		a.Type, a.Selector, a.Value, a.Label, a.OptionIndex = domain.ActionTypeSelect, in.Selector, in.Value, in.Label, in.Index
+	case "fill_form":
+		var in struct {
+			Fields []struct {
+				Field string `json:"field"`
+				Value string `json:"value"`
+			} `json:"fields"`
+			Submit bool `json:"submit"`
+		}
+		json.Unmarshal(raw, &in)
+		a.Type, a.Submit = domain.ActionTypeFillForm, in.Submit
+		for _, f := range in.Fields {
+			a.Fields = append(a.Fields, domain.FormField{Field: f.Field, Value: f.Value})
+		}
+	case "upload_file":
+		var in struct {
+			Selector string   `json:"selector"`
+			Files    []string `json:"files"`
+		}
+		json.Unmarshal(raw, &in)
+		a.Type, a.Selector, a.Files = domain.ActionTypeUploadFile, in.Selector, in.Files
 	case "press_enter":
 		a.Type = domain.ActionTypePressEnter
+	case "press_key":
+		var in struct {
+			Key    string `json:"key"`
+			Repeat int    `json:"repeat"`
+		}
+		json.Unmarshal(raw, &in)
+		a.Type, a.Key, a.Repeat = domain.ActionTypePressKey, in.Key, in.Repeat
+	case "handle_dialog":
+		var in struct {
+			Accept     bool   `json:"accept"`
+			PromptText string `json:"prompt_text"`
+		}
+		json.Unmarshal(raw, &in)
+		a.Type, a.Accept, a.Value = domain.ActionTypeHandleDialog, in.Accept, in.PromptText
 	case "complete_task":
-		var in struct{ Result string }
+		var in struct {
+			Result string      `json:"result"`
+			Data   interface{} `json:"data"`
+		}
 		json.Unmarshal(raw, &in)
-		a.Type, a.Value = domain.ActionTypeCompleteTask, in.Result
+		// data строкой с JSON разбирает агент: только он знает, ждёт ли схема строку
+		a.Type, a.Value, a.Data = domain.ActionTypeCompleteTask, in.Result, in.Data
 	case "take_screenshot":
-		var in struct{ FullPage bool }
+		var in struct {
+			FullPage bool `json:"full_page"`
+			Annotate bool `json:"annotate"`
+		}
 		json.Unmarshal(raw, &in)
-		a.Type, a.FullPage = domain.ActionTypeTakeScreenshot, in.FullPage
+		a.Type, a.FullPage, a.Annotate = domain.ActionTypeTakeScreenshot, in.FullPage, in.Annotate
 	case "query_dom":
 		var in struct{ Query string }
 		json.Unmarshal(raw, &in)
 		a.Type, a.Query = domain.ActionTypeQueryDOM, in.Query
+	case "read_page":
+		var in struct {
+			Offset int `json:"offset"`
+		}
+		json.Unmarshal(raw, &in)
+		a.Type, a.Offset = domain.ActionTypeReadPage, in.Offset
+	case "extract_data":
+		var in struct {
+			Instruction string      `json:"instruction"`
+			Schema      interface{} `json:"schema"`
+		}
+		json.Unmarshal(raw, &in)
+		a.Type, a.Query = domain.ActionTypeExtractData, in.Instruction
+		switch s := in.Schema.(type) {
+		case map[string]interface{}:
+			a.Schema = s
+		case string: // некоторые модели передают схему строкой с JSON
+			json.Unmarshal([]byte(s), &a.Schema)
+		}
 	case "analyze_page":
 		var in struct{ Question string }
 		json.Unmarshal(raw, &in)
@@ -101,7 +205,7 @@ func parseToolUse(t anthropic.ToolUseBlock) (*domain.Action, error) {
 	case "close_tab":
 		a.Type = domain.ActionTypeCloseTab
 	default:
-		return nil, fmt.Errorf("unknown: %s", t.Name)
+		return nil, fmt.Errorf("unknown: %s", t.ToolName)
 	}
 	return a, nil
 }
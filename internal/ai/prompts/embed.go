@@ -13,3 +13,9 @@ var ErrorAnalysis string
 
 //go:embed visual.md
 var Visual string
+
+//go:embed extract.md
+var Extract string
+
+//go:embed summarize.md
+var Summarize string
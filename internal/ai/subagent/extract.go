@@ -0,0 +1,94 @@
+package subagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/jsonschema"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
+)
+
+// maxExtractAttempts сколько раз просим модель исправить ответ, не прошедший проверку схемы
+const maxExtractAttempts = 3
+
+// ExtractData заполняет JSON Schema данными со страницы. Ответ проверяется по схеме,
+// при несоответствии модель получает список ошибок и отвечает заново. Расход токенов
+// суммируется по всем попыткам и возвращается и при ошибке
+func (d *DOMSubAgent) ExtractData(ctx context.Context, page, instruction string, schema *jsonschema.Schema) (interface{}, domain.TokenUsage, error) {
+	logger.Info(ctx, "📊 Sub-Agent: Extracting data", zap.String("instruction", instruction))
+	page = truncate(page, 100000)
+	msg := fmt.Sprintf("СХЕМА:\n%s\n\nИНСТРУКЦИЯ: %s\n\nСТРАНИЦА:\n%s", schema, instruction, page)
+
+	messages := []llm.Message{textMessage("user", msg)}
+	var (
+		usage   domain.TokenUsage
+		lastErr error
+	)
+	for attempt := 1; attempt <= maxExtractAttempts; attempt++ {
+		resp, err := d.provider.Chat(ctx, &llm.ChatRequest{
+			Kind:      llm.KindExtract,
+			Model:     d.model,
+			MaxTokens: d.maxTokens,
+			System:    ExtractPrompt,
+			Messages:  messages,
+		})
+		if err != nil {
+			logger.Error(ctx, "❌ Sub-Agent error", zap.Error(err))
+			return nil, usage, err
+		}
+		usage.Add(tokenUsage(resp.Usage))
+		answer := extractText(resp)
+
+		data, err := schema.ValidateJSON([]byte(jsonPayload(answer)))
+		if err == nil {
+			logger.Info(ctx, "📊 Sub-Agent data extracted", zap.Int("attempt", attempt))
+			return data, usage, nil
+		}
+		lastErr = err
+		logger.Warn(ctx, "⚠️ Extracted data rejected", zap.Int("attempt", attempt), zap.Error(err))
+
+		messages = append(messages,
+			textMessage("assistant", answer),
+			textMessage("user", fixRequest(err)))
+	}
+	return nil, usage, fmt.Errorf("no valid data after %d attempts: %w", maxExtractAttempts, lastErr)
+}
+
+// fixRequest просьба исправить ответ с перечислением ошибок
+func fixRequest(err error) string {
+	var verr *jsonschema.ValidationError
+	if errors.As(err, &verr) {
+		return "Ответ не соответствует схеме:\n- " + strings.Join(verr.Problems, "\n- ") +
+			"\n\nВерни исправленный JSON целиком, без пояснений."
+	}
+	return fmt.Sprintf("Ответ не является корректным JSON (%s). Верни только JSON, без пояснений.", err)
+}
+
+// jsonPayload вырезает JSON из ответа модели: без ```json блока и текста вокруг
+func jsonPayload(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, "```"); i >= 0 {
+		s = s[i+3:]
+		s = strings.TrimPrefix(s, "json")
+		if j := strings.Index(s, "```"); j >= 0 {
+			s = s[:j]
+		}
+		s = strings.TrimSpace(s)
+	}
+	start := strings.IndexAny(s, "{[")
+	end := strings.LastIndexAny(s, "}]")
+	if start < 0 || end < start {
+		return s
+	}
+	return s[start : end+1]
+}
+
+func textMessage(role, text string) llm.Message {
+	return llm.Message{Role: role, Content: []llm.ContentBlock{{Type: "text", Text: text}}}
+}
@@ -7,4 +7,5 @@ var (
 	AnalyzePrompt        = prompts.Analyze
 	ErrorAnalysisPrompt  = prompts.ErrorAnalysis
 	VisualAnalysisPrompt = prompts.Visual
+	ExtractPrompt        = prompts.Extract
 )
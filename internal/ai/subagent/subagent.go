@@ -7,6 +7,7 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
 )
@@ -23,38 +24,38 @@ func New(provider llm.Provider, model string, maxTokens int) *DOMSubAgent {
 	return &DOMSubAgent{provider: provider, model: model, maxTokens: maxTokens}
 }
 
-// Analyze анализирует страницу и отвечает на вопрос
-func (d *DOMSubAgent) Analyze(ctx context.Context, html, liveElements, question string) (string, error) {
+// Analyze анализирует страницу и отвечает на вопрос. Возвращает и расход токенов запроса
+func (d *DOMSubAgent) Analyze(ctx context.Context, html, liveElements, question string) (string, domain.TokenUsage, error) {
 	logger.Info(ctx, "🧠 Sub-Agent: Analyzing", zap.String("question", question))
 	html = truncate(html, 80000)
 	msg := fmt.Sprintf("ЭЛЕМЕНТЫ:\n%s\n\nHTML:\n%s\n\nВОПРОС: %s", liveElements, html, question)
 
-	result, err := d.send(ctx, AnalyzePrompt, msg)
+	result, usage, err := d.send(ctx, AnalyzePrompt, msg)
 	if err != nil {
 		logger.Error(ctx, "❌ Sub-Agent error", zap.Error(err))
-		return "", err
+		return "", usage, err
 	}
 	logger.Info(ctx, "🧠 Sub-Agent result", zap.String("analysis", truncateStr(result, 200)))
-	return result, nil
+	return result, usage, nil
 }
 
-// AnalyzeError анализирует ошибку и предлагает альтернативу
-func (d *DOMSubAgent) AnalyzeError(ctx context.Context, html, liveElements, failedAction, errorMsg string) (string, error) {
+// AnalyzeError анализирует ошибку и предлагает альтернативу. Возвращает и расход токенов запроса
+func (d *DOMSubAgent) AnalyzeError(ctx context.Context, html, liveElements, failedAction, errorMsg string) (string, domain.TokenUsage, error) {
 	logger.Info(ctx, "🔍 Sub-Agent: Error analysis", zap.String("action", failedAction), zap.String("error", errorMsg))
 	html = truncate(html, 60000)
 	msg := fmt.Sprintf("ДЕЙСТВИЕ: %s\nОШИБКА: %s\n\nЭЛЕМЕНТЫ:\n%s\n\nHTML:\n%s", failedAction, errorMsg, liveElements, html)
 
-	result, err := d.send(ctx, ErrorAnalysisPrompt, msg)
+	result, usage, err := d.send(ctx, ErrorAnalysisPrompt, msg)
 	if err != nil {
 		logger.Error(ctx, "❌ Sub-Agent error", zap.Error(err))
-		return "", err
+		return "", usage, err
 	}
 	logger.Info(ctx, "🔍 Sub-Agent diagnosis", zap.String("result", truncateStr(result, 300)))
-	return result, nil
+	return result, usage, nil
 }
 
 // Query выполняет запрос к DOM
-func (d *DOMSubAgent) Query(ctx context.Context, html, query string) (string, error) {
+func (d *DOMSubAgent) Query(ctx context.Context, html, query string) (string, domain.TokenUsage, error) {
 	return d.Analyze(ctx, html, "", query)
 }
 
@@ -76,8 +77,9 @@ func (d *DOMSubAgent) QueryWithScreenshot(ctx context.Context, screenshotB64, qu
 	return extractText(resp), nil
 }
 
-func (d *DOMSubAgent) send(ctx context.Context, system, user string) (string, error) {
+func (d *DOMSubAgent) send(ctx context.Context, system, user string) (string, domain.TokenUsage, error) {
 	resp, err := d.provider.Chat(ctx, &llm.ChatRequest{
+		Kind:      llm.KindSubAgent,
 		Model:     d.model,
 		MaxTokens: d.maxTokens,
 		System:    system,
@@ -87,9 +89,19 @@ func (d *DOMSubAgent) send(ctx context.Context, system, user string) (string, er
 		}},
 	})
 	if err != nil {
-		return "", err
+		return "", domain.TokenUsage{}, err
+	}
+	return extractText(resp), tokenUsage(resp.Usage), nil
+}
+
+// tokenUsage расход токенов ответа провайдера для учёта в задаче и бюджете
+func tokenUsage(u llm.Usage) domain.TokenUsage {
+	return domain.TokenUsage{
+		InputTokens:         u.InputTokens,
+		OutputTokens:        u.OutputTokens,
+		CacheReadTokens:     u.CacheReadInputTokens,
+		CacheCreationTokens: u.CacheCreationInputTokens,
 	}
-	return extractText(resp), nil
 }
 
 func extractText(resp *llm.ChatResponse) string {
@@ -1,48 +1,89 @@
 package tools
 
-import "github.com/anthropics/anthropic-sdk-go"
+import "github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
 
 // InputTools - ввод текста и клавиатура
-func InputTools() []anthropic.ToolParam {
-	return []anthropic.ToolParam{
+func InputTools() []llm.Tool {
+	return []llm.Tool{
 		{
 			Name:        "type_text",
-			Description: anthropic.String("Type text into input field"),
-			InputSchema: anthropic.ToolInputSchemaParam{
-				Properties: map[string]interface{}{
-					"selector": map[string]interface{}{"type": "string", "description": "Input selector"},
-					"text":     map[string]interface{}{"type": "string", "description": "Text to type"},
+			Description: "Type text into input field",
+			InputSchema: objectSchema(map[string]interface{}{
+				"selector": map[string]interface{}{"type": "string", "description": "ref:N or input selector; inside iframe/shadow DOM: 'iframe#login >> input[name=email]'"},
+				"text":     map[string]interface{}{"type": "string", "description": "Text to type"},
+			}, "selector", "text"),
+		},
+		{
+			Name:        "select_option",
+			Description: "Choose an option in a dropdown: native <select> or ARIA combobox/listbox. Specify one of value, label or index. Returns the option that ended up selected",
+			InputSchema: objectSchema(map[string]interface{}{
+				"selector": map[string]interface{}{"type": "string", "description": "Dropdown selector: ref:N, CSS or text:Field label"},
+				"value":    map[string]interface{}{"type": "string", "description": "Option value attribute"},
+				"label":    map[string]interface{}{"type": "string", "description": "Visible option text"},
+				"index":    map[string]interface{}{"type": "integer", "description": "Option number, 1-based"},
+			}, "selector"),
+		},
+		{
+			Name:        "fill_form",
+			Description: "Fill several form fields in one step: text inputs, textareas, dropdowns, checkboxes, radio groups, date/time inputs. Use instead of repeated type_text on registration and checkout forms. Reports the result for each field",
+			InputSchema: objectSchema(map[string]interface{}{
+				"fields": map[string]interface{}{
+					"type": "array",
+					"items": objectSchema(map[string]interface{}{
+						"field": map[string]interface{}{"type": "string", "description": "Field label, name, placeholder, ref:N or CSS selector"},
+						"value": map[string]interface{}{"type": "string", "description": "Text; option text for dropdowns; true/false for checkboxes; option label for radio groups; YYYY-MM-DD for dates"},
+					}, "field", "value"),
+					"description": "Fields in the order they should be filled",
+				},
+				"submit": map[string]interface{}{"type": "boolean", "description": "Submit the form after all fields are filled (default false)"},
+			}, "fields"),
+		},
+		{
+			Name:        "upload_file",
+			Description: "Attach files to a file input or drop zone. Selector may point to the styled 'Attach' button - the hidden input is found automatically. Files are names inside the allowed upload directory",
+			InputSchema: objectSchema(map[string]interface{}{
+				"selector": map[string]interface{}{"type": "string", "description": "File input, attach button or drop zone: ref:N, CSS or text:Text. Empty = first file input on page"},
+				"files": map[string]interface{}{
+					"type": "array", "items": map[string]interface{}{"type": "string"},
+					"description": "File names relative to the upload directory",
 				},
-				Required: []string{"selector", "text"},
-			},
+			}, "files"),
 		},
 		{
 			Name:        "press_enter",
-			Description: anthropic.String("Press Enter key"),
-			InputSchema: anthropic.ToolInputSchemaParam{
-				Properties: map[string]interface{}{},
-				Required:   []string{},
-			},
+			Description: "Press Enter key",
+			InputSchema: objectSchema(map[string]interface{}{}),
+		},
+		{
+			Name:        "press_key",
+			Description: "Press a key or key combination in the focused element: Escape (close popups), Tab, Shift+Tab, ArrowDown, PageDown, Control+A, Backspace, F5",
+			InputSchema: objectSchema(map[string]interface{}{
+				"key":    map[string]interface{}{"type": "string", "description": "Key name or chord joined with +: Escape, Tab, ArrowUp, Control+A, Shift+Tab"},
+				"repeat": map[string]interface{}{"type": "integer", "description": "How many times to press (default 1, max 50)"},
+			}, "key"),
+		},
+		{
+			Name:        "handle_dialog",
+			Description: "Answer the page's open JavaScript dialog (confirm, prompt, beforeunload). The page is frozen until you do. Returns the outcome of the action that opened it",
+			InputSchema: objectSchema(map[string]interface{}{
+				"accept":      map[string]interface{}{"type": "boolean", "description": "true = OK / Leave, false = Cancel / Stay"},
+				"prompt_text": map[string]interface{}{"type": "string", "description": "Answer for a prompt dialog"},
+			}, "accept"),
 		},
 		{
 			Name:        "wait",
-			Description: anthropic.String("Wait for element to appear"),
-			InputSchema: anthropic.ToolInputSchemaParam{
-				Properties: map[string]interface{}{
-					"selector": map[string]interface{}{"type": "string", "description": "Element selector"},
-				},
-				Required: []string{"selector"},
-			},
+			Description: "Wait for element to appear",
+			InputSchema: objectSchema(map[string]interface{}{
+				"selector": map[string]interface{}{"type": "string", "description": "Element selector"},
+			}, "selector"),
 		},
 		{
 			Name:        "complete_task",
-			Description: anthropic.String("Mark task as completed"),
-			InputSchema: anthropic.ToolInputSchemaParam{
-				Properties: map[string]interface{}{
-					"result": map[string]interface{}{"type": "string", "description": "Result summary"},
-				},
-				Required: []string{"result"},
-			},
+			Description: "Mark task as completed",
+			InputSchema: objectSchema(map[string]interface{}{
+				"result": map[string]interface{}{"type": "string", "description": "Result summary"},
+				"data":   map[string]interface{}{"description": "Structured result as JSON matching the task's output schema (required if the task declares one)"},
+			}, "result"),
 		},
 	}
 }
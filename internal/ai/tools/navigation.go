@@ -1,50 +1,79 @@
 package tools
 
-import "github.com/anthropics/anthropic-sdk-go"
+import "github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
 
 // NavigationTools - навигация и клики
-func NavigationTools() []anthropic.ToolParam {
-	return []anthropic.ToolParam{
+func NavigationTools() []llm.Tool {
+	return []llm.Tool{
 		{
 			Name:        "navigate",
-			Description: anthropic.String("Navigate to a URL"),
-			InputSchema: anthropic.ToolInputSchemaParam{
-				Properties: map[string]interface{}{
-					"url": map[string]interface{}{"type": "string", "description": "URL to navigate to"},
-				},
-				Required: []string{"url"},
-			},
+			Description: "Navigate to a URL",
+			InputSchema: objectSchema(map[string]interface{}{
+				"url": map[string]interface{}{"type": "string", "description": "URL to navigate to"},
+			}, "url"),
+		},
+		{
+			Name:        "go_back",
+			Description: "Go back to the previous page in this tab's history (browser Back button)",
+			InputSchema: objectSchema(map[string]interface{}{}),
+		},
+		{
+			Name:        "go_forward",
+			Description: "Go forward in this tab's history (browser Forward button)",
+			InputSchema: objectSchema(map[string]interface{}{}),
+		},
+		{
+			Name:        "reload",
+			Description: "Reload the current page. Use when the page is stuck or shows stale data",
+			InputSchema: objectSchema(map[string]interface{}{}),
 		},
 		{
 			Name:        "click",
-			Description: anthropic.String("Click element by selector. Supports text:ButtonText syntax"),
-			InputSchema: anthropic.ToolInputSchemaParam{
-				Properties: map[string]interface{}{
-					"selector": map[string]interface{}{"type": "string", "description": "CSS selector or text:Text"},
-				},
-				Required: []string{"selector"},
-			},
+			Description: "Click element by selector. Prefer ref:N from the last query_dom; also supports text:ButtonText syntax",
+			InputSchema: objectSchema(map[string]interface{}{
+				"selector": map[string]interface{}{"type": "string", "description": "ref:N, CSS selector or text:Text. Elements inside iframe/shadow DOM: 'iframe#pay >> text:Pay'"},
+			}, "selector"),
 		},
 		{
 			Name:        "click_at_position",
-			Description: anthropic.String("Click at coordinates. Use when selectors fail"),
-			InputSchema: anthropic.ToolInputSchemaParam{
-				Properties: map[string]interface{}{
-					"x": map[string]interface{}{"type": "integer", "description": "X coordinate"},
-					"y": map[string]interface{}{"type": "integer", "description": "Y coordinate"},
-				},
-				Required: []string{"x", "y"},
-			},
+			Description: "Click at coordinates. Use when selectors fail",
+			InputSchema: objectSchema(map[string]interface{}{
+				"x": map[string]interface{}{"type": "integer", "description": "X coordinate"},
+				"y": map[string]interface{}{"type": "integer", "description": "Y coordinate"},
+			}, "x", "y"),
+		},
+		{
+			Name:        "hover",
+			Description: "Move the mouse over an element without clicking. Opens dropdown menus and tooltips that appear on hover; call query_dom afterwards to see the new items",
+			InputSchema: objectSchema(map[string]interface{}{
+				"selector": map[string]interface{}{"type": "string", "description": "ref:N, CSS selector or text:Text"},
+			}, "selector"),
 		},
 		{
 			Name:        "scroll",
-			Description: anthropic.String("Scroll the page"),
-			InputSchema: anthropic.ToolInputSchemaParam{
-				Properties: map[string]interface{}{
-					"direction": map[string]interface{}{"type": "string", "enum": []string{"up", "down"}},
-				},
-				Required: []string{"direction"},
-			},
+			Description: "Scroll the page",
+			InputSchema: objectSchema(map[string]interface{}{
+				"direction": map[string]interface{}{"type": "string", "enum": []string{"up", "down"}},
+			}, "direction"),
+		},
+		{
+			Name:        "drag",
+			Description: "Drag an element with the mouse: cards between kanban columns, sortable list items, range sliders. Drop onto a target element, at x/y, or by an offset (sliders: offset_x from the current thumb). Reports what changed; fails if nothing did",
+			InputSchema: objectSchema(map[string]interface{}{
+				"selector": map[string]interface{}{"type": "string", "description": "Element to drag: ref:N, CSS or text:Text"},
+				"target":   map[string]interface{}{"type": "string", "description": "Element to drop onto: ref:N, CSS or text:Text"},
+				"x":        map[string]interface{}{"type": "integer", "description": "Drop X coordinate in the viewport (instead of target)"},
+				"y":        map[string]interface{}{"type": "integer", "description": "Drop Y coordinate in the viewport (instead of target)"},
+				"offset_x": map[string]interface{}{"type": "integer", "description": "Move by this many pixels right (negative = left)"},
+				"offset_y": map[string]interface{}{"type": "integer", "description": "Move by this many pixels down (negative = up)"},
+			}, "selector"),
+		},
+		{
+			Name:        "scroll_into_view",
+			Description: "Scroll the page and any scrollable containers (lists, modals, sidebars) until the element is in the middle of the screen",
+			InputSchema: objectSchema(map[string]interface{}{
+				"selector": map[string]interface{}{"type": "string", "description": "ref:N, CSS selector or text:Text"},
+			}, "selector"),
 		},
 	}
 }
@@ -1,39 +1,47 @@
 package tools
 
-import "github.com/anthropics/anthropic-sdk-go"
+import "github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
 
 // QueryTools - анализ и скриншоты
-func QueryTools() []anthropic.ToolParam {
-	return []anthropic.ToolParam{
+func QueryTools() []llm.Tool {
+	return []llm.Tool{
 		{
 			Name:        "take_screenshot",
-			Description: anthropic.String("Take screenshot of current page"),
-			InputSchema: anthropic.ToolInputSchemaParam{
-				Properties: map[string]interface{}{
-					"full_page": map[string]interface{}{"type": "boolean", "description": "Capture full page"},
-				},
-				Required: []string{},
-			},
+			Description: "Take screenshot of current page. With annotate=true interactive elements get numbered boxes and a legend; click them with ref:N",
+			InputSchema: objectSchema(map[string]interface{}{
+				"full_page": map[string]interface{}{"type": "boolean", "description": "Capture full page"},
+				"annotate":  map[string]interface{}{"type": "boolean", "description": "Draw numbered boxes over visible elements (viewport only)"},
+			}),
 		},
 		{
 			Name:        "query_dom",
-			Description: anthropic.String("Find clickable elements. Returns text: selectors"),
-			InputSchema: anthropic.ToolInputSchemaParam{
-				Properties: map[string]interface{}{
-					"query": map[string]interface{}{"type": "string", "description": "Optional filter"},
-				},
-				Required: []string{},
-			},
+			Description: "Find clickable elements. Returns numbered elements [N] (use as ref:N in click/type_text/select_option) with text: selectors",
+			InputSchema: objectSchema(map[string]interface{}{
+				"query": map[string]interface{}{"type": "string", "description": "Optional filter"},
+			}),
+		},
+		{
+			Name:        "read_page",
+			Description: "Read the main content of the page as Markdown (headings, lists, links, tables) without menus and footers. Use for articles, search results and tables. Long pages come in chunks: pass offset from the previous result to continue",
+			InputSchema: objectSchema(map[string]interface{}{
+				"offset": map[string]interface{}{"type": "integer", "description": "Character offset to start reading from (default 0)"},
+			}),
+		},
+		{
+			Name: "extract_data",
+			Description: "Extract structured data from the current page into JSON matching a JSON Schema (e.g. list of vacancies with salary and company). " +
+				"Results accumulate across pages into the task's structured result: call on each page of a list, then complete_task",
+			InputSchema: objectSchema(map[string]interface{}{
+				"instruction": map[string]interface{}{"type": "string", "description": "What to extract, e.g. 'all vacancies on the page'"},
+				"schema":      map[string]interface{}{"type": "object", "description": "JSON Schema of the data (omit if the user already provided a schema)"},
+			}, "instruction"),
 		},
 		{
 			Name:        "analyze_page",
-			Description: anthropic.String("Deep AI analysis of page structure and elements"),
-			InputSchema: anthropic.ToolInputSchemaParam{
-				Properties: map[string]interface{}{
-					"question": map[string]interface{}{"type": "string", "description": "Question about the page"},
-				},
-				Required: []string{"question"},
-			},
+			Description: "Deep AI analysis of page structure and elements",
+			InputSchema: objectSchema(map[string]interface{}{
+				"question": map[string]interface{}{"type": "string", "description": "Question about the page"},
+			}, "question"),
 		},
 	}
 }
@@ -1,18 +1,25 @@
 package tools
 
-import "github.com/anthropics/anthropic-sdk-go"
+import "github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
 
-// BrowserTools возвращает все инструменты для Claude
-func BrowserTools() []anthropic.ToolUnionParam {
-	var params []anthropic.ToolParam
-	params = append(params, NavigationTools()...)
-	params = append(params, InputTools()...)
-	params = append(params, QueryTools()...)
-	params = append(params, TabTools()...)
+// BrowserTools возвращает все инструменты для модели
+func BrowserTools() []llm.Tool {
+	var tools []llm.Tool
+	tools = append(tools, NavigationTools()...)
+	tools = append(tools, InputTools()...)
+	tools = append(tools, QueryTools()...)
+	tools = append(tools, TabTools()...)
+	return tools
+}
 
-	tools := make([]anthropic.ToolUnionParam, len(params))
-	for i, p := range params {
-		tools[i] = anthropic.ToolUnionParam{OfTool: &p}
+// objectSchema собирает JSON Schema объекта аргументов инструмента
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	if required == nil {
+		required = []string{}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
 	}
-	return tools
 }
@@ -3,6 +3,7 @@ package app
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -12,7 +13,6 @@ import (
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 
-	"github.com/Daniil-Sakharov/BrowserAgent/internal/agent"
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/config"
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/closer"
@@ -64,6 +64,9 @@ var (
 
 func init() {
 	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(traceCmd)
+	rootCmd.AddCommand(secretsCmd)
 }
 
 // New создает новое приложение
@@ -126,68 +129,7 @@ func (a *App) Run() error {
 		task := domain.NewTask(input)
 
 		// Устанавливаем callback для вывода прогресса
-		ag.SetProgressCallback(func(event agent.ProgressEvent) {
-			switch event.Type {
-			case "step":
-				fmt.Println() // Пустая строка для разделения
-				colorInfo.Printf("━━━ Шаг %d ━━━\n", event.Step)
-			case "waiting":
-				colorAssistant.Println("🤖 Agent: анализирую ситуацию...")
-			case "thinking":
-				colorAssistant.Println("🤖 Agent:")
-				lines := strings.Split(event.Reasoning, "\n")
-				for _, line := range lines {
-					if line != "" {
-						colorInfo.Printf("   %s\n", line)
-					}
-				}
-				if event.Tool != "" {
-					colorTool.Printf("   ➜ %s\n", event.Tool)
-				}
-			case "tool":
-				colorTool.Printf("🔧 %s", event.Tool)
-				if len(event.Params) > 0 {
-					for key, value := range event.Params {
-						if len(value) > 50 {
-							value = value[:50] + "..."
-						}
-						colorInfo.Printf(" %s=%s", key, value)
-					}
-				}
-				fmt.Println()
-			case "result":
-				if event.Success {
-					colorSuccess.Printf("   ✓ %s\n", truncateResult(event.Result))
-				} else {
-					colorError.Printf("   ✗ %s\n", truncateResult(event.Result))
-				}
-			case "subagent":
-				colorSubAgent := color.New(color.FgMagenta)
-				colorSubAgent.Printf("   🔎 SubAgent: поиск элементов...\n")
-			case "subagent_thinking":
-				colorSubAgent := color.New(color.FgMagenta)
-				colorSubAgent.Printf("   🔎 SubAgent: %s\n", truncateResult(event.Result))
-			case "subagent_result":
-				colorSubAgent := color.New(color.FgMagenta)
-				if event.Success && event.Result != "" {
-					// Считаем элементы
-					lines := strings.Split(event.Result, "\n")
-					elemCount := 0
-					for _, line := range lines {
-						if strings.Contains(line, "text:") {
-							elemCount++
-						}
-					}
-					if elemCount > 0 {
-						colorSubAgent.Printf("   ✓ SubAgent: найдено %d элементов\n", elemCount)
-					} else {
-						colorSubAgent.Printf("   ✓ SubAgent: анализ завершён\n")
-					}
-				}
-			case "error":
-				colorError.Printf("   ✗ Ошибка: %s\n", event.Result)
-			}
-		})
+		ag.SetProgressCallback(progressPrinter(os.Stdout))
 
 		// Выполняем
 		colorAssistant.Print("\nAssistant: ")
@@ -203,6 +145,13 @@ func (a *App) Run() error {
 		if task.Result != "" {
 			colorSuccess.Printf("\n✅ Результат: %s\n", task.Result)
 		}
+		if task.Data != nil {
+			data, _ := json.MarshalIndent(task.Data, "", "  ")
+			colorInfo.Printf("📦 Данные:\n%s\n", data)
+		}
+		printDownloads(os.Stdout, task.Downloads)
+		printExtracted(os.Stdout, task)
+		colorInfo.Println(formatUsage(task.Usage))
 	}
 }
 
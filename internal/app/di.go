@@ -2,7 +2,11 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+
+	"go.uber.org/zap"
 
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/agent"
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/ai"
@@ -12,9 +16,14 @@ import (
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm/claude"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm/openai"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm/replay"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/secrets"
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/security"
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/security/confirm"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/trace"
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/closer"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
 )
 
 // DIContainer контейнер зависимостей
@@ -25,16 +34,32 @@ type DIContainer struct {
 	llmProvider       llm.Provider
 	domSubAgent       *subagent.DOMSubAgent
 	agent             *agent.Agent
+	confirmPolicy     confirm.Policy
+	secrets           *secrets.Store
+	secretsLoaded     bool
 }
 
 // NewDIContainer создаёт новый контейнер
-func NewDIContainer() *DIContainer { return &DIContainer{} }
+func NewDIContainer() *DIContainer { return &DIContainer{confirmPolicy: confirm.PolicyPrompt} }
+
+// SetConfirmPolicy задаёт политику подтверждения (до создания SecurityChecker)
+func (d *DIContainer) SetConfirmPolicy(p confirm.Policy) { d.confirmPolicy = p }
 
 // BrowserController возвращает контроллер браузера
 func (d *DIContainer) BrowserController(ctx context.Context) *browser.Controller {
 	if d.browserController == nil {
 		cfg := config.AppConfig().Browser
-		ctrl, err := browser.New(ctx, cfg.Headless(), cfg.UserDataDir(), cfg.Timeout())
+		opts := browser.Options{
+			UploadDir:       cfg.UploadDir(),
+			DownloadDir:     cfg.DownloadDir(),
+			DownloadTimeout: time.Duration(cfg.DownloadTimeout()) * time.Second,
+			Extractor:       cfg.Extractor(),
+			DialogPolicy:    cfg.DialogPolicy(),
+		}
+		if store := d.Secrets(ctx); store != nil {
+			opts.Secrets = store
+		}
+		ctrl, err := browser.New(ctx, cfg.Headless(), cfg.UserDataDir(), cfg.Timeout(), opts)
 		if err != nil {
 			panic(fmt.Sprintf("browser: %s", err))
 		}
@@ -47,8 +72,15 @@ func (d *DIContainer) BrowserController(ctx context.Context) *browser.Controller
 // AIClient возвращает AI клиент
 func (d *DIContainer) AIClient(ctx context.Context) *ai.Client {
 	if d.aiClient == nil {
-		cfg := config.AppConfig().Anthropic
-		client, err := ai.New(ctx, cfg.APIKey(), cfg.BaseURL(), cfg.Model(), cfg.MaxTokens(), cfg.Temperature())
+		model, maxTokens, temperature := modelSettings()
+		agentCfg := config.AppConfig().Agent
+		compaction := ai.CompactionConfig{
+			KeepPageContexts:     agentCfg.ContextKeepPages(),
+			KeepImages:           agentCfg.ContextKeepImages(),
+			SummarizeAboveTokens: agentCfg.ContextSummarizeTokens(),
+			KeepRecentMessages:   agentCfg.ContextKeepMessages(),
+		}
+		client, err := ai.New(ctx, d.LLMProvider(ctx), model, maxTokens, temperature, compaction)
 		if err != nil {
 			panic(fmt.Sprintf("ai: %s", err))
 		}
@@ -63,6 +95,14 @@ func (d *DIContainer) SecurityChecker(ctx context.Context) *security.Checker {
 	if d.securityChecker == nil {
 		cfg := config.AppConfig().Security
 		callback := func(ctx context.Context, a domain.Action, r confirm.Risk) (bool, error) {
+			switch d.confirmPolicy {
+			case confirm.PolicyAllow:
+				logger.Warn(ctx, "⚠️ Auto-confirmed by policy", zap.String("action", string(a.Type)), zap.String("reason", r.Reason))
+				return true, nil
+			case confirm.PolicyDeny:
+				logger.Warn(ctx, "⛔ Rejected by policy", zap.String("action", string(a.Type)), zap.String("reason", r.Reason))
+				return false, nil
+			}
 			fmt.Println()
 			return confirm.Action(a, r)
 		}
@@ -76,24 +116,77 @@ func (d *DIContainer) SecurityChecker(ctx context.Context) *security.Checker {
 	return d.securityChecker
 }
 
+// Secrets возвращает хранилище секретов; nil если SECRETS_MASTER_KEY не задан.
+// С открытием хранилища логи начинают скрывать значения секретов
+func (d *DIContainer) Secrets(ctx context.Context) *secrets.Store {
+	if !d.secretsLoaded {
+		d.secretsLoaded = true
+		cfg := config.AppConfig().Secrets
+		store, err := secrets.Open(cfg.File(), cfg.MasterKey())
+		switch {
+		case errors.Is(err, secrets.ErrNoMasterKey):
+			return nil
+		case err != nil:
+			panic(fmt.Sprintf("secrets: %s", err))
+		}
+		logger.SetRedactor(store.Redact)
+		logger.Info(ctx, "🔑 Secrets loaded", zap.Int("count", len(store.Names())))
+		d.secrets = store
+	}
+	return d.secrets
+}
+
 // LLMProvider возвращает LLM провайдер
 func (d *DIContainer) LLMProvider(ctx context.Context) llm.Provider {
 	if d.llmProvider == nil {
-		cfg := config.AppConfig().Anthropic
-		provider, err := claude.New(cfg.APIKey(), cfg.BaseURL())
+		var (
+			provider llm.Provider
+			err      error
+		)
+		llmCfg := config.AppConfig().LLM
+		switch name := llmCfg.Provider(); name {
+		case "anthropic", "":
+			cfg := config.AppConfig().Anthropic
+			provider, err = claude.New(cfg.APIKey(), cfg.BaseURL(), cfg.PromptCache())
+		case "openai":
+			cfg := config.AppConfig().OpenAI
+			provider, err = openai.New(cfg.APIKey(), cfg.BaseURL())
+		case "replay":
+			provider, err = replay.New(llmCfg.ReplayScript())
+		default:
+			err = fmt.Errorf("unknown LLM_PROVIDER: %q", name)
+		}
 		if err != nil {
 			panic(fmt.Sprintf("llm: %s", err))
 		}
+		if path := llmCfg.RecordScript(); path != "" {
+			recorder := replay.NewRecorder(provider, path)
+			closer.AddNamed("llm-recorder", func(ctx context.Context) error { return recorder.Save() })
+			provider = recorder
+		}
+		if store := d.Secrets(ctx); store != nil {
+			provider = llm.WithRedaction(provider, store.Redact)
+		}
 		d.llmProvider = provider
 	}
 	return d.llmProvider
 }
 
+// modelSettings возвращает модель и параметры генерации выбранного провайдера
+func modelSettings() (model string, maxTokens int, temperature *float64) {
+	if config.AppConfig().LLM.Provider() == "openai" {
+		cfg := config.AppConfig().OpenAI
+		return cfg.Model(), cfg.MaxTokens(), cfg.Temperature()
+	}
+	cfg := config.AppConfig().Anthropic
+	return cfg.Model(), cfg.MaxTokens(), cfg.Temperature()
+}
+
 // DOMSubAgent возвращает DOM Sub-Agent
 func (d *DIContainer) DOMSubAgent(ctx context.Context) *subagent.DOMSubAgent {
 	if d.domSubAgent == nil {
-		cfg := config.AppConfig().Anthropic
-		d.domSubAgent = subagent.New(d.LLMProvider(ctx), cfg.Model(), 2048)
+		model, _, _ := modelSettings()
+		d.domSubAgent = subagent.New(d.LLMProvider(ctx), model, 2048)
 	}
 	return d.domSubAgent
 }
@@ -102,10 +195,22 @@ func (d *DIContainer) DOMSubAgent(ctx context.Context) *subagent.DOMSubAgent {
 func (d *DIContainer) Agent(ctx context.Context) *agent.Agent {
 	if d.agent == nil {
 		cfg := config.AppConfig().Agent
-		a, err := agent.New(ctx, d.BrowserController(ctx), d.AIClient(ctx), d.SecurityChecker(ctx), d.DOMSubAgent(ctx), cfg.MaxSteps(), cfg.Interactive(), cfg.Screenshots())
+		limits := agent.BudgetLimits{
+			MaxSteps:        cfg.MaxSteps(),
+			MaxDuration:     cfg.MaxDuration(),
+			MaxInputTokens:  cfg.MaxInputTokens(),
+			MaxOutputTokens: cfg.MaxOutputTokens(),
+		}
+		a, err := agent.New(ctx, d.BrowserController(ctx), d.AIClient(ctx), d.SecurityChecker(ctx), d.DOMSubAgent(ctx), limits, cfg.Interactive(), cfg.Screenshots())
 		if err != nil {
 			panic(fmt.Sprintf("agent: %s", err))
 		}
+		if store := d.Secrets(ctx); store != nil {
+			a.SetSecrets(store)
+		}
+		if cfg.Trace() {
+			a.AddObserver(trace.NewRecorder(cfg.TraceDir()))
+		}
 		closer.AddNamed("agent", func(ctx context.Context) error { return a.Close(ctx) })
 		d.agent = a
 	}
@@ -0,0 +1,242 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/security/confirm"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/jsonschema"
+)
+
+// Коды выхода для неинтерактивного режима
+const (
+	ExitCodeFailed      = 1   // задача завершилась с ошибкой
+	ExitCodeUsage       = 2   // некорректные аргументы
+	ExitCodeInterrupted = 130 // прервано сигналом
+)
+
+// ExitError ошибка с кодом завершения процесса
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// execFlags флаги команды exec
+var execFlags struct {
+	taskFile      string
+	confirmPolicy string
+	extractSchema string
+	outputSchema  string
+	output        string
+	json          bool
+}
+
+var execCmd = &cobra.Command{
+	Use:   "exec [task]",
+	Short: "Выполнить одну задачу без интерактивного режима",
+	Long: "Выполняет одну задачу и завершается. Результат печатается в stdout, прогресс - в stderr.\n" +
+		"С --json в stdout печатается JSON объект с итогом задачи, в том числе при ошибке.\n" +
+		"Код выхода: 0 - задача выполнена, 1 - ошибка, 2 - неверные аргументы, 130 - прервано.",
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return appInstance.Exec(args)
+	},
+}
+
+func init() {
+	execCmd.Flags().StringVarP(&execFlags.taskFile, "task-file", "f", "", "файл с текстом задачи ('-' = stdin)")
+	execCmd.Flags().StringVar(&execFlags.confirmPolicy, "confirm", "",
+		"подтверждение опасных действий: prompt, allow, deny (по умолчанию prompt при TTY, иначе deny)")
+	execCmd.Flags().StringVar(&execFlags.extractSchema, "extract-schema", "",
+		"файл с JSON Schema данных для extract_data; stdout - JSON массив собранных записей")
+	execCmd.Flags().StringVar(&execFlags.outputSchema, "output-schema", "",
+		"файл с JSON Schema результата: complete_task обязан вернуть data по схеме; stdout - data")
+	execCmd.Flags().BoolVar(&execFlags.json, "json", false,
+		"печатать в stdout итог задачи JSON объектом (status, result, data, extracted, downloads, error)")
+	execCmd.Flags().StringVarP(&execFlags.output, "output", "o", "", "записать итог задачи JSON объектом в файл")
+}
+
+// Exec выполняет одну задачу и возвращает ExitError если она не выполнена
+func (a *App) Exec(args []string) error {
+	description, err := readTaskDescription(args, execFlags.taskFile)
+	if err != nil {
+		return &ExitError{Code: ExitCodeUsage, Err: err}
+	}
+
+	policy, err := confirm.ParsePolicy(execFlags.confirmPolicy)
+	if err != nil {
+		return &ExitError{Code: ExitCodeUsage, Err: err}
+	}
+	a.di.SetConfirmPolicy(policy)
+
+	var extractSchema, outputSchema *jsonschema.Schema
+	if execFlags.extractSchema != "" {
+		if extractSchema, err = readSchemaFile(execFlags.extractSchema); err != nil {
+			return &ExitError{Code: ExitCodeUsage, Err: err}
+		}
+	}
+	if execFlags.outputSchema != "" {
+		if outputSchema, err = readSchemaFile(execFlags.outputSchema); err != nil {
+			return &ExitError{Code: ExitCodeUsage, Err: err}
+		}
+	}
+
+	ag := a.di.Agent(a.ctx)
+	ag.SetProgressCallback(progressPrinter(os.Stderr))
+
+	task := domain.NewTask(description)
+	if extractSchema != nil {
+		task.ExtractSchema = extractSchema.Map()
+	}
+	if outputSchema != nil {
+		task.OutputSchema = outputSchema.Map()
+	}
+	err = ag.Execute(a.ctx, task)
+	colorInfo.Fprintln(os.Stderr, formatUsage(task.Usage))
+	printDownloads(os.Stderr, task.Downloads)
+
+	completed := task.Status == domain.TaskStatusCompleted
+	if !completed {
+		if err == nil {
+			err = task.Error
+		}
+		if err == nil {
+			err = fmt.Errorf("task finished with status %s", task.Status)
+		}
+		colorError.Fprintf(os.Stderr, "❌ Ошибка: %v\n", err)
+	}
+
+	out := newTaskOutput(task, err)
+	if execFlags.output != "" {
+		if werr := writeJSONFile(execFlags.output, out); werr != nil {
+			colorError.Fprintf(os.Stderr, "❌ %v\n", werr)
+			if completed {
+				return &ExitError{Code: ExitCodeFailed, Err: werr}
+			}
+		}
+	}
+
+	switch {
+	case execFlags.json:
+		writeJSON(os.Stdout, out)
+	case !completed:
+		// Ошибка уже выведена в stderr
+	case outputSchema != nil:
+		// Со схемой stdout - данные для других программ, текстовый итог уходит в stderr
+		colorSuccess.Fprintf(os.Stderr, "✅ %s\n", task.Result)
+		writeJSON(os.Stdout, task.Data)
+	case extractSchema != nil:
+		colorSuccess.Fprintf(os.Stderr, "✅ %s\n", task.Result)
+		writeJSON(os.Stdout, out.Extracted)
+	default:
+		fmt.Fprintln(os.Stdout, task.Result)
+	}
+
+	if completed {
+		return nil
+	}
+	if a.ctx.Err() != nil {
+		return &ExitError{Code: ExitCodeInterrupted, Err: err}
+	}
+	return &ExitError{Code: ExitCodeFailed, Err: err}
+}
+
+// taskOutput итог задачи в JSON для других программ (--json, --output)
+type taskOutput struct {
+	ID        string            `json:"id"`
+	Status    string            `json:"status"`
+	Result    string            `json:"result,omitempty"`
+	Data      interface{}       `json:"data,omitempty"`
+	Extracted interface{}       `json:"extracted"`
+	Downloads []domain.Download `json:"downloads,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+func newTaskOutput(task *domain.Task, err error) taskOutput {
+	out := taskOutput{
+		ID: task.ID, Status: string(task.Status),
+		Result: task.Result, Data: task.Data,
+		Extracted: task.Extracted, Downloads: task.Downloads,
+	}
+	if out.Extracted == nil {
+		out.Extracted = []interface{}{}
+	}
+	if task.Status != domain.TaskStatusCompleted && err != nil {
+		out.Error = err.Error()
+	}
+	return out
+}
+
+// readSchemaFile читает и проверяет JSON Schema из файла
+func readSchemaFile(path string) (*jsonschema.Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read schema file: %w", err)
+	}
+	schema, err := jsonschema.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// writeJSON печатает значение JSON с отступами
+func writeJSON(w io.Writer, v interface{}) {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	fmt.Fprintln(w, string(data))
+}
+
+// writeJSONFile записывает значение JSON с отступами в файл
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+	return nil
+}
+
+// readTaskDescription читает текст задачи из аргумента или файла
+func readTaskDescription(args []string, taskFile string) (string, error) {
+	if len(args) > 0 && taskFile != "" {
+		return "", errors.New("use either task argument or --task-file, not both")
+	}
+
+	var description string
+	switch {
+	case taskFile == "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("read stdin: %w", err)
+		}
+		description = string(data)
+	case taskFile != "":
+		data, err := os.ReadFile(taskFile)
+		if err != nil {
+			return "", fmt.Errorf("read task file: %w", err)
+		}
+		description = string(data)
+	case len(args) > 0:
+		description = args[0]
+	}
+
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return "", errors.New("task is empty")
+	}
+	return description, nil
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/agent"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+)
+
+// progressPrinter возвращает callback, печатающий прогресс агента в w
+func progressPrinter(w io.Writer) agent.ProgressCallback {
+	return func(event agent.ProgressEvent) {
+		switch event.Type {
+		case "step":
+			fmt.Fprintln(w) // Пустая строка для разделения
+			colorInfo.Fprintf(w, "━━━ Шаг %d ━━━%s\n", event.Step, formatBudget(event.Budget))
+		case "waiting":
+			colorAssistant.Fprintln(w, "🤖 Agent: анализирую ситуацию...")
+		case "thinking":
+			colorAssistant.Fprintln(w, "🤖 Agent:")
+			lines := strings.Split(event.Reasoning, "\n")
+			for _, line := range lines {
+				if line != "" {
+					colorInfo.Fprintf(w, "   %s\n", line)
+				}
+			}
+			if event.Tool != "" {
+				colorTool.Fprintf(w, "   ➜ %s\n", event.Tool)
+			}
+		case "tool":
+			colorTool.Fprintf(w, "🔧 %s", event.Tool)
+			if len(event.Params) > 0 {
+				for key, value := range event.Params {
+					if len(value) > 50 {
+						value = value[:50] + "..."
+					}
+					colorInfo.Fprintf(w, " %s=%s", key, value)
+				}
+			}
+			fmt.Fprintln(w)
+		case "result":
+			if event.Success {
+				colorSuccess.Fprintf(w, "   ✓ %s\n", truncateResult(event.Result))
+			} else {
+				colorError.Fprintf(w, "   ✗ %s\n", truncateResult(event.Result))
+			}
+		case "subagent":
+			colorSubAgent := color.New(color.FgMagenta)
+			colorSubAgent.Fprintf(w, "   🔎 SubAgent: поиск элементов...\n")
+		case "subagent_thinking":
+			colorSubAgent := color.New(color.FgMagenta)
+			colorSubAgent.Fprintf(w, "   🔎 SubAgent: %s\n", truncateResult(event.Result))
+		case "subagent_result":
+			colorSubAgent := color.New(color.FgMagenta)
+			if event.Success && event.Result != "" {
+				// Считаем элементы
+				lines := strings.Split(event.Result, "\n")
+				elemCount := 0
+				for _, line := range lines {
+					if strings.Contains(line, "text:") {
+						elemCount++
+					}
+				}
+				if elemCount > 0 {
+					colorSubAgent.Fprintf(w, "   ✓ SubAgent: найдено %d элементов\n", elemCount)
+				} else {
+					colorSubAgent.Fprintf(w, "   ✓ SubAgent: анализ завершён\n")
+				}
+			}
+		case "error":
+			colorError.Fprintf(w, "   ✗ Ошибка: %s\n", event.Result)
+		case "budget":
+			colorError.Fprintf(w, "   ⛔ Бюджет исчерпан: %s\n", event.Result)
+		}
+	}
+}
+
+// formatUsage форматирует расход токенов задачи с учётом кэша промпта
+func formatUsage(u domain.TokenUsage) string {
+	out := fmt.Sprintf("📊 Токены: вход %d, выход %d", u.InputTokens, u.OutputTokens)
+	if u.CacheReadTokens > 0 || u.CacheCreationTokens > 0 {
+		out += fmt.Sprintf(", кэш: прочитано %d, записано %d", u.CacheReadTokens, u.CacheCreationTokens)
+	}
+	return out
+}
+
+// printDownloads выводит файлы, скачанные во время задачи
+func printDownloads(w io.Writer, downloads []domain.Download) {
+	if len(downloads) == 0 {
+		return
+	}
+	colorInfo.Fprintf(w, "📥 Загрузки (%d):\n", len(downloads))
+	for _, d := range downloads {
+		colorInfo.Fprintf(w, "   %s\n", d)
+	}
+}
+
+// printExtracted выводит данные, собранные extract_data
+func printExtracted(w io.Writer, task *domain.Task) {
+	if task.Extracted == nil {
+		return
+	}
+	data, _ := json.MarshalIndent(task.Extracted, "", "  ")
+	colorInfo.Fprintf(w, "📊 Извлечённые данные (%d):\n%s\n", task.ExtractedCount(), data)
+}
+
+// formatBudget форматирует расход бюджета для заголовка шага
+func formatBudget(b *agent.BudgetStatus) string {
+	if b == nil {
+		return ""
+	}
+	var parts []string
+	if b.MaxSteps > 0 {
+		parts = append(parts, fmt.Sprintf("шаги %d/%d", b.Steps, b.MaxSteps))
+	}
+	if b.MaxDuration > 0 {
+		parts = append(parts, fmt.Sprintf("время %s/%s", b.Elapsed.Round(time.Second), b.MaxDuration))
+	}
+	if b.MaxInputTokens > 0 {
+		parts = append(parts, fmt.Sprintf("вход %d/%d tok", b.InputTokens, b.MaxInputTokens))
+	}
+	if b.MaxOutputTokens > 0 {
+		parts = append(parts, fmt.Sprintf("выход %d/%d tok", b.OutputTokens, b.MaxOutputTokens))
+	}
+	if b.CacheReadTokens > 0 {
+		parts = append(parts, fmt.Sprintf("кэш %d tok", b.CacheReadTokens))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/config"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/secrets"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/security/confirm"
+)
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Хранилище паролей и токенов для задач (SECRETS_FILE, SECRETS_MASTER_KEY)",
+	Long: "Секреты хранятся в локальном файле, значения зашифрованы мастер-ключом из SECRETS_MASTER_KEY.\n" +
+		"В тексте задачи пишите {{secret:name}}: значение подставится только при вводе в поле,\n" +
+		"а в модель, логи и вывод прогресса попадёт плейсхолдер.",
+}
+
+var secretsSetCmd = &cobra.Command{
+	Use:           "set <name>",
+	Short:         "Сохранить секрет (значение вводится без эха или читается из stdin)",
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return appInstance.SecretsSet(args[0])
+	},
+}
+
+var secretsListCmd = &cobra.Command{
+	Use:           "list",
+	Short:         "Показать имена сохранённых секретов",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return appInstance.SecretsList()
+	},
+}
+
+var secretsRmCmd = &cobra.Command{
+	Use:           "rm <name>",
+	Short:         "Удалить секрет",
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return appInstance.SecretsRemove(args[0])
+	},
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsSetCmd, secretsListCmd, secretsRmCmd)
+}
+
+// SecretsSet сохраняет секрет; значение не передаётся аргументом, чтобы не попасть в историю shell
+func (a *App) SecretsSet(name string) error {
+	store, err := openSecrets()
+	if err != nil {
+		return err
+	}
+	value, err := readSecretValue(name)
+	if err != nil {
+		return err
+	}
+	if err := store.Set(name, value); err != nil {
+		return err
+	}
+	colorSuccess.Printf("✅ Секрет сохранён: %s\n", secrets.Placeholder(name))
+	return nil
+}
+
+// SecretsList печатает имена секретов в виде плейсхолдеров для задач
+func (a *App) SecretsList() error {
+	store, err := openSecrets()
+	if err != nil {
+		return err
+	}
+	names := store.Names()
+	if len(names) == 0 {
+		colorInfo.Println("Секретов нет. Добавьте: agent secrets set <name>")
+		return nil
+	}
+	for _, name := range names {
+		fmt.Println(secrets.Placeholder(name))
+	}
+	return nil
+}
+
+// SecretsRemove удаляет секрет
+func (a *App) SecretsRemove(name string) error {
+	store, err := openSecrets()
+	if err != nil {
+		return err
+	}
+	if err := store.Remove(name); err != nil {
+		return err
+	}
+	colorSuccess.Printf("🗑️ Секрет удалён: %s\n", name)
+	return nil
+}
+
+func openSecrets() (*secrets.Store, error) {
+	cfg := config.AppConfig().Secrets
+	store, err := secrets.Open(cfg.File(), cfg.MasterKey())
+	if err != nil {
+		return nil, fmt.Errorf("open secrets %s: %w", cfg.File(), err)
+	}
+	return store, nil
+}
+
+// readSecretValue читает значение из терминала без эха или из stdin (echo ... | agent secrets set)
+func readSecretValue(name string) (string, error) {
+	if confirm.HasTTY() {
+		fmt.Fprintf(os.Stderr, "Значение %s: ", name)
+		value, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("read value: %w", err)
+		}
+		return string(value), nil
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("read value: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
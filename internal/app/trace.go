@@ -0,0 +1,65 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/trace"
+)
+
+// traceViewFlags флаги команды trace view
+var traceViewFlags struct {
+	output string
+}
+
+var traceCmd = &cobra.Command{
+	Use:   "trace",
+	Short: "Работа с записанными прогонами (AGENT_TRACE)",
+}
+
+var traceViewCmd = &cobra.Command{
+	Use:   "view <run-dir>",
+	Short: "Собрать HTML отчёт по записанному прогону",
+	Long: "Превращает директорию прогона (steps.jsonl, task.json, скриншоты, HTML снимки) в самодостаточный HTML отчёт\n" +
+		"с таймлайном шагов. По умолчанию отчёт пишется в <run-dir>/report.html.",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return appInstance.TraceView(args[0], traceViewFlags.output)
+	},
+}
+
+func init() {
+	traceViewCmd.Flags().StringVarP(&traceViewFlags.output, "output", "o", "", "файл отчёта ('-' = stdout)")
+	traceCmd.AddCommand(traceViewCmd)
+}
+
+// TraceView собирает HTML отчёт по директории прогона
+func (a *App) TraceView(runDir, output string) error {
+	run, err := trace.ReadRun(runDir)
+	if err != nil {
+		return err
+	}
+
+	if output == "-" {
+		return trace.RenderHTML(os.Stdout, run)
+	}
+	if output == "" {
+		output = filepath.Join(runDir, "report.html")
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("create report: %w", err)
+	}
+	defer f.Close()
+
+	if err := trace.RenderHTML(f, run); err != nil {
+		return fmt.Errorf("render report: %w", err)
+	}
+	colorSuccess.Printf("✅ Отчёт: %s (%d шагов)\n", output, len(run.Steps))
+	return nil
+}
@@ -3,6 +3,7 @@ package action
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -10,22 +11,92 @@ import (
 	"github.com/go-rod/rod/lib/proto"
 	"go.uber.org/zap"
 
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/browser/dom"
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
 )
 
-// Click выполняет умный клик с цепочкой fallback
+// Click выполняет умный клик с цепочкой fallback. Селектор может вести внутрь
+// iframe и shadow root: iframe#pay >> text:Оплатить. ref:N кликает ровно по узлу
+// из последнего query_dom без поиска
 func Click(ctx context.Context, p PageProvider, selector string) error {
-	page := p.GetPage()
+	if n, ok := ParseRef(selector); ok {
+		return clickRef(ctx, p, n)
+	}
+
+	path, last := dom.SplitChain(selector)
+	scope, err := dom.ResolveScope(p.GetPage(), path)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(last, "text:") {
+		text := strings.TrimPrefix(last, "text:")
+		return smartClickText(ctx, p, scope, text)
+	}
+	return smartClickCSS(ctx, p, scope, last)
+}
+
+// ParseRef разбирает селектор вида ref:17 (номер элемента из query_dom)
+func ParseRef(selector string) (int, bool) {
+	s, ok := strings.CutPrefix(strings.TrimSpace(selector), "ref:")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.Trim(s, "[] "))
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// Locate находит элемент по ref:N, CSS, text: или цепочке через iframe/shadow root
+func Locate(p PageProvider, selector string, timeout time.Duration) (*rod.Element, error) {
+	if n, ok := ParseRef(selector); ok {
+		return p.ResolveRef(n)
+	}
+
+	path, last := dom.SplitChain(selector)
+	scope, err := dom.ResolveScope(p.GetPage(), path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(last, "text:") {
+		return scope.Element(last, timeout)
+	}
+	text := strings.TrimPrefix(last, "text:")
+	if elem, err := findElementByText(scope, text, true); err == nil {
+		return elem, nil
+	}
+	return findElementByText(scope, text, false)
+}
+
+// locateError ошибка поиска элемента; для ref:N сохраняет причину (устарел/неизвестен)
+func locateError(selector string, err error) error {
+	if _, ok := ParseRef(selector); ok {
+		return err
+	}
+	return fmt.Errorf("element not found: %s", selector)
+}
 
-	if strings.HasPrefix(selector, "text:") {
-		text := strings.TrimPrefix(selector, "text:")
-		return smartClickText(ctx, p, page, text)
+// clickRef кликает по узлу из query_dom; если он перекрыт - кликает через JS
+func clickRef(ctx context.Context, p PageProvider, n int) error {
+	elem, err := p.ResolveRef(n)
+	if err != nil {
+		return err
+	}
+	if err := doClick(ctx, p, elem); err != nil {
+		logger.Debug(ctx, "🔄 Ref click via JS", zap.Int("ref", n), zap.Error(err))
+		if _, jsErr := elem.Eval(`() => { this.scrollIntoView({block: 'center'}); this.click(); }`); jsErr != nil {
+			return fmt.Errorf("click ref:%d: %w", n, err)
+		}
+		p.WaitStable(3 * time.Second)
 	}
-	return smartClickCSS(ctx, p, page, selector)
+	logger.Info(ctx, "✅ Click ref", zap.Int("ref", n))
+	return nil
 }
 
 // smartClickText - умный клик по тексту с fallback цепочкой
-func smartClickText(ctx context.Context, p PageProvider, page *rod.Page, text string) error {
+func smartClickText(ctx context.Context, p PageProvider, scope dom.Scope, text string) error {
 	// Убираем ... в конце если есть
 	text = strings.TrimSuffix(text, "...")
 
@@ -33,10 +104,10 @@ func smartClickText(ctx context.Context, p PageProvider, page *rod.Page, text st
 		name string
 		fn   func() error
 	}{
-		{"exact", func() error { return tryClickText(ctx, p, page, text, true) }},
-		{"partial", func() error { return tryClickText(ctx, p, page, text, false) }},
-		{"short", func() error { return tryClickText(ctx, p, page, getShortText(text), false) }},
-		{"js_smart", func() error { return jsSmartClick(ctx, page, text) }},
+		{"exact", func() error { return tryClickText(ctx, p, scope, text, true) }},
+		{"partial", func() error { return tryClickText(ctx, p, scope, text, false) }},
+		{"short", func() error { return tryClickText(ctx, p, scope, getShortText(text), false) }},
+		{"js_smart", func() error { return jsSmartClick(ctx, scope, text) }},
 	}
 
 	for _, a := range attempts {
@@ -51,13 +122,13 @@ func smartClickText(ctx context.Context, p PageProvider, page *rod.Page, text st
 }
 
 // smartClickCSS - умный клик по CSS с fallback
-func smartClickCSS(ctx context.Context, p PageProvider, page *rod.Page, selector string) error {
+func smartClickCSS(ctx context.Context, p PageProvider, scope dom.Scope, selector string) error {
 	attempts := []struct {
 		name string
 		fn   func() error
 	}{
-		{"rod", func() error { return tryClickCSS(ctx, p, page, selector) }},
-		{"js", func() error { return jsClickCSS(page, selector) }},
+		{"rod", func() error { return tryClickCSS(ctx, p, scope, selector) }},
+		{"js", func() error { return jsClickCSS(scope, selector) }},
 	}
 
 	for _, a := range attempts {
@@ -70,8 +141,8 @@ func smartClickCSS(ctx context.Context, p PageProvider, page *rod.Page, selector
 }
 
 // tryClickText пробует кликнуть по тексту через Rod
-func tryClickText(ctx context.Context, p PageProvider, page *rod.Page, text string, exact bool) error {
-	elem, err := findElementByText(page, text, exact)
+func tryClickText(ctx context.Context, p PageProvider, scope dom.Scope, text string, exact bool) error {
+	elem, err := findElementByText(scope, text, exact)
 	if err != nil {
 		return err
 	}
@@ -79,8 +150,8 @@ func tryClickText(ctx context.Context, p PageProvider, page *rod.Page, text stri
 }
 
 // tryClickCSS пробует кликнуть по CSS через Rod
-func tryClickCSS(ctx context.Context, p PageProvider, page *rod.Page, selector string) error {
-	elem, err := page.Timeout(5 * time.Second).Element(selector)
+func tryClickCSS(ctx context.Context, p PageProvider, scope dom.Scope, selector string) error {
+	elem, err := scope.Element(selector, 5*time.Second)
 	if err != nil {
 		return err
 	}
@@ -113,19 +184,20 @@ func doClick(ctx context.Context, p PageProvider, elem *rod.Element) error {
 	return nil
 }
 
-// findElementByText ищет элемент по тексту
-func findElementByText(page *rod.Page, text string, exact bool) (*rod.Element, error) {
+// findElementByText ищет элемент по тексту, в том числе внутри открытых shadow roots
+func findElementByText(scope dom.Scope, text string, exact bool) (*rod.Element, error) {
 	matchType := "includes"
 	if exact {
 		matchType = "exact"
 	}
 
-	js := `(text, matchType) => {
+	js := `(text, matchType) => {` + dom.JSHelpers + `
 		const t = text.toLowerCase();
 		const selectors = ['button','a','[role="button"]','[role="link"]','div[onclick]','span[onclick]','li','label','h1','h2','h3','h4'];
+		const roots = deepRoots(this.querySelectorAll ? this : document);
 		
 		for (const sel of selectors) {
-			for (const el of document.querySelectorAll(sel)) {
+			for (const el of roots.flatMap(r => Array.from(r.root.querySelectorAll(sel)))) {
 				const rect = el.getBoundingClientRect();
 				if (rect.width === 0 || rect.height === 0) continue;
 				
@@ -148,20 +220,14 @@ func findElementByText(page *rod.Page, text string, exact bool) (*rod.Element, e
 		return null;
 	}`
 
-	result, err := page.Timeout(5*time.Second).Eval(js, text, matchType)
-	if err != nil || result.Value.Nil() {
-		return nil, fmt.Errorf("not found")
-	}
-
-	return page.ElementFromObject(&proto.RuntimeRemoteObject{
-		ObjectID: proto.RuntimeRemoteObjectID(result.Value.Get("objectId").String()),
-	})
+	return scope.ElementByJS(5*time.Second, js, text, matchType)
 }
 
 // jsSmartClick - умный JS клик с несколькими стратегиями
-func jsSmartClick(ctx context.Context, page *rod.Page, text string) error {
-	js := `(searchText) => {
+func jsSmartClick(ctx context.Context, scope dom.Scope, text string) error {
+	js := `(searchText) => {` + dom.JSHelpers + `
 		const t = searchText.toLowerCase();
+		const roots = deepRoots(this.querySelectorAll ? this : document);
 		const words = t.split(' ').filter(w => w.length > 2);
 		
 		// Стратегия 1: точное совпадение
@@ -178,7 +244,7 @@ func jsSmartClick(ctx context.Context, page *rod.Page, text string) error {
 		
 		for (const strategy of strategies) {
 			for (const sel of selectors) {
-				for (const el of document.querySelectorAll(sel)) {
+				for (const el of roots.flatMap(r => Array.from(r.root.querySelectorAll(sel)))) {
 					const rect = el.getBoundingClientRect();
 					if (rect.width === 0 || rect.height === 0) continue;
 					if (rect.top < 0 || rect.top > window.innerHeight) continue;
@@ -194,7 +260,7 @@ func jsSmartClick(ctx context.Context, page *rod.Page, text string) error {
 		return {ok: false};
 	}`
 
-	result, err := page.Eval(js, text)
+	result, err := scope.Eval(js, text)
 	if err != nil || result == nil || !result.Value.Get("ok").Bool() {
 		return fmt.Errorf("js click failed")
 	}
@@ -204,15 +270,15 @@ func jsSmartClick(ctx context.Context, page *rod.Page, text string) error {
 }
 
 // jsClickCSS - JS клик по CSS селектору
-func jsClickCSS(page *rod.Page, selector string) error {
+func jsClickCSS(scope dom.Scope, selector string) error {
 	js := `(sel) => {
-		const el = document.querySelector(sel);
+		const el = (this.querySelector ? this : document).querySelector(sel);
 		if (!el) return {ok: false};
 		el.scrollIntoView({block: 'center'});
 		el.click();
 		return {ok: true};
 	}`
-	result, _ := page.Eval(js, selector)
+	result, _ := scope.Eval(js, selector)
 	if result == nil || !result.Value.Get("ok").Bool() {
 		return fmt.Errorf("js css click failed")
 	}
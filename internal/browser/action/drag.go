@@ -0,0 +1,357 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"go.uber.org/zap"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
+)
+
+const (
+	dragSteps     = 20 // промежуточных движений мыши от источника до цели
+	dragThreshold = 6  // сдвиг в пикселях, после которого библиотеки начинают перетаскивание
+)
+
+// DragTarget куда перетащить: элемент, точка viewport или смещение от источника
+type DragTarget struct {
+	Selector string
+	X, Y     int  // координаты viewport, если Selector пуст и смещения нет
+	Point    bool // X, Y заданы явно: точка (0,0) тоже допустима
+	DX, DY   int  // смещение от точки захвата источника
+}
+
+func (t DragTarget) String() string {
+	switch {
+	case t.Selector != "":
+		return t.Selector
+	case t.DX != 0 || t.DY != 0:
+		return fmt.Sprintf("offset (%+d,%+d)", t.DX, t.DY)
+	default:
+		return fmt.Sprintf("(%d,%d)", t.X, t.Y)
+	}
+}
+
+// DragResult что произошло после перетаскивания
+type DragResult struct {
+	From, To proto.Point
+	Method   string   // mouse или html5
+	Changes  []string // подтверждения эффекта: сдвиг элемента, новое значение, изменения DOM
+}
+
+// DragLocateError не найден источник или цель перетаскивания
+type DragLocateError struct {
+	Selector string
+	Target   bool // не найдена цель, а не источник
+	Err      error
+}
+
+func (e *DragLocateError) Error() string { return e.Err.Error() }
+func (e *DragLocateError) Unwrap() error { return e.Err }
+
+// dragState состояние источника до и после перетаскивания
+type dragState struct {
+	box   *proto.DOMRect
+	value string
+}
+
+// Drag перетаскивает элемент последовательностью движений мыши, как человек. Если
+// эффекта нет, а источник использует HTML5 drag and drop (draggable) - повторяет через
+// события dragstart/dragover/drop. Ошибка, если страница никак не изменилась
+func Drag(ctx context.Context, p PageProvider, selector string, target DragTarget) (*DragResult, error) {
+	logger.Info(ctx, "✋ Dragging", zap.String("from", selector), zap.String("to", target.String()))
+	if target.Selector == "" && target.DX == 0 && target.DY == 0 && !target.Point {
+		return nil, errors.New("drag target is required: target selector, x/y or offset_x/offset_y")
+	}
+
+	src, err := Locate(p, selector, p.GetTimeout())
+	if err != nil {
+		return nil, &DragLocateError{Selector: selector, Err: locateError(selector, err)}
+	}
+	if err := src.ScrollIntoView(); err != nil {
+		logger.Debug(ctx, "ScrollIntoView failed", zap.Error(err))
+	}
+	from, html5, err := grabPoint(src)
+	if err != nil {
+		return nil, err
+	}
+	to, dst, err := dropPoint(p, from, target)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkInViewport(p.GetPage(), to); err != nil {
+		return nil, err
+	}
+
+	before := readDragState(src)
+	watch := func() {
+		if err := watchDrag(src, dst, from, to); err != nil {
+			logger.Debug(ctx, "Mutation observer unavailable", zap.Error(err))
+		}
+	}
+	watch()
+
+	res := &DragResult{From: from, To: to, Method: "mouse"}
+	if html5 {
+		// Нативный drag из CDP мыши не стартует - сразу события HTML5
+		res.Method = "html5"
+		err = html5Drag(src, from, to)
+	} else {
+		err = mouseDrag(p.GetPage(), from, to)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("drag failed: %w", err)
+	}
+	p.WaitStable(2 * time.Second)
+
+	var dropped bool
+	res.Changes, dropped = dragChanges(src, before)
+	// Страница уже приняла drop - повтор через HTML5 события уронил бы элемент второй раз
+	if len(res.Changes) == 0 && !html5 && !dropped {
+		logger.Info(ctx, "🔁 No effect from mouse drag, trying HTML5 drag events")
+		watch()
+		if err := html5Drag(src, from, to); err == nil {
+			p.WaitStable(2 * time.Second)
+			res.Method = "html5"
+			res.Changes, _ = dragChanges(src, before)
+		}
+	}
+	if len(res.Changes) == 0 {
+		return nil, fmt.Errorf("drag had no visible effect: element did not move, value and page content unchanged")
+	}
+
+	logger.Info(ctx, "✅ Drag completed", zap.String("method", res.Method), zap.Strings("changes", res.Changes))
+	return res, nil
+}
+
+// grabPoint точка, за которую берём элемент: центр, а у input[type=range] - бегунок.
+// Второе значение - источник перетаскивается через HTML5 drag and drop
+func grabPoint(el *rod.Element) (proto.Point, bool, error) {
+	box, err := elementBox(el)
+	if err != nil {
+		return proto.Point{}, false, fmt.Errorf("source is not visible: %w", err)
+	}
+	info, err := el.Eval(grabInfoJS)
+	if err != nil {
+		return proto.Point{}, false, fmt.Errorf("source info: %w", err)
+	}
+	pt := proto.Point{X: box.X + box.Width/2, Y: box.Y + box.Height/2}
+	if ratio := info.Value.Get("thumb"); !ratio.Nil() {
+		pt.X = box.X + ratio.Num()*box.Width
+	}
+	return pt, info.Value.Get("draggable").Bool(), nil
+}
+
+// dropPoint точка, куда отпускаем, и элемент цели, если он задан селектором
+func dropPoint(p PageProvider, from proto.Point, t DragTarget) (proto.Point, *rod.Element, error) {
+	switch {
+	case t.Selector != "":
+		el, err := Locate(p, t.Selector, p.GetTimeout())
+		if err != nil {
+			return proto.Point{}, nil, &DragLocateError{Selector: t.Selector, Target: true, Err: locateError(t.Selector, err)}
+		}
+		box, err := elementBox(el)
+		if err != nil {
+			return proto.Point{}, nil, fmt.Errorf("target is not visible: %w", err)
+		}
+		return proto.Point{X: box.X + box.Width/2, Y: box.Y + box.Height/2}, el, nil
+	case t.DX != 0 || t.DY != 0:
+		return proto.Point{X: from.X + float64(t.DX), Y: from.Y + float64(t.DY)}, nil, nil
+	default:
+		return proto.Point{X: float64(t.X), Y: float64(t.Y)}, nil, nil
+	}
+}
+
+func elementBox(el *rod.Element) (*proto.DOMRect, error) {
+	shape, err := el.Shape()
+	if err != nil {
+		return nil, err
+	}
+	box := shape.Box()
+	if box == nil || box.Width < 1 || box.Height < 1 {
+		return nil, errors.New("element has no size")
+	}
+	return box, nil
+}
+
+// checkInViewport мышь не может отпустить элемент за пределами экрана
+func checkInViewport(page *rod.Page, pt proto.Point) error {
+	vp, err := page.Eval(`() => ({w: window.innerWidth, h: window.innerHeight})`)
+	if err != nil {
+		return nil
+	}
+	w, h := vp.Value.Get("w").Num(), vp.Value.Get("h").Num()
+	if pt.X < 0 || pt.Y < 0 || pt.X >= w || pt.Y >= h {
+		return fmt.Errorf("drop point (%.0f,%.0f) is outside the viewport %.0fx%.0f: scroll so both elements are visible", pt.X, pt.Y, w, h)
+	}
+	return nil
+}
+
+// mouseDrag нажимает кнопку на источнике, сдвигается за порог начала перетаскивания
+// и ведёт мышь к цели плавно, с паузой перед отпусканием
+func mouseDrag(page *rod.Page, from, to proto.Point) error {
+	mouse := page.Mouse
+	if err := mouse.MoveLinear(from, 5); err != nil {
+		return err
+	}
+	if err := mouse.Down(proto.InputMouseButtonLeft, 1); err != nil {
+		return err
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	move := func() error {
+		dx, dy := to.X-from.X, to.Y-from.Y
+		if dist := math.Hypot(dx, dy); dist > dragThreshold {
+			step := proto.Point{X: from.X + dx/dist*dragThreshold, Y: from.Y + dy/dist*dragThreshold}
+			if err := mouse.MoveLinear(step, 2); err != nil {
+				return err
+			}
+		}
+		return mouse.MoveLinear(to, dragSteps)
+	}
+	if err := move(); err != nil {
+		// Не оставляем кнопку нажатой - иначе следующие клики станут перетаскиванием
+		mouse.Up(proto.InputMouseButtonLeft, 1)
+		return err
+	}
+	time.Sleep(150 * time.Millisecond) // цели подсвечиваются и принимают dragover
+	return mouse.Up(proto.InputMouseButtonLeft, 1)
+}
+
+// html5Drag генерирует события HTML5 drag and drop с общим DataTransfer. Цель - элемент
+// под точкой отпускания в документе источника
+func html5Drag(src *rod.Element, from, to proto.Point) error {
+	res, err := src.Eval(html5DragJS, to.X-from.X, to.Y-from.Y)
+	if err != nil {
+		return err
+	}
+	if msg := res.Value.Str(); msg != "" {
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func readDragState(el *rod.Element) dragState {
+	var s dragState
+	if box, err := elementBox(el); err == nil {
+		s.box = box
+	}
+	if res, err := el.Eval(dragValueJS); err == nil {
+		s.value = res.Value.Str()
+	}
+	return s
+}
+
+// watchDrag до конца перетаскивания считает изменения DOM в контейнере источника и в цели
+// (элементе dst или элементе под точкой отпускания) и запоминает, было ли событие drop.
+// Изменения в остальном документе - таймеры, спиннеры, реклама - эффектом не считаются
+func watchDrag(src, dst *rod.Element, from, to proto.Point) error {
+	var target *proto.RuntimeRemoteObject
+	if dst != nil {
+		target = dst.Object
+	}
+	_, err := src.Eval(watchDragJS, to.X-from.X, to.Y-from.Y, target)
+	return err
+}
+
+// dragChanges сравнивает состояние источника и документа с тем, что было до перетаскивания.
+// Второе значение - страница получила событие drop
+func dragChanges(el *rod.Element, before dragState) ([]string, bool) {
+	var changes []string
+	after := readDragState(el)
+	switch {
+	case before.box != nil && after.box == nil:
+		changes = append(changes, "source element was re-rendered or removed")
+	case before.box != nil && after.box != nil:
+		if dx, dy := after.box.X-before.box.X, after.box.Y-before.box.Y; math.Abs(dx) >= 2 || math.Abs(dy) >= 2 {
+			changes = append(changes, fmt.Sprintf("source moved by (%+.0f,%+.0f)", dx, dy))
+		}
+	}
+	if after.value != before.value {
+		changes = append(changes, fmt.Sprintf("value %q → %q", before.value, after.value))
+	}
+	var dropped bool
+	if res, err := el.Eval(takeDragJS); err == nil {
+		if n := res.Value.Get("mutations").Int(); n > 0 {
+			changes = append(changes, fmt.Sprintf("%d DOM change(s)", n))
+		}
+		dropped = res.Value.Get("dropped").Bool()
+	}
+	return changes, dropped
+}
+
+// grabInfoJS: thumb - доля положения бегунка input[type=range] по ширине
+const grabInfoJS = `() => {
+	let thumb = null;
+	if (this.tagName === 'INPUT' && this.type === 'range') {
+		const min = this.min === '' ? 0 : +this.min, max = this.max === '' ? 100 : +this.max;
+		thumb = max > min ? Math.min(1, Math.max(0, (+this.value - min) / (max - min))) : 0.5;
+	}
+	return {draggable: !!this.closest('[draggable=true]'), thumb};
+}`
+
+// dragValueJS значение слайдера или поля: aria-valuenow, value или текст aria-valuetext
+const dragValueJS = `() => {
+	const v = this.getAttribute('aria-valuenow') ?? this.getAttribute('aria-valuetext');
+	if (v !== null) return v;
+	return typeof this.value === 'string' ? this.value : '';
+}`
+
+// Атрибуты не считаем: классы и style меняются от одного наведения мыши. Наблюдаем
+// родителя источника (элемент уходит из списка или колонки) и цель: заданный элемент
+// или контейнер элемента под точкой отпускания, но не body целиком
+const watchDragJS = `(dx, dy, target) => {
+	const doc = this.ownerDocument, w = doc.defaultView;
+	w.__agentDragObserver?.disconnect();
+	if (w.__agentDragListener) doc.removeEventListener('drop', w.__agentDragListener, true);
+	w.__agentDragMutations = 0;
+	w.__agentDragDropped = false;
+	w.__agentDragListener = () => { w.__agentDragDropped = true; };
+	doc.addEventListener('drop', w.__agentDragListener, true);
+
+	const r = this.getBoundingClientRect();
+	const under = doc.elementFromPoint(r.left + r.width / 2 + dx, r.top + r.height / 2 + dy);
+	const wide = el => !el || el === doc.body || el === doc.documentElement;
+	const roots = [];
+	for (const el of [this.parentElement || this, target || (under && under.parentElement)]) {
+		if (wide(el) || roots.some(root => root.contains(el))) continue;
+		for (let i = roots.length - 1; i >= 0; i--) if (el.contains(roots[i])) roots.splice(i, 1);
+		roots.push(el);
+	}
+	w.__agentDragObserver = new MutationObserver((list) => { w.__agentDragMutations += list.length; });
+	for (const root of roots) w.__agentDragObserver.observe(root, {subtree: true, childList: true, characterData: true});
+}`
+
+const takeDragJS = `() => {
+	const doc = this.ownerDocument, w = doc.defaultView;
+	w.__agentDragObserver?.disconnect();
+	w.__agentDragObserver = null;
+	if (w.__agentDragListener) doc.removeEventListener('drop', w.__agentDragListener, true);
+	w.__agentDragListener = null;
+	return {mutations: w.__agentDragMutations || 0, dropped: !!w.__agentDragDropped};
+}`
+
+const html5DragJS = `(dx, dy) => {
+	const doc = this.ownerDocument;
+	const r = this.getBoundingClientRect();
+	const sx = r.left + r.width / 2, sy = r.top + r.height / 2, tx = sx + dx, ty = sy + dy;
+	const target = doc.elementFromPoint(tx, ty);
+	if (!target) return 'no element at the drop point';
+	const dt = new DataTransfer();
+	const fire = (el, type, x, y) => el.dispatchEvent(new DragEvent(type, {
+		bubbles: true, cancelable: true, composed: true, clientX: x, clientY: y, dataTransfer: dt,
+	}));
+	if (!fire(this, 'dragstart', sx, sy)) return 'dragstart was cancelled by the page';
+	fire(this, 'drag', sx, sy);
+	fire(target, 'dragenter', tx, ty);
+	fire(target, 'dragover', tx, ty);
+	fire(target, 'drop', tx, ty);
+	fire(this, 'dragend', tx, ty);
+	return '';
+}`
@@ -0,0 +1,493 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"go.uber.org/zap"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/browser/dom"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
+)
+
+// maxFormFields сколько полей можно заполнить за один вызов
+const maxFormFields = 50
+
+// valueFormats формат значения для полей, которые заполняются напрямую, а не вводом с клавиатуры
+var valueFormats = map[string]string{
+	"date":           "YYYY-MM-DD",
+	"time":           "HH:MM",
+	"datetime-local": "YYYY-MM-DDTHH:MM",
+	"month":          "YYYY-MM",
+	"week":           "YYYY-Www",
+	"color":          "#rrggbb",
+	"range":          "number",
+}
+
+// FormField поле формы и значение для него
+type FormField struct {
+	Field string // ref:N, CSS селектор или подпись, name, id, placeholder поля
+	Value string
+}
+
+// FieldResult итог заполнения одного поля
+type FieldResult struct {
+	Field string
+	Kind  string // text, select, combobox, checkbox, radio или тип input (date, time...)
+	Value string // что оказалось в поле после заполнения
+	Err   error
+}
+
+// FormResult итог заполнения формы
+type FormResult struct {
+	Fields    []FieldResult
+	Submitted string // чем отправлена форма; пусто если не отправляли
+	SubmitErr error
+}
+
+// Failed сколько полей заполнить не удалось
+func (r *FormResult) Failed() int {
+	n := 0
+	for _, f := range r.Fields {
+		if f.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// FillForm заполняет поля формы по очереди: текстовые поля, списки, чекбоксы, радио и даты.
+// Ошибка в одном поле не останавливает остальные. Если submit и все поля заполнены -
+// отправляет форму кнопкой submit последнего поля; поля из разных форм не отправляются
+func FillForm(ctx context.Context, p PageProvider, fields []FormField, submit bool) (*FormResult, error) {
+	logger.Info(ctx, "📝 Filling form", zap.Int("fields", len(fields)), zap.Bool("submit", submit))
+	if len(fields) == 0 {
+		return nil, errors.New("at least one field is required")
+	}
+	if len(fields) > maxFormFields {
+		return nil, fmt.Errorf("too many fields: %d (max %d)", len(fields), maxFormFields)
+	}
+
+	res := &FormResult{}
+	var filled []*rod.Element
+	for _, f := range fields {
+		el, fr := fillField(ctx, p, f)
+		if fr.Err != nil {
+			logger.Warn(ctx, "⚠️ Field not filled", zap.String("field", f.Field), zap.Error(fr.Err))
+		} else {
+			filled = append(filled, el)
+		}
+		res.Fields = append(res.Fields, fr)
+	}
+
+	if submit {
+		switch failed := res.Failed(); {
+		case failed > 0:
+			res.SubmitErr = fmt.Errorf("form not submitted: %d field(s) failed", failed)
+		default:
+			if el, err := submitField(filled); err != nil {
+				res.SubmitErr = err
+			} else {
+				res.Submitted, res.SubmitErr = submitForm(ctx, p, el)
+			}
+		}
+	}
+
+	logger.Info(ctx, "✅ Form filled", zap.Int("filled", len(res.Fields)-res.Failed()), zap.Int("total", len(res.Fields)))
+	return res, nil
+}
+
+// fillField находит поле и заполняет его в зависимости от вида
+func fillField(ctx context.Context, p PageProvider, f FormField) (*rod.Element, FieldResult) {
+	res := FieldResult{Field: f.Field}
+	value, err := resolveSecrets(p, f.Value)
+	if err != nil {
+		res.Err = err
+		return nil, res
+	}
+	el, err := findFormField(p, f.Field)
+	if err != nil {
+		res.Err = locateError(f.Field, err)
+		return nil, res
+	}
+	info, err := el.Eval(fieldKindJS)
+	if err != nil {
+		res.Err = fmt.Errorf("inspect field: %w", err)
+		return nil, res
+	}
+	kind := info.Value.Get("kind").Str()
+	res.Kind = kind
+
+	switch kind {
+	case "text":
+		if err = typeInto(ctx, p, el, value); err == nil {
+			res.Value = fieldValue(el)
+		}
+		if value != f.Value {
+			// В поле секрет - в отчёт идёт плейсхолдер, а не прочитанное значение
+			res.Value = f.Value
+		}
+	case "select", "combobox", "listbox":
+		// Значение от модели может быть и текстом варианта, и его value
+		var opt *SelectedOption
+		q := OptionQuery{Value: value, Label: value}
+		if kind == "select" {
+			opt, err = selectNative(el, q)
+		} else {
+			opt, err = selectCustom(ctx, p, el, q, kind == "combobox")
+		}
+		if err == nil {
+			res.Value = opt.Label
+		}
+	case "checkbox":
+		res.Value, err = setChecked(ctx, p, el, value)
+	case "radio":
+		el, res.Value, err = pickRadio(ctx, p, el, value)
+	case "value":
+		res.Kind = info.Value.Get("type").Str()
+		res.Value, err = setFieldValue(el, res.Kind, value)
+	case "file":
+		err = errors.New("file inputs are filled with upload_file")
+	case "disabled":
+		err = errors.New("field is disabled")
+	default:
+		err = errors.New("element is not a form field")
+	}
+	res.Err = err
+	return el, res
+}
+
+// findFormField ищет поле по ref:N, подписи (label, aria-label, placeholder, name, id) или CSS,
+// в том числе внутри iframe и shadow root (iframe#form >> Email)
+func findFormField(p PageProvider, field string) (*rod.Element, error) {
+	if n, ok := ParseRef(field); ok {
+		return p.ResolveRef(n)
+	}
+	path, last := dom.SplitChain(field)
+	scope, err := dom.ResolveScope(p.GetPage(), path)
+	if err != nil {
+		return nil, err
+	}
+	if text, ok := strings.CutPrefix(last, "text:"); ok {
+		return formControl(scope.ElementByJS(time.Second, findFieldByLabelJS, text))
+	}
+	if el, err := formControl(scope.ElementByJS(time.Second, findFieldByLabelJS, last)); err == nil {
+		return el, nil
+	}
+	return formControl(scope.Element(last, 3*time.Second))
+}
+
+// formControl заменяет обёртку или label на поле внутри неё
+func formControl(el *rod.Element, err error) (*rod.Element, error) {
+	if err != nil {
+		return nil, err
+	}
+	obj, err := el.Evaluate(rod.Eval(formControlJS).ByObject())
+	if err != nil || obj.Subtype != proto.RuntimeRemoteObjectSubtypeNode {
+		return el, nil
+	}
+	return el.Page().ElementFromObject(obj)
+}
+
+// setChecked приводит чекбокс или переключатель к нужному состоянию
+func setChecked(ctx context.Context, p PageProvider, el *rod.Element, value string) (string, error) {
+	want, err := parseChecked(value)
+	if err != nil {
+		return "", err
+	}
+	if isChecked(el) != want {
+		clickControl(ctx, p, el)
+	}
+	if isChecked(el) != want {
+		return "", fmt.Errorf("checkbox did not change state")
+	}
+	return fmt.Sprint(want), nil
+}
+
+// pickRadio выбирает в группе радио-кнопку, чьё value или подпись совпадает со значением.
+// Если поле указывает на саму кнопку, значение может быть просто true
+func pickRadio(ctx context.Context, p PageProvider, el *rod.Element, value string) (*rod.Element, string, error) {
+	radio := el
+	obj, err := el.Evaluate(rod.Eval(pickRadioJS, value).ByObject())
+	if err == nil && obj.Subtype == proto.RuntimeRemoteObjectSubtypeNode {
+		if radio, err = el.Page().ElementFromObject(obj); err != nil {
+			return nil, "", err
+		}
+	} else if want, err := parseChecked(value); err != nil || !want {
+		return nil, "", fmt.Errorf("option %q not found in radio group", value)
+	}
+	if !isChecked(radio) {
+		clickControl(ctx, p, radio)
+	}
+	if !isChecked(radio) {
+		return nil, "", fmt.Errorf("radio button did not become selected")
+	}
+	label := value
+	if res, err := radio.Eval(radioLabelJS); err == nil && res.Value.Str() != "" {
+		label = res.Value.Str()
+	}
+	return radio, label, nil
+}
+
+// setFieldValue выставляет значение напрямую: ввод дат с клавиатуры зависит от локали браузера
+func setFieldValue(el *rod.Element, typ, value string) (string, error) {
+	res, err := el.Eval(setValueJS, value)
+	if err != nil {
+		return "", fmt.Errorf("set value: %w", err)
+	}
+	got := res.Value.Str()
+	if value != "" && got == "" {
+		return "", fmt.Errorf("value %q rejected by %s input, expected format %s", value, typ, valueFormats[typ])
+	}
+	return got, nil
+}
+
+// clickControl кликает как пользователь; стилизованные чекбоксы часто скрыты - тогда через JS
+func clickControl(ctx context.Context, p PageProvider, el *rod.Element) {
+	if err := doClick(ctx, p, el); err != nil {
+		logger.Debug(ctx, "🔄 Form control click via JS", zap.Error(err))
+		el.Eval(`() => this.click()`)
+		p.WaitStable(time.Second)
+	}
+}
+
+func isChecked(el *rod.Element) bool {
+	res, err := el.Eval(`() => this.getAttribute('role') && this.tagName !== 'INPUT'
+		? this.getAttribute('aria-checked') === 'true' : !!this.checked`)
+	return err == nil && res.Value.Bool()
+}
+
+func fieldValue(el *rod.Element) string {
+	res, err := el.Eval(`() => typeof this.value === 'string' ? this.value : (this.innerText || '').trim()`)
+	if err != nil {
+		return ""
+	}
+	return res.Value.Str()
+}
+
+// parseChecked значение чекбокса: true/false, yes/no, on/off, да/нет, 1/0
+func parseChecked(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "on", "1", "checked", "да":
+		return true, nil
+	case "false", "no", "off", "0", "unchecked", "нет", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("checkbox value must be true or false, got %q", value)
+}
+
+// submitForm отправляет форму поля: кликом по кнопке submit, иначе form.requestSubmit()
+func submitForm(ctx context.Context, p PageProvider, el *rod.Element) (string, error) {
+	if el == nil {
+		return "", errors.New("no filled field to find the form from")
+	}
+	obj, err := el.Evaluate(rod.Eval(submitButtonJS).ByObject())
+	if err == nil && obj.Subtype == proto.RuntimeRemoteObjectSubtypeNode {
+		btn, err := el.Page().ElementFromObject(obj)
+		if err != nil {
+			return "", err
+		}
+		label := "submit button"
+		if text := buttonText(btn); text != "" {
+			label = fmt.Sprintf("button %q", truncate(text, 40))
+		}
+		if err := doClick(ctx, p, btn); err != nil {
+			return "", fmt.Errorf("click %s: %w", label, err)
+		}
+		return label, nil
+	}
+	res, err := el.Eval(`() => { const f = this.form || this.closest('form'); if (!f) return false; f.requestSubmit(); return true; }`)
+	if err != nil {
+		return "", fmt.Errorf("submit form: %w", err)
+	}
+	if !res.Value.Bool() {
+		return "", errors.New("field is not inside a form and no submit button found")
+	}
+	p.WaitStable(3 * time.Second)
+	return "form.requestSubmit()", nil
+}
+
+// SubmitLabel текст кнопки, которой FillForm отправит форму с этими полями. Нужен правилам
+// безопасности до заполнения: отправка через "Оплатить" - такой же платёж, как клик по ней.
+// Форма выбирается так же, как в FillForm - по последнему найденному полю.
+// Пусто, если полей или кнопки нет (форма уйдёт через requestSubmit) и если поля
+// из разных форм (FillForm такую форму не отправит)
+func SubmitLabel(p PageProvider, fields []FormField) string {
+	var found []*rod.Element
+	for _, f := range fields {
+		if el, err := findFormField(p, f.Field); err == nil {
+			found = append(found, el)
+		}
+	}
+	el, err := submitField(found)
+	if err != nil {
+		return ""
+	}
+	obj, err := el.Evaluate(rod.Eval(submitButtonJS).ByObject())
+	if err != nil || obj.Subtype != proto.RuntimeRemoteObjectSubtypeNode {
+		return ""
+	}
+	btn, err := el.Page().ElementFromObject(obj)
+	if err != nil {
+		return ""
+	}
+	return buttonText(btn)
+}
+
+// sameFormJS все ли переданные поля из той же формы, что и this
+const sameFormJS = `(...others) => {
+	const formOf = (el) => el.form || el.closest('form');
+	const form = formOf(this);
+	return others.every(o => formOf(o) === form);
+}`
+
+// submitField поле, через форму которого отправляются поля: последнее из них.
+// Проверка безопасности и отправка должны видеть одну форму, поэтому поля
+// из разных форм (или разных фреймов) не отправляются
+func submitField(els []*rod.Element) (*rod.Element, error) {
+	if len(els) == 0 {
+		return nil, errors.New("no filled field to find the form from")
+	}
+	last := els[len(els)-1]
+	others := make([]interface{}, 0, len(els)-1)
+	for _, el := range els[:len(els)-1] {
+		others = append(others, el.Object)
+	}
+	res, err := last.Eval(sameFormJS, others...)
+	if err != nil || !res.Value.Bool() {
+		return nil, errors.New("form not submitted: fields belong to different forms")
+	}
+	return last, nil
+}
+
+// buttonText видимый текст кнопки, value у input или aria-label
+func buttonText(btn *rod.Element) string {
+	res, err := btn.Eval(`() => (this.innerText || this.value || this.getAttribute('aria-label') || '').trim()`)
+	if err != nil {
+		return ""
+	}
+	return res.Value.Str()
+}
+
+// formFieldsCSS элементы, которые считаются полями формы
+const formFieldsCSS = `input:not([type=hidden]):not([type=submit]):not([type=button]):not([type=reset]):not([type=image]),
+	textarea, select, [contenteditable=""], [contenteditable="true"], [role="textbox"], [role="combobox"],
+	[role="listbox"], [role="checkbox"], [role="switch"], [role="radio"]`
+
+// findFieldByLabelJS ищет поле по подписи: сначала точное совпадение, затем вхождение.
+// Радио-кнопки находятся и по legend своего fieldset / aria-label radiogroup
+const findFieldByLabelJS = `(text) => {` + dom.JSHelpers + `
+	const t = text.trim().toLowerCase();
+	if (!t) return null;
+	const controls = deepRoots(this.querySelectorAll ? this : document).flatMap(r => Array.from(
+		r.root.querySelectorAll(` + "`" + formFieldsCSS + "`" + `)));
+	const labelOf = (el) => {
+		const parts = [el.getAttribute('aria-label'), el.getAttribute('placeholder'), el.getAttribute('name'), el.id, el.title];
+		if (el.labels) for (const l of el.labels) parts.push(l.innerText);
+		const by = el.getAttribute('aria-labelledby');
+		if (by) for (const id of by.split(' ')) { const l = el.getRootNode().getElementById(id); if (l) parts.push(l.innerText); }
+		if (el.type === 'radio' || el.getAttribute('role') === 'radio') {
+			const legend = el.closest('fieldset')?.querySelector('legend');
+			if (legend) parts.push(legend.innerText);
+			const group = el.closest('[role="radiogroup"]');
+			if (group) parts.push(group.getAttribute('aria-label'));
+		}
+		return parts.filter(Boolean).map(s => s.trim().toLowerCase());
+	};
+	const visible = (el) => {
+		if (el.tagName === 'SELECT' || el.type === 'checkbox' || el.type === 'radio') return true;
+		const r = el.getBoundingClientRect();
+		return r.width > 0 && r.height > 0;
+	};
+	for (const exact of [true, false]) {
+		for (const el of controls) {
+			if (!visible(el)) continue;
+			if (labelOf(el).some(l => exact ? l === t : l.includes(t))) return el;
+		}
+	}
+	return null;
+}`
+
+// formControlJS поле для обёртки: label[for], вложенное поле; null если это уже поле
+const formControlJS = `() => {
+	if (this.matches(` + "`" + formFieldsCSS + "`" + `)) return null;
+	if (this.tagName === 'LABEL' && this.control) return this.control;
+	return this.querySelector(` + "`" + formFieldsCSS + "`" + `);
+}`
+
+const fieldKindJS = `() => {
+	const el = this, role = el.getAttribute('role'), type = (el.type || '').toLowerCase();
+	if (el.disabled || el.getAttribute('aria-disabled') === 'true') return {kind: 'disabled'};
+	if (el.tagName === 'SELECT') return {kind: 'select'};
+	if (el.tagName === 'TEXTAREA') return {kind: 'text'};
+	if (el.tagName === 'INPUT') {
+		if (type === 'checkbox') return {kind: 'checkbox'};
+		if (type === 'radio') return {kind: 'radio'};
+		if (type === 'file') return {kind: 'file'};
+		if (['date', 'time', 'datetime-local', 'month', 'week', 'color', 'range'].includes(type)) return {kind: 'value', type};
+		return {kind: 'text'};
+	}
+	if (role === 'checkbox' || role === 'switch') return {kind: 'checkbox'};
+	if (role === 'radio') return {kind: 'radio'};
+	if (role === 'listbox') return {kind: 'listbox'};
+	if (role === 'combobox' || el.getAttribute('aria-haspopup') === 'listbox') return {kind: 'combobox'};
+	if (el.isContentEditable || role === 'textbox') return {kind: 'text'};
+	return {kind: 'other'};
+}`
+
+// pickRadioJS кнопка группы, чьё value или подпись совпадает со значением
+const pickRadioJS = `(value) => {
+	const v = value.trim().toLowerCase();
+	if (!v) return null;
+	let group;
+	if (this.tagName === 'INPUT' && this.name) {
+		group = Array.from((this.form || this.getRootNode()).querySelectorAll('input[type="radio"]')).filter(r => r.name === this.name);
+	} else {
+		const g = this.closest('[role="radiogroup"], fieldset');
+		group = g ? Array.from(g.querySelectorAll('input[type="radio"], [role="radio"]')) : [this];
+	}
+	const labelsOf = (r) => {
+		const parts = [r.value, r.getAttribute('aria-label')];
+		if (r.labels) for (const l of r.labels) parts.push(l.innerText);
+		if (r.tagName !== 'INPUT') parts.push(r.innerText);
+		return parts.filter(Boolean).map(s => s.trim().toLowerCase());
+	};
+	for (const exact of [true, false]) {
+		for (const r of group) {
+			if (labelsOf(r).some(l => exact ? l === v : l.includes(v))) return r;
+		}
+	}
+	return null;
+}`
+
+const radioLabelJS = `() => {
+	if (this.labels && this.labels.length) return this.labels[0].innerText.trim();
+	return (this.getAttribute('aria-label') || this.value || this.innerText || '').trim();
+}`
+
+// setValueJS через нативный сеттер, чтобы React/Vue увидели изменение
+const setValueJS = `(value) => {
+	const setter = Object.getOwnPropertyDescriptor(HTMLInputElement.prototype, 'value').set;
+	this.focus();
+	setter.call(this, value);
+	this.dispatchEvent(new Event('input', {bubbles: true}));
+	this.dispatchEvent(new Event('change', {bubbles: true}));
+	this.blur();
+	return this.value;
+}`
+
+// submitButtonJS кнопка отправки формы поля; без формы - единственная кнопка submit в документе
+const submitButtonJS = `() => {
+	const sel = 'button[type="submit"], input[type="submit"], input[type="image"], button:not([type])';
+	const form = this.form || this.closest('form');
+	if (form) {
+		return form.querySelector(sel) || (form.id ? form.ownerDocument.querySelector('[form="' + CSS.escape(form.id) + '"]') : null);
+	}
+	const buttons = this.getRootNode().querySelectorAll('button[type="submit"], input[type="submit"]');
+	return buttons.length === 1 ? buttons[0] : null;
+}`
@@ -0,0 +1,81 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
+)
+
+// GoBack возвращается на предыдущую страницу истории
+func GoBack(ctx context.Context, p PageProvider) error {
+	logger.Info(ctx, "⬅️ Going back")
+	page := p.GetPage()
+	if err := checkHistory(p, -1); err != nil {
+		return err
+	}
+	if err := page.NavigateBack(); err != nil {
+		return fmt.Errorf("go back failed: %w", err)
+	}
+	settle(ctx, p)
+	return nil
+}
+
+// GoForward переходит на следующую страницу истории
+func GoForward(ctx context.Context, p PageProvider) error {
+	logger.Info(ctx, "➡️ Going forward")
+	page := p.GetPage()
+	if err := checkHistory(p, 1); err != nil {
+		return err
+	}
+	if err := page.NavigateForward(); err != nil {
+		return fmt.Errorf("go forward failed: %w", err)
+	}
+	settle(ctx, p)
+	return nil
+}
+
+// Reload перезагружает страницу
+func Reload(ctx context.Context, p PageProvider) error {
+	logger.Info(ctx, "🔄 Reloading page")
+	if err := p.GetPage().Timeout(p.GetTimeout()).Reload(); err != nil {
+		return fmt.Errorf("reload failed: %w", err)
+	}
+	settle(ctx, p)
+	return nil
+}
+
+// checkHistory проверяет, что в истории вкладки есть запись со сдвигом delta от текущей
+func checkHistory(p PageProvider, delta int) error {
+	h, err := p.GetPage().GetNavigationHistory()
+	if err != nil {
+		// История недоступна (например, во время навигации) - пробуем всё равно
+		return nil
+	}
+	target := h.CurrentIndex + delta
+	switch {
+	case target < 0:
+		return errors.New("no previous page in this tab's history")
+	case target >= len(h.Entries):
+		return errors.New("no next page in this tab's history")
+	}
+	return nil
+}
+
+// settle ждёт загрузки страницы после перехода. Переход внутри SPA load не вызывает,
+// поэтому таймауты не считаются ошибкой
+func settle(ctx context.Context, p PageProvider) {
+	page := p.GetPage()
+	// Переход по истории асинхронный: даём ему начаться, чтобы не поймать load старой страницы
+	time.Sleep(200 * time.Millisecond)
+	if err := page.Timeout(p.GetTimeout()).WaitLoad(); err != nil {
+		logger.Warn(ctx, "⚠️ WaitLoad timeout, continuing...", zap.Error(err))
+	}
+	if err := page.Timeout(3 * time.Second).WaitStable(300 * time.Millisecond); err != nil {
+		logger.Debug(ctx, "WaitStable timeout", zap.Error(err))
+	}
+}
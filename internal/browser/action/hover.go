@@ -0,0 +1,35 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
+)
+
+// Hover наводит курсор на элемент: открывает выпадающие меню и подсказки, которые
+// появляются по :hover или mouseenter
+func Hover(ctx context.Context, p PageProvider, selector string) error {
+	logger.Info(ctx, "🖱️ Hovering", zap.String("selector", selector))
+
+	elem, err := Locate(p, selector, p.GetTimeout())
+	if err != nil {
+		return locateError(selector, err)
+	}
+	if err := elem.ScrollIntoView(); err != nil {
+		logger.Debug(ctx, "ScrollIntoView failed", zap.Error(err))
+	}
+	if err := elem.Hover(); err != nil {
+		return fmt.Errorf("hover failed: %w", err)
+	}
+
+	// Меню обычно открываются с анимацией
+	p.WaitStable(2 * time.Second)
+	time.Sleep(300 * time.Millisecond)
+
+	logger.Info(ctx, "✅ Hover completed", zap.String("selector", selector))
+	return nil
+}
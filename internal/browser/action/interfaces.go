@@ -11,4 +11,13 @@ type PageProvider interface {
 	GetPage() *rod.Page
 	WaitStable(timeout time.Duration)
 	GetTimeout() time.Duration
+	ResolveRef(n int) (*rod.Element, error) // узел по номеру [N] из последнего query_dom
+	Secrets() SecretResolver                // nil если хранилище секретов не настроено
+}
+
+// SecretResolver подставляет значения секретов вместо плейсхолдеров {{secret:name}}
+// и заменяет значения обратно плейсхолдерами в том, что читается со страницы
+type SecretResolver interface {
+	Resolve(text string) (string, error)
+	Redact(text string) string
 }
@@ -2,12 +2,38 @@ package action
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/go-rod/rod/lib/input"
+	"go.uber.org/zap"
 
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
 )
 
+// maxKeyRepeat сколько раз подряд можно нажать сочетание за одно действие
+const maxKeyRepeat = 50
+
+// namedKeys клавиши по именам KeyboardEvent.key и распространённым сокращениям
+var namedKeys = map[string]input.Key{
+	"escape": input.Escape, "esc": input.Escape,
+	"tab": input.Tab, "enter": input.Enter, "return": input.Enter,
+	"backspace": input.Backspace, "delete": input.Delete, "del": input.Delete, "insert": input.Insert,
+	"space": input.Space, "spacebar": input.Space,
+	"arrowup": input.ArrowUp, "up": input.ArrowUp, "arrowdown": input.ArrowDown, "down": input.ArrowDown,
+	"arrowleft": input.ArrowLeft, "left": input.ArrowLeft, "arrowright": input.ArrowRight, "right": input.ArrowRight,
+	"home": input.Home, "end": input.End, "pageup": input.PageUp, "pagedown": input.PageDown,
+	"f1": input.F1, "f2": input.F2, "f3": input.F3, "f4": input.F4, "f5": input.F5, "f6": input.F6,
+	"f7": input.F7, "f8": input.F8, "f9": input.F9, "f10": input.F10, "f11": input.F11, "f12": input.F12,
+}
+
+// modifierKeys модификаторы сочетаний
+var modifierKeys = map[string]input.Key{
+	"control": input.ControlLeft, "ctrl": input.ControlLeft, "shift": input.ShiftLeft,
+	"alt": input.AltLeft, "option": input.AltLeft,
+	"meta": input.MetaLeft, "cmd": input.MetaLeft, "command": input.MetaLeft, "win": input.MetaLeft,
+}
+
 // PressEnter нажимает Enter
 func PressEnter(ctx context.Context, p PageProvider) error {
 	logger.Info(ctx, "⏎ Pressing Enter")
@@ -20,3 +46,62 @@ func PressKey(ctx context.Context, p PageProvider, key input.Key) error {
 	page := p.GetPage()
 	return page.Keyboard.Press(key)
 }
+
+// PressChord нажимает клавишу или сочетание вида Control+A, Shift+Tab, ArrowDown
+// repeat раз подряд в элементе, который сейчас в фокусе
+func PressChord(ctx context.Context, p PageProvider, chord string, repeat int) error {
+	mods, key, err := ParseChord(chord)
+	if err != nil {
+		return err
+	}
+	if repeat < 1 {
+		repeat = 1
+	}
+	if repeat > maxKeyRepeat {
+		return fmt.Errorf("repeat must be at most %d", maxKeyRepeat)
+	}
+	logger.Info(ctx, "⌨️ Pressing keys", zap.String("keys", chord), zap.Int("repeat", repeat))
+
+	page := p.GetPage()
+	for i := 0; i < repeat; i++ {
+		if err := page.KeyActions().Press(mods...).Type(key).Do(); err != nil {
+			return fmt.Errorf("press %s failed: %w", chord, err)
+		}
+	}
+	return nil
+}
+
+// ParseChord разбирает сочетание клавиш: модификаторы через + и одна основная клавиша
+func ParseChord(chord string) ([]input.Key, input.Key, error) {
+	parts := strings.Split(strings.TrimSpace(chord), "+")
+	// "Control++" - последней клавишей был сам плюс
+	if len(parts) > 1 && parts[len(parts)-1] == "" && parts[len(parts)-2] == "" {
+		parts = append(parts[:len(parts)-2], "+")
+	}
+
+	var mods []input.Key
+	for _, m := range parts[:len(parts)-1] {
+		k, ok := modifierKeys[strings.ToLower(strings.TrimSpace(m))]
+		if !ok {
+			return nil, 0, fmt.Errorf("unknown modifier %q in %q (expected Control, Shift, Alt or Meta)", m, chord)
+		}
+		mods = append(mods, k)
+	}
+
+	name := strings.TrimSpace(parts[len(parts)-1])
+	if k, ok := namedKeys[strings.ToLower(name)]; ok {
+		return mods, k, nil
+	}
+	if k, ok := modifierKeys[strings.ToLower(name)]; ok && len(mods) == 0 {
+		return nil, k, nil
+	}
+	r := []rune(name)
+	if len(r) != 1 || r[0] < '!' || r[0] > '~' {
+		return nil, 0, fmt.Errorf("unknown key %q (use names like Escape, Tab, ArrowDown, F5 or a single Latin character)", name)
+	}
+	// С модификаторами буква означает клавишу, а не заглавный символ: Control+A = Control+a
+	if len(mods) > 0 && r[0] >= 'A' && r[0] <= 'Z' {
+		r[0] += 'a' - 'A'
+	}
+	return mods, input.Key(r[0]), nil
+}
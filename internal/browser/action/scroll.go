@@ -49,3 +49,21 @@ func Scroll(ctx context.Context, p PageProvider, direction string, amount int) e
 		zap.Int("maxScroll", result.Value.Get("maxScroll").Int()))
 	return nil
 }
+
+// ScrollIntoView прокручивает страницу и все прокручиваемые контейнеры вокруг элемента
+// так, чтобы он оказался в центре видимой области
+func ScrollIntoView(ctx context.Context, p PageProvider, selector string) error {
+	logger.Info(ctx, "📜 Scrolling into view", zap.String("selector", selector))
+
+	elem, err := Locate(p, selector, p.GetTimeout())
+	if err != nil {
+		return locateError(selector, err)
+	}
+	if _, err := elem.Eval(`() => this.scrollIntoView({block: 'center', inline: 'nearest'})`); err != nil {
+		return fmt.Errorf("scroll into view failed: %w", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	logger.Info(ctx, "✅ Scrolled into view", zap.String("selector", selector))
+	return nil
+}
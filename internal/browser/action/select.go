@@ -0,0 +1,324 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"go.uber.org/zap"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/browser/dom"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
+)
+
+// OptionQuery какой вариант выбрать: по value, по видимому тексту или по номеру (с 1)
+type OptionQuery struct {
+	Value string
+	Label string
+	Index int
+}
+
+func (q OptionQuery) String() string {
+	switch {
+	case q.Value != "":
+		return "value=" + q.Value
+	case q.Label != "":
+		return "label=" + q.Label
+	default:
+		return fmt.Sprintf("index=%d", q.Index)
+	}
+}
+
+// SelectedOption вариант, который оказался выбран после действия
+type SelectedOption struct {
+	Label string
+	Value string
+	Index int    // номер варианта с 1
+	Total int    // всего вариантов
+	Shown string // что показывает контрол после выбора, если это отличается от Label
+}
+
+func (o SelectedOption) String() string {
+	s := fmt.Sprintf("%q", o.Label)
+	if o.Value != "" && o.Value != o.Label {
+		s += fmt.Sprintf(" (value=%s)", o.Value)
+	}
+	if o.Total > 0 {
+		s += fmt.Sprintf(", option %d of %d", o.Index, o.Total)
+	}
+	if o.Shown != "" {
+		s += fmt.Sprintf(", dropdown now shows %q", o.Shown)
+	}
+	return s
+}
+
+// SelectOption выбирает вариант в нативном <select> или в ARIA combobox/listbox
+func SelectOption(ctx context.Context, p PageProvider, selector string, q OptionQuery) (*SelectedOption, error) {
+	logger.Info(ctx, "🔽 Selecting option", zap.String("selector", selector), zap.String("option", q.String()))
+
+	if q.Value == "" && q.Label == "" && q.Index <= 0 {
+		return nil, fmt.Errorf("option value, label or index is required")
+	}
+
+	elem, err := findSelectTarget(p, selector)
+	if err != nil {
+		return nil, locateError(selector, err)
+	}
+	elem.ScrollIntoView()
+
+	kind, err := elem.Eval(selectKindJS)
+	if err != nil {
+		return nil, fmt.Errorf("inspect element: %w", err)
+	}
+
+	var selected *SelectedOption
+	switch kind.Value.String() {
+	case "native":
+		selected, err = selectNative(elem, q)
+	case "listbox":
+		selected, err = selectCustom(ctx, p, elem, q, false)
+	default:
+		selected, err = selectCustom(ctx, p, elem, q, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.WaitStable(2 * time.Second)
+	logger.Info(ctx, "✅ Option selected", zap.String("selected", selected.String()))
+	return selected, nil
+}
+
+// findSelectTarget ищет выпадающий список по ref:N, CSS или тексту подписи (text:Город),
+// в том числе внутри iframe и shadow root (iframe#form >> text:Город)
+func findSelectTarget(p PageProvider, selector string) (*rod.Element, error) {
+	if n, ok := ParseRef(selector); ok {
+		return p.ResolveRef(n)
+	}
+	path, last := dom.SplitChain(selector)
+	scope, err := dom.ResolveScope(p.GetPage(), path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(last, "text:") {
+		return scope.Element(last, 5*time.Second)
+	}
+	return scope.ElementByJS(5*time.Second, findSelectByLabelJS, strings.TrimPrefix(last, "text:"))
+}
+
+// selectNative выставляет вариант <select> и генерирует input/change как при выборе мышью
+func selectNative(elem *rod.Element, q OptionQuery) (*SelectedOption, error) {
+	result, err := elem.Eval(selectNativeJS, q.Value, q.Label, q.Index)
+	if err != nil {
+		return nil, fmt.Errorf("select failed: %w", err)
+	}
+	v := result.Value
+	if !v.Get("ok").Bool() {
+		return nil, fmt.Errorf("option %s not found, available: %s", q, v.Get("options").String())
+	}
+	if v.Get("disabled").Bool() {
+		return nil, fmt.Errorf("option %s is disabled", q)
+	}
+	return &SelectedOption{
+		Label: v.Get("label").String(),
+		Value: v.Get("value").String(),
+		Index: v.Get("index").Int(),
+		Total: v.Get("total").Int(),
+	}, nil
+}
+
+// selectCustom открывает ARIA combobox (или использует listbox) и кликает по role=option.
+// Listbox уже раскрыт: клик по нему попал бы в вариант под курсором, поэтому кликаем
+// только по combobox (openable)
+func selectCustom(ctx context.Context, p PageProvider, elem *rod.Element, q OptionQuery, openable bool) (*SelectedOption, error) {
+	// Открываем список, если он ещё не раскрыт
+	if expanded, _ := elem.Attribute("aria-expanded"); openable && (expanded == nil || *expanded != "true") {
+		if err := doClick(ctx, p, elem); err != nil {
+			return nil, fmt.Errorf("open dropdown: %w", err)
+		}
+	}
+
+	option, err := findCustomOption(elem, q)
+	// Автодополнение: если вариантов нет, вводим текст в поле, чтобы список отфильтровался.
+	// Текст заменяет уже введённый, а не дописывается к нему
+	if err != nil && q.Label != "" {
+		if tag, _ := elem.Eval(`() => this.tagName`); tag != nil && tag.Value.String() == "INPUT" {
+			logger.Debug(ctx, "🔄 Typing to filter combobox", zap.String("label", q.Label))
+			if selErr := elem.SelectAllText(); selErr != nil {
+				logger.Warn(ctx, "⚠️ Clear combobox before filtering failed", zap.Error(selErr))
+			} else if inputErr := elem.Input(q.Label); inputErr == nil {
+				option, err = findCustomOption(elem, q)
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := option.Eval(optionInfoJS)
+	if err != nil {
+		return nil, fmt.Errorf("inspect option: %w", err)
+	}
+	if info.Value.Get("disabled").Bool() {
+		return nil, fmt.Errorf("option %s is disabled", q)
+	}
+	selected := &SelectedOption{
+		Label: info.Value.Get("label").String(),
+		Value: info.Value.Get("value").String(),
+		Index: info.Value.Get("index").Int(),
+		Total: info.Value.Get("total").Int(),
+	}
+
+	if err := doClick(ctx, p, option); err != nil {
+		// Опции часто перекрыты анимацией списка - кликаем через JS
+		if _, jsErr := option.Eval(`() => { this.scrollIntoView({block: 'center'}); this.click(); }`); jsErr != nil {
+			return nil, fmt.Errorf("click option: %w", err)
+		}
+	}
+
+	// Проверяем что выбор применился: подпись combobox или aria-selected у опции.
+	// Подпись может быть сокращённой, поэтому расхождение не ошибка - сообщаем его модели
+	if shown, err := elem.Eval(shownValueJS); err == nil {
+		if s := strings.TrimSpace(shown.Value.String()); s != "" && !strings.Contains(strings.ToLower(s), strings.ToLower(selected.Label)) {
+			logger.Warn(ctx, "⚠️ Dropdown shows different value", zap.String("option", selected.Label), zap.String("shown", s))
+			selected.Shown = truncate(s, 80)
+		}
+	}
+	return selected, nil
+}
+
+// findCustomOption ищет role=option в списке, связанном с combobox
+func findCustomOption(combo *rod.Element, q OptionQuery) (*rod.Element, error) {
+	// Список открывается с анимацией или подгружается - ждём до ~1.5с
+	var lastOptions string
+	for i := 0; i < 10; i++ {
+		obj, err := combo.Evaluate(rod.Eval(findCustomOptionJS, q.Value, q.Label, q.Index).ByObject())
+		if err == nil && obj.Subtype == proto.RuntimeRemoteObjectSubtypeNode {
+			return combo.Page().ElementFromObject(obj)
+		}
+		if err == nil && obj.Type == proto.RuntimeRemoteObjectTypeString {
+			lastOptions = obj.Value.Str()
+		}
+		time.Sleep(150 * time.Millisecond)
+	}
+	if lastOptions == "" || lastOptions == "[]" {
+		return nil, fmt.Errorf("dropdown has no visible options (role=option)")
+	}
+	return nil, fmt.Errorf("option %s not found, available: %s", q, lastOptions)
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "..."
+	}
+	return s
+}
+
+const selectKindJS = `() => {
+	const el = this;
+	if (el.tagName === 'SELECT') return 'native';
+	if (el.getAttribute('role') === 'listbox') return 'listbox';
+	return 'combobox';
+}`
+
+const findSelectByLabelJS = `(text) => {` + dom.JSHelpers + `
+	const t = text.trim().toLowerCase();
+	const controls = deepRoots(this.querySelectorAll ? this : document).flatMap(r => Array.from(
+		r.root.querySelectorAll('select, [role="combobox"], [role="listbox"], [aria-haspopup="listbox"]')));
+	const labelOf = (el) => {
+		const parts = [el.getAttribute('aria-label'), el.getAttribute('placeholder'), el.getAttribute('name'), el.title];
+		if (el.labels) for (const l of el.labels) parts.push(l.innerText);
+		const by = el.getAttribute('aria-labelledby');
+		if (by) for (const id of by.split(' ')) { const l = el.getRootNode().getElementById(id); if (l) parts.push(l.innerText); }
+		return parts.filter(Boolean).map(s => s.trim().toLowerCase());
+	};
+	for (const exact of [true, false]) {
+		for (const el of controls) {
+			const rect = el.getBoundingClientRect();
+			if (el.tagName !== 'SELECT' && (rect.width === 0 || rect.height === 0)) continue;
+			const labels = labelOf(el);
+			if (el.tagName !== 'SELECT') labels.push((el.innerText || '').trim().toLowerCase());
+			if (labels.some(l => exact ? l === t : l.includes(t))) return el;
+		}
+	}
+	return null;
+}`
+
+const selectNativeJS = `(value, label, index) => {
+	const el = this;
+	const opts = Array.from(el.options);
+	const norm = s => (s || '').trim().toLowerCase();
+	let opt = null;
+	if (value) opt = opts.find(o => o.value === value);
+	if (!opt && label) opt = opts.find(o => norm(o.label) === norm(label)) || opts.find(o => norm(o.label).includes(norm(label)));
+	if (!opt && index > 0) opt = opts[index - 1];
+	if (!opt) return {ok: false, options: JSON.stringify(opts.slice(0, 30).map(o => o.label))};
+	if (opt.disabled) return {ok: true, disabled: true};
+	el.focus();
+	opt.selected = true;
+	el.dispatchEvent(new Event('input', {bubbles: true}));
+	el.dispatchEvent(new Event('change', {bubbles: true}));
+	const cur = el.options[el.selectedIndex];
+	return {ok: true, label: cur.label, value: cur.value, index: el.selectedIndex + 1, total: opts.length};
+}`
+
+// listboxOfJS находит список вариантов для combobox: aria-controls/aria-owns,
+// сам элемент или ближайший видимый role=listbox
+const listboxOfJS = `
+	const visible = el => { const r = el.getBoundingClientRect(); return r.width > 0 && r.height > 0; };
+	const listboxOf = (combo) => {
+		if (combo.getAttribute('role') === 'listbox') return combo;
+		const root = combo.getRootNode();
+		for (const attr of ['aria-controls', 'aria-owns']) {
+			const id = combo.getAttribute(attr);
+			const lb = id && (root.getElementById(id.split(' ')[0]) || document.getElementById(id.split(' ')[0]));
+			if (lb) return lb;
+		}
+		const inner = combo.querySelector('[role="listbox"]');
+		if (inner && visible(inner)) return inner;
+		return Array.from(root.querySelectorAll('[role="listbox"]')).find(visible) ||
+			Array.from(document.querySelectorAll('[role="listbox"]')).find(visible) || null;
+	};
+`
+
+const findCustomOptionJS = `(value, label, index) => {` + listboxOfJS + `
+	const lb = listboxOf(this);
+	if (!lb) return '[]';
+	const opts = Array.from(lb.querySelectorAll('[role="option"]')).filter(visible);
+	const norm = s => (s || '').trim().toLowerCase();
+	const text = o => norm(o.getAttribute('aria-label') || o.innerText);
+	const val = o => o.getAttribute('data-value') || o.getAttribute('value') || o.id;
+	let opt = null;
+	if (value) opt = opts.find(o => val(o) === value);
+	if (!opt && label) opt = opts.find(o => text(o) === norm(label)) || opts.find(o => text(o).includes(norm(label)));
+	if (!opt && index > 0) opt = opts[index - 1];
+	// Не нашли - возвращаем список доступных вариантов строкой для сообщения об ошибке
+	if (!opt) return JSON.stringify(opts.slice(0, 30).map(o => (o.innerText || '').trim()));
+	return opt;
+}`
+
+const optionInfoJS = `() => {
+	const o = this;
+	const lb = o.closest('[role="listbox"]') || o.parentElement;
+	const all = Array.from(lb.querySelectorAll('[role="option"]'));
+	return {
+		label: (o.getAttribute('aria-label') || o.innerText || '').trim(),
+		value: o.getAttribute('data-value') || o.getAttribute('value') || '',
+		index: all.indexOf(o) + 1,
+		total: all.length,
+		disabled: o.getAttribute('aria-disabled') === 'true',
+	};
+}`
+
+const shownValueJS = `() => {
+	const el = this;
+	if (el.getAttribute('role') === 'listbox') {
+		const sel = el.querySelector('[role="option"][aria-selected="true"]');
+		return sel ? sel.innerText : '';
+	}
+	if ('value' in el && typeof el.value === 'string' && el.tagName === 'INPUT') return el.value;
+	return el.innerText || '';
+}`
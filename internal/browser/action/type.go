@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/go-rod/rod"
 	"go.uber.org/zap"
 
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/secrets"
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
 )
 
@@ -14,12 +16,36 @@ import (
 func Type(ctx context.Context, p PageProvider, selector, text string) error {
 	logger.Info(ctx, "⌨️ Typing", zap.String("selector", selector), zap.Int("len", len(text)))
 
-	page := p.GetPage()
-	elem, err := page.Timeout(10 * time.Second).Element(selector)
+	text, err := resolveSecrets(p, text)
 	if err != nil {
-		return fmt.Errorf("element not found: %s", selector)
+		return err
 	}
+	elem, err := Locate(p, selector, 10*time.Second)
+	if err != nil {
+		return locateError(selector, err)
+	}
+	if err := typeInto(ctx, p, elem, text); err != nil {
+		return err
+	}
+	logger.Info(ctx, "✅ Type completed")
+	return nil
+}
 
+// resolveSecrets подставляет секреты в текст для ввода. Плейсхолдер без хранилища - ошибка,
+// а не буквальный {{secret:...}} в поле пароля
+func resolveSecrets(p PageProvider, text string) (string, error) {
+	if !secrets.HasPlaceholders(text) {
+		return text, nil
+	}
+	r := p.Secrets()
+	if r == nil {
+		return "", secrets.ErrNotConfigured
+	}
+	return r.Resolve(text)
+}
+
+// typeInto кликает в поле, очищает его и вводит текст
+func typeInto(ctx context.Context, p PageProvider, elem *rod.Element, text string) error {
 	elem.ScrollIntoView()
 	if err := elem.Click("left", 1); err != nil {
 		logger.Warn(ctx, "⚠️ Click before type failed", zap.Error(err))
@@ -36,6 +62,5 @@ func Type(ctx context.Context, p PageProvider, selector, text string) error {
 	}
 
 	p.WaitStable(2 * time.Second)
-	logger.Info(ctx, "✅ Type completed")
 	return nil
 }
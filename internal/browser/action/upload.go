@@ -0,0 +1,231 @@
+package action
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"go.uber.org/zap"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
+)
+
+// maxDropBytes лимит суммарного размера файлов для загрузки через JS drop
+// (файлы передаются в страницу целиком в base64)
+const maxDropBytes = 20 << 20
+
+// ErrUploadDirNotSet загрузка файлов выключена
+var ErrUploadDirNotSet = errors.New("file upload is disabled: BROWSER_UPLOAD_DIR is not set")
+
+// ResolveUploadFiles превращает имена файлов из запроса модели в абсолютные пути
+// внутри разрешённой директории. Выход за пределы директории (.., абсолютные пути,
+// симлинки) запрещён
+func ResolveUploadFiles(dir string, names []string) ([]string, error) {
+	if dir == "" {
+		return nil, ErrUploadDirNotSet
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no files specified")
+	}
+
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("upload dir: %w", err)
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return nil, fmt.Errorf("upload dir: %w", err)
+	}
+
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		clean := filepath.Clean(strings.TrimPrefix(filepath.ToSlash(name), "/"))
+		if clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("file %q is outside the upload dir", name)
+		}
+
+		path, err := filepath.EvalSymlinks(filepath.Join(root, clean))
+		if err != nil {
+			return nil, fmt.Errorf("file %q not found in upload dir, available: %s", name, strings.Join(ListUploadFiles(dir), ", "))
+		}
+		if rel, err := filepath.Rel(root, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("file %q is outside the upload dir", name)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("%q is not a regular file", name)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// ListUploadFiles список файлов, доступных для загрузки (для подсказки модели)
+func ListUploadFiles(dir string) []string {
+	var names []string
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || len(names) >= 50 {
+			return nil
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			names = append(names, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return names
+}
+
+// UploadFile прикрепляет файлы к <input type=file>, найденному по селектору.
+// Селектор может указывать на саму кнопку "Прикрепить" - тогда ищется связанный
+// скрытый input. Если input'а нет (drag-and-drop зона), файлы бросаются через JS.
+// Возвращает имена прикреплённых файлов
+func UploadFile(ctx context.Context, p PageProvider, selector string, paths []string) ([]string, error) {
+	logger.Info(ctx, "📎 Uploading files", zap.String("selector", selector), zap.Strings("files", paths))
+
+	target, err := findUploadTarget(p, selector)
+	if err != nil {
+		return nil, locateError(selector, err)
+	}
+
+	input, err := fileInputFor(target)
+	if err != nil {
+		logger.Info(ctx, "🔄 No file input found, trying drop", zap.String("selector", selector))
+		names, dropErr := dropFiles(target, paths)
+		if dropErr != nil {
+			return nil, fmt.Errorf("no file input for %s and drop failed: %w", selector, dropErr)
+		}
+		p.WaitStable(2 * time.Second)
+		return names, nil
+	}
+
+	multiple, _ := input.Property("multiple")
+	if len(paths) > 1 && !multiple.Bool() {
+		return nil, fmt.Errorf("file input accepts a single file, got %d", len(paths))
+	}
+
+	if err := input.SetFiles(paths); err != nil {
+		return nil, fmt.Errorf("set files: %w", err)
+	}
+
+	// Проверяем что файлы действительно прикрепились
+	res, err := input.Eval(`() => Array.from(this.files || []).map(f => f.name)`)
+	if err != nil {
+		return nil, fmt.Errorf("read attached files: %w", err)
+	}
+	var names []string
+	for _, v := range res.Value.Arr() {
+		names = append(names, v.Str())
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("files were not attached")
+	}
+
+	p.WaitStable(2 * time.Second)
+	logger.Info(ctx, "✅ Files attached", zap.Strings("names", names))
+	return names, nil
+}
+
+// findUploadTarget ищет элемент по селектору; пустой селектор - первый input[type=file]
+func findUploadTarget(p PageProvider, selector string) (*rod.Element, error) {
+	if selector == "" {
+		return p.GetPage().Timeout(5 * time.Second).Element(`input[type="file"]`)
+	}
+	return Locate(p, selector, 5*time.Second)
+}
+
+// fileInputFor находит input[type=file] для элемента: сам элемент, вложенный input,
+// input из label[for] или ближайший input среди предков (стилизованные кнопки)
+func fileInputFor(elem *rod.Element) (*rod.Element, error) {
+	obj, err := elem.Evaluate(rod.Eval(fileInputForJS).ByObject())
+	if err != nil || obj.Subtype != proto.RuntimeRemoteObjectSubtypeNode {
+		return nil, fmt.Errorf("file input not found")
+	}
+	return elem.Page().ElementFromObject(obj)
+}
+
+// dropFiles имитирует перетаскивание файлов на drop-зону через DataTransfer
+func dropFiles(target *rod.Element, paths []string) ([]string, error) {
+	type dropFile struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+		Data string `json:"data"`
+	}
+
+	var files []dropFile
+	total := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		total += len(data)
+		if total > maxDropBytes {
+			return nil, fmt.Errorf("files too large for drop upload (limit %d MB)", maxDropBytes>>20)
+		}
+		typ := mime.TypeByExtension(filepath.Ext(path))
+		if typ == "" {
+			typ = "application/octet-stream"
+		}
+		files = append(files, dropFile{Name: filepath.Base(path), Type: typ, Data: base64.StdEncoding.EncodeToString(data)})
+	}
+
+	res, err := target.Eval(dropFilesJS, files)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Value.Get("ok").Bool() {
+		return nil, fmt.Errorf("drop event was not handled by the page")
+	}
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	return names, nil
+}
+
+const fileInputForJS = `() => {
+	const el = this;
+	const isFile = n => n && n.tagName === 'INPUT' && n.type === 'file';
+	if (isFile(el)) return el;
+	const inner = el.querySelector && el.querySelector('input[type="file"]');
+	if (inner) return inner;
+	const label = el.closest('label');
+	if (label && isFile(label.control)) return label.control;
+	if (el.tagName === 'LABEL' && isFile(el.control)) return el.control;
+	const forId = el.getAttribute('for') || el.getAttribute('aria-controls');
+	const byId = forId && el.getRootNode().getElementById(forId);
+	if (isFile(byId)) return byId;
+	let node = el.parentElement;
+	for (let i = 0; node && i < 4; i++, node = node.parentElement) {
+		const found = node.querySelectorAll('input[type="file"]');
+		if (found.length === 1) return found[0];
+	}
+	return null;
+}`
+
+const dropFilesJS = `(files) => {
+	const dt = new DataTransfer();
+	for (const f of files) {
+		const bin = atob(f.data);
+		const bytes = new Uint8Array(bin.length);
+		for (let i = 0; i < bin.length; i++) bytes[i] = bin.charCodeAt(i);
+		dt.items.add(new File([bytes], f.name, {type: f.type}));
+	}
+	const el = this;
+	el.scrollIntoView({block: 'center'});
+	const opts = {bubbles: true, cancelable: true, dataTransfer: dt};
+	el.dispatchEvent(new DragEvent('dragenter', opts));
+	el.dispatchEvent(new DragEvent('dragover', opts));
+	const drop = new DragEvent('drop', opts);
+	el.dispatchEvent(drop);
+	// Страница, принявшая файлы, обычно вызывает preventDefault на drop
+	return {ok: drop.defaultPrevented};
+}`
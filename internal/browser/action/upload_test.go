@@ -0,0 +1,97 @@
+package action
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// uploadDir директория загрузок с файлами, поддиректорией и симлинками, и файл вне её
+func uploadDir(t *testing.T) (dir, outside string) {
+	t.Helper()
+	base := t.TempDir()
+	dir = filepath.Join(base, "uploads")
+	outside = filepath.Join(base, "secret.txt")
+	for _, path := range []string{
+		filepath.Join(dir, "cv.pdf"),
+		filepath.Join(dir, "..notes.pdf"),
+		filepath.Join(dir, "docs", "letter.txt"),
+		outside,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "escape.txt")); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "cv.pdf"), filepath.Join(dir, "latest.pdf")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(base, filepath.Join(dir, "parent")); err != nil {
+		t.Fatal(err)
+	}
+	return dir, outside
+}
+
+func TestResolveUploadFiles(t *testing.T) {
+	dir, outside := uploadDir(t)
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		files   []string
+		want    []string // пути относительно директории загрузок
+		wantErr bool
+	}{
+		{name: "files", files: []string{"cv.pdf", "docs/letter.txt"}, want: []string{"cv.pdf", "docs/letter.txt"}},
+		{name: "name starting with dots", files: []string{"..notes.pdf"}, want: []string{"..notes.pdf"}},
+		{name: "leading slash stays inside", files: []string{"/cv.pdf"}, want: []string{"cv.pdf"}},
+		{name: "symlink inside the dir", files: []string{"latest.pdf"}, want: []string{"cv.pdf"}},
+		{name: "parent dir", files: []string{"../secret.txt"}, wantErr: true},
+		{name: "parent dir after subdir", files: []string{"docs/../../secret.txt"}, wantErr: true},
+		{name: "absolute path outside", files: []string{outside}, wantErr: true},
+		{name: "symlink escaping the dir", files: []string{"escape.txt"}, wantErr: true},
+		{name: "symlinked parent dir", files: []string{"parent/secret.txt"}, wantErr: true},
+		{name: "directory", files: []string{"docs"}, wantErr: true},
+		{name: "upload dir itself", files: []string{"."}, wantErr: true},
+		{name: "missing file", files: []string{"missing.pdf"}, wantErr: true},
+		{name: "one bad file rejects all", files: []string{"cv.pdf", "../secret.txt"}, wantErr: true},
+		{name: "no files", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveUploadFiles(dir, tt.files)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ResolveUploadFiles(%q) = %q, want error", tt.files, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := make([]string, len(tt.want))
+			for i, rel := range tt.want {
+				want[i] = filepath.Join(root, filepath.FromSlash(rel))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ResolveUploadFiles(%q) = %q, want %q", tt.files, got, want)
+			}
+		})
+	}
+}
+
+func TestResolveUploadFilesDisabled(t *testing.T) {
+	if _, err := ResolveUploadFiles("", []string{"cv.pdf"}); !errors.Is(err, ErrUploadDirNotSet) {
+		t.Errorf("empty upload dir: err = %v, want ErrUploadDirNotSet", err)
+	}
+}
@@ -0,0 +1,118 @@
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
+)
+
+// markBox рамка элемента на скриншоте в CSS пикселях viewport
+type markBox struct {
+	N int `json:"n"`
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// AnnotatedScreenshot скриншот с пронумерованными рамками и легендой номер → элемент
+type AnnotatedScreenshot struct {
+	ScreenshotResult
+	Legend string
+}
+
+// TakeAnnotatedScreenshot рисует поверх видимых интерактивных элементов рамки с номерами
+// ref:N и снимает viewport. Номера совпадают с query_dom, по ним можно кликать
+func (c *Controller) TakeAnnotatedScreenshot(ctx context.Context, saveDir string) (*AnnotatedScreenshot, error) {
+	logger.Info(ctx, "📸 Taking annotated screenshot")
+
+	elements, err := c.discoverElements(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("find elements: %w", err)
+	}
+
+	vp, err := c.page.Eval(`() => ({w: window.innerWidth, h: window.innerHeight})`)
+	if err != nil {
+		return nil, fmt.Errorf("viewport: %w", err)
+	}
+	vw, vh := vp.Value.Get("w").Int(), vp.Value.Get("h").Int()
+
+	var boxes []markBox
+	var legend strings.Builder
+	for _, e := range elements {
+		if e.ref == 0 {
+			continue
+		}
+		shape, err := e.handle.Shape()
+		if err != nil || shape.Box() == nil {
+			continue
+		}
+		r := shape.Box()
+		b := markBox{N: e.ref, X: int(math.Round(r.X)), Y: int(math.Round(r.Y)), W: int(math.Round(r.Width)), H: int(math.Round(r.Height))}
+		// Элементы за пределами экрана на снимок не попадут - не показываем их в легенде
+		if b.W <= 0 || b.H <= 0 || b.X+b.W <= 0 || b.Y+b.H <= 0 || b.X >= vw || b.Y >= vh {
+			continue
+		}
+		boxes = append(boxes, b)
+		legend.WriteString(fmt.Sprintf("[%d] %s @ (%d,%d %dx%d)\n", b.N, e.selector, b.X, b.Y, b.W, b.H))
+	}
+
+	if _, err := c.page.Eval(drawMarksJS, boxes); err != nil {
+		return nil, fmt.Errorf("draw marks: %w", err)
+	}
+	data, err := c.page.Timeout(c.timeout).Screenshot(false, nil)
+	if _, rmErr := c.page.Eval(removeMarksJS); rmErr != nil {
+		logger.Warn(ctx, "⚠️ Failed to remove marks", zap.Error(rmErr))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take screenshot: %w", err)
+	}
+
+	if saveDir == "" {
+		saveDir = "screenshots"
+	}
+	os.MkdirAll(saveDir, 0o755)
+	filePath := filepath.Join(saveDir, fmt.Sprintf("screenshot-%d-marks.png", time.Now().UnixMilli()))
+	if err := os.WriteFile(filePath, data, 0o644); err != nil {
+		logger.Warn(ctx, "⚠️ Failed to save screenshot", zap.Error(err))
+	}
+
+	logger.Info(ctx, "✅ Annotated screenshot taken", zap.String("path", filePath), zap.Int("marks", len(boxes)))
+	return &AnnotatedScreenshot{
+		ScreenshotResult: ScreenshotResult{Path: filePath, Base64: base64.StdEncoding.EncodeToString(data)},
+		Legend:           legend.String(),
+	}, nil
+}
+
+const drawMarksJS = `(boxes) => {
+	document.getElementById('__agent_marks')?.remove();
+	const root = document.createElement('div');
+	root.id = '__agent_marks';
+	root.style.cssText = 'position:fixed;left:0;top:0;width:0;height:0;pointer-events:none;z-index:2147483647';
+	const colors = ['#e6194b', '#3cb44b', '#4363d8', '#f58231', '#911eb4', '#008080', '#9a6324', '#800000'];
+	for (const b of boxes) {
+		const c = colors[b.n % colors.length];
+		const box = document.createElement('div');
+		box.style.cssText = 'position:fixed;box-sizing:border-box;border:2px solid ' + c +
+			';left:' + b.x + 'px;top:' + b.y + 'px;width:' + b.w + 'px;height:' + b.h + 'px';
+		const label = document.createElement('div');
+		label.textContent = b.n;
+		// Метка над рамкой, а у верхнего края экрана - внутри неё
+		label.style.cssText = 'position:absolute;left:-2px;' + (b.y >= 16 ? 'top:-16px;' : 'top:0;') +
+			'background:' + c + ';color:#fff;font:bold 11px/14px monospace;padding:0 3px;border-radius:2px';
+		box.appendChild(label);
+		root.appendChild(box);
+	}
+	document.documentElement.appendChild(root);
+}`
+
+const removeMarksJS = `() => document.getElementById('__agent_marks')?.remove()`
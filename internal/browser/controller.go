@@ -3,6 +3,7 @@ package browser
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/go-rod/rod"
@@ -21,16 +22,53 @@ type Controller struct {
 	browser   *rod.Browser
 	page      *rod.Page
 	timeout   time.Duration
-	extractor *dom.Extractor
+	extractor dom.ContextExtractor
+	opts      Options
+	downloads *downloadTracker
+	dialogs   *dialogTracker
+	blocked   chan actionDone // действие, которое ждёт ответа на диалог
+	refs      elementRefs
+}
+
+// Options дополнительные настройки контроллера
+type Options struct {
+	UploadDir       string                // директория, из которой разрешено загружать файлы на сайты (пусто = запрещено)
+	DownloadDir     string                // корень для загрузок, у каждой задачи своя поддиректория (пусто = не перехватывать)
+	DownloadTimeout time.Duration         // сколько ждать завершения загрузки после действия
+	Extractor       string                // представление страницы: dom (HTML) или ax (дерево доступности)
+	DialogPolicy    string                // что делать с confirm/prompt/beforeunload: ask, accept или dismiss
+	Secrets         action.SecretResolver // значения для {{secret:name}} в вводимом тексте (nil = не настроено)
 }
 
 // New создаёт новый контроллер браузера
-func New(ctx context.Context, headless bool, userDataDir string, timeoutSec int) (*Controller, error) {
+func New(ctx context.Context, headless bool, userDataDir string, timeoutSec int, opts Options) (*Controller, error) {
 	timeout := time.Duration(timeoutSec) * time.Second
 	if timeout == 0 {
 		timeout = 30 * time.Second
 	}
 
+	var extractor dom.ContextExtractor
+	switch opts.Extractor {
+	case "dom", "":
+		extractor = dom.NewExtractor()
+	case "ax":
+		var redact func(string) string
+		if opts.Secrets != nil {
+			redact = opts.Secrets.Redact
+		}
+		extractor = dom.NewAXExtractor(redact)
+	default:
+		return nil, fmt.Errorf("unknown extractor: %q (expected dom or ax)", opts.Extractor)
+	}
+
+	switch opts.DialogPolicy {
+	case "":
+		opts.DialogPolicy = DialogPolicyAsk
+	case DialogPolicyAsk, DialogPolicyAccept, DialogPolicyDismiss:
+	default:
+		return nil, fmt.Errorf("unknown dialog policy: %q (expected ask, accept or dismiss)", opts.DialogPolicy)
+	}
+
 	l := launcher.New().Headless(headless).Devtools(false)
 	if userDataDir != "" {
 		l = l.UserDataDir(userDataDir)
@@ -53,14 +91,42 @@ func New(ctx context.Context, headless bool, userDataDir string, timeoutSec int)
 
 	page.Timeout(timeout).WaitLoad()
 
-	logger.Info(ctx, "✅ Browser initialized", zap.Bool("headless", headless), zap.Duration("timeout", timeout))
-	return &Controller{browser: browser, page: page, timeout: timeout, extractor: dom.NewExtractor()}, nil
+	if opts.DownloadTimeout == 0 {
+		opts.DownloadTimeout = 2 * time.Minute
+	}
+	downloads := newDownloadTracker()
+	downloads.listen(ctx, browser)
+	dialogs := newDialogTracker(opts.DialogPolicy)
+	dialogs.listen(ctx, browser)
+
+	logger.Info(ctx, "✅ Browser initialized", zap.Bool("headless", headless), zap.Duration("timeout", timeout),
+		zap.String("extractor", opts.Extractor), zap.String("dialogs", opts.DialogPolicy))
+	return &Controller{
+		browser: browser, page: page, timeout: timeout, extractor: extractor, opts: opts,
+		downloads: downloads, dialogs: dialogs,
+	}, nil
+}
+
+// StartTask готовит браузер к новой задаче: загрузки идут в <DownloadDir>/<taskID>
+func (c *Controller) StartTask(ctx context.Context, taskID string) error {
+	if c.opts.DownloadDir == "" {
+		return nil
+	}
+	dir := filepath.Join(c.opts.DownloadDir, taskID)
+	if err := c.downloads.setDir(c.browser, dir); err != nil {
+		return fmt.Errorf("download dir: %w", err)
+	}
+	logger.Debug(ctx, "📥 Downloads directory", zap.String("dir", dir))
+	return nil
 }
 
 // --- PageProvider interface ---
 
 func (c *Controller) GetPage() *rod.Page        { return c.page }
 func (c *Controller) GetTimeout() time.Duration { return c.timeout }
+
+// Secrets хранилище секретов для ввода текста
+func (c *Controller) Secrets() action.SecretResolver { return c.opts.Secrets }
 func (c *Controller) WaitStable(timeout time.Duration) {
 	c.page.Timeout(timeout).WaitStable(300 * time.Millisecond)
 }
@@ -74,10 +140,16 @@ func (c *Controller) GetHTML(ctx context.Context) (string, error) {
 	if c.page == nil {
 		return "", fmt.Errorf("page is nil")
 	}
+	if err := c.dialogErr(); err != nil {
+		return "", err
+	}
 	return c.page.Timeout(c.timeout).HTML()
 }
 
 func (c *Controller) GetPageContext(ctx context.Context) (*domain.PageContext, error) {
+	if d := c.OpenDialog(); d != nil {
+		return dialogContext(d), nil
+	}
 	return c.extractor.ExtractContext(ctx, c.page)
 }
 
@@ -132,6 +204,51 @@ func (c *Controller) PressEnter(ctx context.Context) error {
 	return action.PressEnter(ctx, c)
 }
 
+func (c *Controller) PressKeys(ctx context.Context, chord string, repeat int) error {
+	return action.PressChord(ctx, c, chord, repeat)
+}
+
+func (c *Controller) Hover(ctx context.Context, selector string) error {
+	return action.Hover(ctx, c, selector)
+}
+
+func (c *Controller) ScrollIntoView(ctx context.Context, selector string) error {
+	return action.ScrollIntoView(ctx, c, selector)
+}
+
+func (c *Controller) Drag(ctx context.Context, selector string, target action.DragTarget) (*action.DragResult, error) {
+	return action.Drag(ctx, c, selector, target)
+}
+
+func (c *Controller) GoBack(ctx context.Context) error    { return action.GoBack(ctx, c) }
+func (c *Controller) GoForward(ctx context.Context) error { return action.GoForward(ctx, c) }
+func (c *Controller) Reload(ctx context.Context) error    { return action.Reload(ctx, c) }
+
+// UploadFile прикрепляет файлы из разрешённой директории к полю загрузки
+func (c *Controller) UploadFile(ctx context.Context, selector string, files []string) ([]string, error) {
+	paths, err := action.ResolveUploadFiles(c.opts.UploadDir, files)
+	if err != nil {
+		return nil, err
+	}
+	return action.UploadFile(ctx, c, selector, paths)
+}
+
+func (c *Controller) SelectOption(ctx context.Context, selector string, q action.OptionQuery) (*action.SelectedOption, error) {
+	return action.SelectOption(ctx, c, selector, q)
+}
+
+func (c *Controller) FillForm(ctx context.Context, fields []action.FormField, submit bool) (*action.FormResult, error) {
+	return action.FillForm(ctx, c, fields, submit)
+}
+
+// FormSubmitLabel текст кнопки, которой fill_form отправит форму - для проверки безопасности
+func (c *Controller) FormSubmitLabel(fields []domain.FormField) string {
+	if c.dialogErr() != nil {
+		return ""
+	}
+	return action.SubmitLabel(c, formFields(fields))
+}
+
 // --- DOM (delegate to dom package) ---
 
 func (c *Controller) BuildErrorContext(ctx context.Context, failedSelector string, err error) *domain.ErrorContext {
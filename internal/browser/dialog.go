@@ -0,0 +1,244 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"go.uber.org/zap"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
+)
+
+// Политики JavaScript диалогов (confirm, prompt, beforeunload). alert закрывается всегда:
+// у него одна кнопка и решать нечего
+const (
+	DialogPolicyAsk     = "ask"     // оставить диалог открытым, решает модель через handle_dialog
+	DialogPolicyAccept  = "accept"  // принять после проверки безопасности (делает агент)
+	DialogPolicyDismiss = "dismiss" // отклонить сразу
+)
+
+// dialog открытый или уже закрытый диалог страницы
+type dialog struct {
+	session proto.TargetSessionID
+	auto    bool // закрывается трекером по политике, модели не показываем как открытый
+	info    domain.Dialog
+}
+
+// dialogTracker следит за диалогами всех вкладок. Пока диалог открыт, страница стоит:
+// действие, которое его вызвало, не завершится, пока диалог не закрыть
+type dialogTracker struct {
+	eventLog[*dialog]
+	policy string
+}
+
+func newDialogTracker(policy string) *dialogTracker {
+	return &dialogTracker{eventLog: newEventLog[*dialog](), policy: policy}
+}
+
+// listen подписывается на открытие и закрытие диалогов
+func (t *dialogTracker) listen(ctx context.Context, browser *rod.Browser) {
+	subscribe(browser, func(e *proto.PageJavascriptDialogOpening, session proto.TargetSessionID) {
+		logger.Info(ctx, "💬 Dialog opened", zap.String("type", string(e.Type)), zap.String("message", e.Message))
+		t.mu.Lock()
+		d := &dialog{
+			session: session,
+			auto:    e.Type == proto.PageDialogTypeAlert || t.policy == DialogPolicyDismiss,
+			info:    domain.Dialog{Type: string(e.Type), Message: e.Message, URL: e.URL, DefaultPrompt: e.DefaultPrompt},
+		}
+		t.items = append(t.items, d)
+		t.notifyLocked()
+		t.mu.Unlock()
+
+		if d.auto {
+			// Вызов из обработчика событий заблокировал бы чтение ответа - отвечаем отдельно
+			go func() {
+				if err := t.resolve(browser, d, e.Type == proto.PageDialogTypeAlert, ""); err != nil {
+					logger.Warn(ctx, "⚠️ Failed to close dialog", zap.Error(err))
+				}
+			}()
+		}
+	}, func(e *proto.PageJavascriptDialogClosed, session proto.TargetSessionID) {
+		// Диалог мог закрыть сам сайт или навигация
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		for _, d := range t.items {
+			if d.session == session && d.info.Outcome == "" {
+				d.info.Outcome = outcome(e.Result)
+				t.notifyLocked()
+			}
+		}
+	})
+}
+
+// resolve отвечает на диалог
+func (t *dialogTracker) resolve(browser *rod.Browser, d *dialog, accept bool, promptText string) error {
+	err := proto.PageHandleJavaScriptDialog{Accept: accept, PromptText: promptText}.Call(browser.PageFromSession(d.session))
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	d.info.Outcome = outcome(accept)
+	t.notifyLocked()
+	return nil
+}
+
+// open возвращает самый ранний открытый диалог, ждущий решения
+func (t *dialogTracker) open() *dialog {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, d := range t.items {
+		if d.info.Outcome == "" && !d.auto {
+			return d
+		}
+	}
+	return nil
+}
+
+// snapshot копия описания диалога
+func (t *dialogTracker) snapshot(d *dialog) domain.Dialog {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return d.info
+}
+
+// since возвращает диалоги, открывшиеся после отметки
+func (t *dialogTracker) since(mark int) []domain.Dialog {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	items := t.sinceLocked(mark)
+	if len(items) == 0 {
+		return nil
+	}
+	out := make([]domain.Dialog, 0, len(items))
+	for _, d := range items {
+		out = append(out, d.info)
+	}
+	return out
+}
+
+func outcome(accepted bool) string {
+	if accepted {
+		return "accepted"
+	}
+	return "dismissed"
+}
+
+// actionDone результат действия, выполняемого в фоне
+type actionDone struct {
+	r   *domain.ActionResult
+	err error
+}
+
+// runAction выполняет действие, но не ждёт его, если страница открыла диалог:
+// действие продолжится после handle_dialog
+func (c *Controller) runAction(ctx context.Context, a domain.Action) (*domain.ActionResult, error) {
+	if a.Type == domain.ActionTypeHandleDialog {
+		return c.execHandleDialog(ctx, a)
+	}
+	done := make(chan actionDone, 1)
+	go func() {
+		r, err := c.executeAction(ctx, a)
+		done <- actionDone{r, err}
+	}()
+	return c.await(ctx, a, done)
+}
+
+// await ждёт завершения действия или открытия диалога, который его блокирует
+func (c *Controller) await(ctx context.Context, a domain.Action, done chan actionDone) (*domain.ActionResult, error) {
+	for {
+		changed := c.dialogs.changes()
+		if c.dialogs.open() != nil {
+			c.blocked = done
+			return ok(a, fmt.Sprintf("%s is waiting: the page opened a dialog", a.Type)), nil
+		}
+		select {
+		case res := <-done:
+			return res.r, res.err
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// execHandleDialog отвечает на открытый диалог и дожидается действия, которое он блокировал
+func (c *Controller) execHandleDialog(ctx context.Context, a domain.Action) (*domain.ActionResult, error) {
+	d := c.dialogs.open()
+	if d == nil {
+		return fail(a, "No open dialog"), nil
+	}
+	if err := c.dialogs.resolve(c.browser, d, a.Accept, a.Value); err != nil {
+		return fail(a, "Handle dialog failed: "+err.Error()), nil
+	}
+	info := c.dialogs.snapshot(d)
+	logger.Info(ctx, "💬 Dialog handled", zap.String("type", info.Type), zap.String("outcome", info.Outcome))
+
+	r := ok(a, "Dialog "+info.String())
+	r.Dialogs = []domain.Dialog{info}
+	if c.blocked == nil {
+		return r, nil
+	}
+	blocked := c.blocked
+	c.blocked = nil
+	res, err := c.await(ctx, a, blocked)
+	if err != nil {
+		return nil, err
+	}
+	if res != nil {
+		r.Message += "\n" + res.Message
+		r.Success = res.Success
+		r.ErrorContext = res.ErrorContext
+	}
+	return r, nil
+}
+
+// reportDialogs добавляет к результату диалоги, открывшиеся во время действия
+func (c *Controller) reportDialogs(r *domain.ActionResult, mark int) {
+	dialogs := c.dialogs.since(mark)
+	if len(dialogs) == 0 {
+		return
+	}
+	r.Dialogs = append(r.Dialogs, dialogs...)
+	for _, d := range dialogs {
+		if d.Outcome == "" {
+			r.Message += "\n💬 Dialog open: " + d.String() + " - the page waits for an answer, call handle_dialog"
+		} else {
+			r.Message += "\n💬 Dialog: " + d.String()
+		}
+	}
+}
+
+// OpenDialog открытый диалог, которого ждёт страница (nil если нет)
+func (c *Controller) OpenDialog() *domain.Dialog {
+	d := c.dialogs.open()
+	if d == nil {
+		return nil
+	}
+	info := c.dialogs.snapshot(d)
+	return &info
+}
+
+// dialogErr ошибка для операций со страницей, пока она ждёт ответа на диалог:
+// любой вызов в неё повис бы до таймаута
+func (c *Controller) dialogErr() error {
+	if d := c.OpenDialog(); d != nil {
+		return fmt.Errorf("page is blocked by a dialog: %s", d)
+	}
+	return nil
+}
+
+// dialogContext контекст страницы, пока она ждёт ответа на диалог
+func dialogContext(d *domain.Dialog) *domain.PageContext {
+	return &domain.PageContext{
+		URL: d.URL, Title: "Диалог сайта", InteractiveElems: []domain.Element{},
+		VisibleText: "💬 Страница ждёт ответа на диалог: " + d.String() + ". Ответь через handle_dialog.",
+		Metadata:    map[string]string{"dialog": d.Type},
+	}
+}
+
+// DialogPolicy политика диалогов confirm/prompt/beforeunload
+func (c *Controller) DialogPolicy() string { return c.dialogs.policy }
@@ -0,0 +1,329 @@
+package dom
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"go.uber.org/zap"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
+)
+
+const (
+	maxAXElements  = 200        // сколько узлов дерева доступности отдаём модели
+	axObjectGroup  = "agent-ax" // группа JS объектов, освобождаемых после извлечения
+	axViewportSpan = 2          // узлы дальше стольких экранов от viewport отбрасываем
+)
+
+// axInteractiveRoles роли, с которыми модель может взаимодействовать, и их тип в domain.Element
+var axInteractiveRoles = map[string]string{
+	"button": "button", "menuitem": "button", "menuitemcheckbox": "button", "menuitemradio": "button",
+	"tab": "button", "checkbox": "button", "radio": "button", "switch": "button", "treeitem": "button",
+	"link":    "link",
+	"textbox": "input", "searchbox": "input", "spinbutton": "input", "slider": "input",
+	"combobox": "select", "listbox": "select", "option": "select",
+}
+
+// axStructuralRoles роли, задающие структуру страницы; попадают в дерево, только если у них есть имя
+// (кроме main и dialog, которые полезны всегда)
+var axStructuralRoles = map[string]bool{
+	"heading": true, "navigation": true, "main": true, "form": true, "search": true,
+	"dialog": true, "alertdialog": true, "banner": true, "contentinfo": true,
+	"region": true, "complementary": true, "table": true, "list": true,
+}
+
+// AXExtractor извлекает контекст страницы из дерева доступности (Accessibility.getFullAXTree):
+// роли, доступные имена, состояния и вложенность вместо разбора HTML
+type AXExtractor struct {
+	maxTextChars int
+	redact       func(string) string
+}
+
+// NewAXExtractor создаёт экстрактор на основе дерева доступности. redact применяется к значениям
+// полей до сокращения: обрезанный или экранированный секрет потом уже не найти (nil = как есть)
+func NewAXExtractor(redact func(string) string) *AXExtractor {
+	if redact == nil {
+		redact = func(s string) string { return s }
+	}
+	return &AXExtractor{maxTextChars: 20000, redact: redact}
+}
+
+// ExtractContext извлекает контекст страницы
+func (e *AXExtractor) ExtractContext(ctx context.Context, page *rod.Page) (*domain.PageContext, error) {
+	info, err := page.Info()
+	if err != nil {
+		return emptyContext("Страница недоступна"), nil
+	}
+
+	if info.URL == "" || info.URL == "about:blank" {
+		return emptyContext("Пустая страница"), nil
+	}
+	defer proto.RuntimeReleaseObjectGroup{ObjectGroup: axObjectGroup}.Call(page)
+
+	layout, err := captureAXLayout(page)
+	if err != nil {
+		return nil, fmt.Errorf("layout snapshot: %w", err)
+	}
+	w := &axWalker{page: page, redact: e.redact, layout: layout}
+	if err := w.walkFrame("", ""); err != nil {
+		return nil, fmt.Errorf("accessibility tree: %w", err)
+	}
+	// Дерево iframe не входит в дерево страницы - запрашиваем по frameId,
+	// селекторы узлов получают префикс-цепочку (iframe#pay >> #card)
+	frames := ListFrames(page)
+	for _, f := range frames {
+		if err := w.walkFrame(f.Page.FrameID, f.Chain); err != nil {
+			logger.Warn(ctx, "⚠️ Frame accessibility tree unavailable", zap.String("frame", f.Chain), zap.Error(err))
+		}
+	}
+
+	logger.Info(ctx, "✅ Page context extracted (accessibility tree)",
+		zap.Int("nodes", len(w.elems)), zap.Int("frames", len(frames)))
+	return &domain.PageContext{
+		URL: info.URL, Title: info.Title,
+		InteractiveElems: w.elems,
+		VisibleText:      e.truncateText(strings.Join(w.texts, " ")),
+		Metadata:         map[string]string{"extractor": "ax"},
+	}, nil
+}
+
+func (e *AXExtractor) truncateText(text string) string {
+	if len(text) > e.maxTextChars {
+		text = text[:e.maxTextChars] + "..."
+	}
+	return text
+}
+
+// axWalker обходит деревья доступности страницы и её фреймов
+type axWalker struct {
+	page   *rod.Page
+	redact func(string) string
+	layout axLayout
+	elems  []domain.Element
+	texts  []string
+}
+
+// axCandidate узел дерева, который может попасть к модели
+type axCandidate struct {
+	node        *proto.AccessibilityAXNode
+	role, name  string
+	elemType    string
+	interactive bool
+	parent      int // ближайший предок-кандидат, -1 - нет
+}
+
+// axPick кандидат, прошедший проверку видимости
+type axPick struct {
+	cand  int
+	depth int
+	box   axBox
+}
+
+func (w *axWalker) walkFrame(frameID proto.PageFrameID, prefix string) error {
+	res, err := proto.AccessibilityGetFullAXTree{FrameID: frameID}.Call(w.page)
+	if err != nil {
+		return err
+	}
+	if len(res.Nodes) == 0 {
+		return nil
+	}
+
+	byID := make(map[proto.AccessibilityAXNodeID]*proto.AccessibilityAXNode, len(res.Nodes))
+	for _, n := range res.Nodes {
+		byID[n.NodeID] = n
+	}
+
+	var cands []axCandidate
+	var walk func(n *proto.AccessibilityAXNode, parent int)
+	walk = func(n *proto.AccessibilityAXNode, parent int) {
+		// Скрытые узлы (display:none, aria-hidden) помечены ignored, но их потомки могут быть видимы
+		if !n.Ignored {
+			role, name := axString(n.Role), strings.TrimSpace(axString(n.Name))
+			if role == "StaticText" {
+				if name != "" && (len(w.texts) == 0 || w.texts[len(w.texts)-1] != name) {
+					w.texts = append(w.texts, name)
+				}
+				return
+			}
+			if c, ok := axCandidateOf(n, role, name); ok {
+				c.parent = parent
+				cands = append(cands, c)
+				parent = len(cands) - 1
+			}
+		}
+		for _, id := range n.ChildIDs {
+			if child, ok := byID[id]; ok {
+				walk(child, parent)
+			}
+		}
+	}
+	walk(res.Nodes[0], -1)
+
+	picks := selectAX(cands, w.layout, maxAXElements-len(w.elems))
+	w.elems = append(w.elems, w.elements(cands, picks, prefix)...)
+	return nil
+}
+
+// axCandidateOf false - узел не нужен модели: не интерактивный и не структурный
+func axCandidateOf(n *proto.AccessibilityAXNode, role, name string) (axCandidate, bool) {
+	elemType, interactive := axInteractiveRoles[role]
+	switch {
+	case interactive:
+	case axStructuralRoles[role] && (name != "" || role == "main" || role == "dialog"):
+		elemType = role
+	default:
+		return axCandidate{}, false
+	}
+	if n.BackendDOMNodeID == 0 {
+		return axCandidate{}, false
+	}
+	return axCandidate{node: n, role: role, name: name, elemType: elemType, interactive: interactive}, true
+}
+
+// selectAX оставляет видимых кандидатов (не больше limit) и считает их вложенность:
+// глубина - число выбранных предков
+func selectAX(cands []axCandidate, layout axLayout, limit int) []axPick {
+	var picks []axPick
+	childDepth := make([]int, len(cands))
+	for i, c := range cands {
+		depth := 0
+		if c.parent >= 0 {
+			depth = childDepth[c.parent]
+		}
+		childDepth[i] = depth
+		if len(picks) >= limit {
+			break
+		}
+		box, ok := layout[c.node.BackendDOMNodeID]
+		if !ok || box.far() {
+			continue
+		}
+		picks = append(picks, axPick{cand: i, depth: depth, box: box})
+		childDepth[i] = depth + 1
+	}
+	return picks
+}
+
+// elements превращает выбранные узлы в domain.Element. Селекторы считаются одним вызовом
+// JS на все узлы фрейма; узлы, которые не удалось найти в DOM, пропускаются
+func (w *axWalker) elements(cands []axCandidate, picks []axPick, prefix string) []domain.Element {
+	objs := make([]interface{}, 0, len(picks))
+	resolved := make([]axPick, 0, len(picks))
+	for _, pk := range picks {
+		node, err := proto.DOMResolveNode{BackendNodeID: cands[pk.cand].node.BackendDOMNodeID, ObjectGroup: axObjectGroup}.Call(w.page)
+		if err != nil {
+			continue
+		}
+		objs = append(objs, node.Object)
+		resolved = append(resolved, pk)
+	}
+	if len(objs) == 0 {
+		return nil
+	}
+	first, err := w.page.ElementFromObject(objs[0].(*proto.RuntimeRemoteObject))
+	if err != nil {
+		return nil
+	}
+	res, err := first.Eval(axNodesInfoJS, objs...)
+	if err != nil {
+		return nil
+	}
+	infos := res.Value.Arr()
+
+	elems := make([]domain.Element, 0, len(resolved))
+	for i, pk := range resolved {
+		if i >= len(infos) || infos[i].Nil() {
+			continue
+		}
+		v, c := infos[i], cands[pk.cand]
+		states := axStates(c.node, c.role, w.redact)
+		if pk.box.offscreen() {
+			states = append(states, "offscreen")
+		}
+		elems = append(elems, domain.Element{
+			Tag: v.Get("tag").Str(), Text: c.name,
+			Selector: JoinChain(prefix, v.Get("selector").Str()),
+			Type:     c.elemType, Role: c.role, States: states, Depth: pk.depth,
+			Visible: true, Clickable: c.interactive && c.elemType != "input",
+			Href: v.Get("href").Str(), ID: v.Get("id").Str(),
+		})
+	}
+	return elems
+}
+
+// axStates собирает состояния узла: disabled, checked, expanded/collapsed, значение поля и т.п.
+func axStates(n *proto.AccessibilityAXNode, role string, redact func(string) string) []string {
+	var states []string
+	for _, p := range n.Properties {
+		val := axString(p.Value)
+		switch p.Name {
+		case proto.AccessibilityAXPropertyNameDisabled, proto.AccessibilityAXPropertyNameRequired,
+			proto.AccessibilityAXPropertyNameFocused, proto.AccessibilityAXPropertyNameReadonly,
+			proto.AccessibilityAXPropertyNameSelected, proto.AccessibilityAXPropertyNameModal:
+			if val == "true" {
+				states = append(states, string(p.Name))
+			}
+		case proto.AccessibilityAXPropertyNameChecked, proto.AccessibilityAXPropertyNamePressed:
+			switch val {
+			case "true":
+				states = append(states, string(p.Name))
+			case "mixed":
+				states = append(states, "mixed")
+			default:
+				states = append(states, "not "+string(p.Name))
+			}
+		case proto.AccessibilityAXPropertyNameExpanded:
+			if val == "true" {
+				states = append(states, "expanded")
+			} else {
+				states = append(states, "collapsed")
+			}
+		case proto.AccessibilityAXPropertyNameInvalid:
+			if val != "" && val != "false" {
+				states = append(states, "invalid")
+			}
+		case proto.AccessibilityAXPropertyNameLevel:
+			if role == "heading" {
+				states = append(states, "level "+val)
+			}
+		}
+	}
+	if _, ok := axInteractiveRoles[role]; ok {
+		if val := strings.TrimSpace(redact(axString(n.Value))); val != "" {
+			if len(val) > 40 {
+				val = val[:40] + "..."
+			}
+			states = append(states, fmt.Sprintf("value=%q", val))
+		}
+	}
+	return states
+}
+
+// axString возвращает значение AX свойства строкой ("" если его нет)
+func axString(v *proto.AccessibilityAXValue) string {
+	if v == nil || v.Value.Nil() {
+		return ""
+	}
+	return v.Value.Str()
+}
+
+// axNodesInfoJS возвращает селектор, тег, id и href каждого узла из аргументов (null - не элемент)
+const axNodesInfoJS = `(...nodes) => {` + JSHelpers + `
+	return nodes.map(el => {
+		if (!(el instanceof Element)) return null;
+		const chain = [uniqueSelector(el)];
+		for (let root = el.getRootNode(); root instanceof ShadowRoot; root = root.host.getRootNode()) {
+			chain.unshift(uniqueSelector(root.host));
+		}
+		return {
+			selector: chain.join(' >> '),
+			tag: el.tagName.toLowerCase(),
+			id: el.id || '',
+			href: typeof el.href === 'string' ? el.href : '',
+		};
+	});
+}`
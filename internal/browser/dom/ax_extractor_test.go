@@ -0,0 +1,225 @@
+package dom
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/ysmood/gson"
+)
+
+func axValue(v interface{}) *proto.AccessibilityAXValue {
+	return &proto.AccessibilityAXValue{Value: gson.New(v)}
+}
+
+func axProp(name proto.AccessibilityAXPropertyName, v interface{}) *proto.AccessibilityAXProperty {
+	return &proto.AccessibilityAXProperty{Name: name, Value: axValue(v)}
+}
+
+func TestAXStates(t *testing.T) {
+	tests := []struct {
+		name   string
+		role   string
+		node   *proto.AccessibilityAXNode
+		want   []string
+		redact func(string) string
+	}{
+		{
+			name: "checkbox",
+			role: "checkbox",
+			node: &proto.AccessibilityAXNode{Properties: []*proto.AccessibilityAXProperty{
+				axProp(proto.AccessibilityAXPropertyNameChecked, "false"),
+				axProp(proto.AccessibilityAXPropertyNameDisabled, true),
+				axProp(proto.AccessibilityAXPropertyNameFocused, false),
+			}},
+			want: []string{"not checked", "disabled"},
+		},
+		{
+			name: "tri-state and expanded",
+			role: "button",
+			node: &proto.AccessibilityAXNode{Properties: []*proto.AccessibilityAXProperty{
+				axProp(proto.AccessibilityAXPropertyNamePressed, "mixed"),
+				axProp(proto.AccessibilityAXPropertyNameExpanded, false),
+			}},
+			want: []string{"mixed", "collapsed"},
+		},
+		{
+			name: "heading level only for headings",
+			role: "heading",
+			node: &proto.AccessibilityAXNode{Properties: []*proto.AccessibilityAXProperty{
+				axProp(proto.AccessibilityAXPropertyNameLevel, 2),
+			}},
+			want: []string{"level 2"},
+		},
+		{
+			name: "invalid field value is truncated",
+			role: "textbox",
+			node: &proto.AccessibilityAXNode{
+				Value: axValue(strings.Repeat("x", 50)),
+				Properties: []*proto.AccessibilityAXProperty{
+					axProp(proto.AccessibilityAXPropertyNameInvalid, "spelling"),
+					axProp(proto.AccessibilityAXPropertyNameRequired, true),
+				},
+			},
+			want: []string{"invalid", "required", `value="` + strings.Repeat("x", 40) + `..."`},
+		},
+		{
+			// Секрет заменяется до сокращения - иначе его обрезанный хвост остался бы в контексте
+			name:   "value redacted before truncation",
+			role:   "textbox",
+			node:   &proto.AccessibilityAXNode{Value: axValue("token " + strings.Repeat("s", 45))},
+			redact: func(s string) string { return strings.ReplaceAll(s, strings.Repeat("s", 45), "{{secret:api}}") },
+			want:   []string{`value="token {{secret:api}}"`},
+		},
+		{
+			name: "no value for structural roles",
+			role: "region",
+			node: &proto.AccessibilityAXNode{Value: axValue("ignored")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redact := tt.redact
+			if redact == nil {
+				redact = func(s string) string { return s }
+			}
+			if got := axStates(tt.node, tt.role, redact); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("axStates = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// snapshot страница 1000x800 (в снимке пиксели устройства, scale 2), прокрученная на 100 CSS px,
+// с iframe 300x200 CSS px
+func snapshot() *proto.DOMSnapshotCaptureSnapshotResult {
+	f := func(v float64) *float64 { return &v }
+	styles := func(idx ...proto.DOMSnapshotStringIndex) []proto.DOMSnapshotArrayOfStrings {
+		out := make([]proto.DOMSnapshotArrayOfStrings, len(idx))
+		for i, x := range idx {
+			out[i] = proto.DOMSnapshotArrayOfStrings{x}
+		}
+		return out
+	}
+	return &proto.DOMSnapshotCaptureSnapshotResult{
+		Strings: []string{"visible", "hidden"},
+		Documents: []*proto.DOMSnapshotDocumentSnapshot{
+			{
+				ScrollOffsetY: f(200),
+				Nodes: &proto.DOMSnapshotNodeTreeSnapshot{
+					BackendNodeID:        []proto.DOMBackendNodeID{1, 2, 3, 4, 5, 6},
+					ContentDocumentIndex: &proto.DOMSnapshotRareIntegerData{Index: []int{5}, Value: []int{1}},
+				},
+				// Узел 4 (display:none) без layout
+				Layout: &proto.DOMSnapshotLayoutTreeSnapshot{
+					NodeIndex: []int{0, 1, 2, 4, 5},
+					Bounds: []proto.DOMSnapshotRectangle{
+						{0, 0, 2000, 8000},   // html
+						{20, 400, 200, 60},   // кнопка: в CSS (10,100)-(110,130), после прокрутки top=100
+						{20, 5000, 200, 60},  // visibility:hidden
+						{20, 2200, 200, 1.5}, // меньше пикселя по высоте
+						{0, 1800, 600, 400},  // iframe 300x200
+					},
+					Styles: styles(0, 0, 1, 0, 0),
+				},
+			},
+			{
+				Nodes: &proto.DOMSnapshotNodeTreeSnapshot{BackendNodeID: []proto.DOMBackendNodeID{10, 11}},
+				Layout: &proto.DOMSnapshotLayoutTreeSnapshot{
+					NodeIndex: []int{1},
+					Bounds:    []proto.DOMSnapshotRectangle{{0, 500, 100, 40}},
+					Styles:    styles(0),
+				},
+			},
+			// Документ скрытого iframe: владельца в layout нет
+			{
+				Nodes:  &proto.DOMSnapshotNodeTreeSnapshot{BackendNodeID: []proto.DOMBackendNodeID{20}},
+				Layout: &proto.DOMSnapshotLayoutTreeSnapshot{NodeIndex: []int{0}, Bounds: []proto.DOMSnapshotRectangle{{0, 0, 100, 100}}},
+			},
+		},
+	}
+}
+
+func TestNewAXLayout(t *testing.T) {
+	layout := newAXLayout(snapshot(), 2, 1000, 800)
+
+	if got, want := layout[2], (axBox{top: 100, bottom: 130, left: 10, right: 110, vw: 1000, vh: 800}); got != want {
+		t.Errorf("button box = %+v, want %+v", got, want)
+	}
+	for _, id := range []proto.DOMBackendNodeID{3, 4, 5, 20} {
+		if _, ok := layout[id]; ok {
+			t.Errorf("node %d must not be visible", id)
+		}
+	}
+	// Узел iframe считается относительно viewport iframe
+	if got, want := layout[11], (axBox{top: 250, bottom: 270, left: 0, right: 50, vw: 300, vh: 200}); got != want {
+		t.Errorf("iframe node box = %+v, want %+v", got, want)
+	}
+	if !layout[11].offscreen() || layout[2].offscreen() {
+		t.Error("offscreen")
+	}
+}
+
+func TestSelectAX(t *testing.T) {
+	node := func(id proto.DOMBackendNodeID) *proto.AccessibilityAXNode {
+		return &proto.AccessibilityAXNode{BackendDOMNodeID: id}
+	}
+	visible := axBox{top: 10, bottom: 40, right: 100, vw: 1000, vh: 800}
+	layout := axLayout{
+		1: visible, 3: visible, 4: visible, 5: visible,
+		2: {top: 5000, bottom: 5040, right: 100, vw: 1000, vh: 800}, // дальше двух экранов
+	}
+	// main(1) > form(2, далеко) > button(3); main(1) > link(4) > button(5); node 6 без layout
+	cands := []axCandidate{
+		{node: node(1), parent: -1},
+		{node: node(2), parent: 0},
+		{node: node(3), parent: 1},
+		{node: node(4), parent: 0},
+		{node: node(5), parent: 3},
+		{node: node(6), parent: -1},
+	}
+
+	got := selectAX(cands, layout, 10)
+	want := []axPick{
+		{cand: 0, depth: 0, box: visible},
+		{cand: 2, depth: 1, box: visible}, // невидимая форма не добавляет уровень
+		{cand: 3, depth: 1, box: visible},
+		{cand: 4, depth: 2, box: visible},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("selectAX = %+v, want %+v", got, want)
+	}
+	if got := selectAX(cands, layout, 2); len(got) != 2 || got[1].cand != 2 {
+		t.Errorf("limited selectAX = %+v", got)
+	}
+	if got := selectAX(cands, layout, 0); len(got) != 0 {
+		t.Errorf("selectAX over limit = %+v", got)
+	}
+}
+
+func TestAXCandidateOf(t *testing.T) {
+	n := &proto.AccessibilityAXNode{BackendDOMNodeID: 7}
+	tests := []struct {
+		role, name string
+		ok         bool
+		elemType   string
+	}{
+		{"button", "", true, "button"},
+		{"searchbox", "Search", true, "input"},
+		{"option", "Moscow", true, "select"},
+		{"navigation", "Main menu", true, "navigation"},
+		{"navigation", "", false, ""},
+		{"main", "", true, "main"},
+		{"generic", "wrapper", false, ""},
+	}
+	for _, tt := range tests {
+		c, ok := axCandidateOf(n, tt.role, tt.name)
+		if ok != tt.ok || c.elemType != tt.elemType {
+			t.Errorf("axCandidateOf(%s, %q) = %q, %v", tt.role, tt.name, c.elemType, ok)
+		}
+	}
+	if _, ok := axCandidateOf(&proto.AccessibilityAXNode{}, "button", "OK"); ok {
+		t.Error("node without DOM node accepted")
+	}
+}
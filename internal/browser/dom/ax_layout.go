@@ -0,0 +1,124 @@
+package dom
+
+import (
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// axBox положение узла относительно viewport его документа в CSS пикселях
+type axBox struct {
+	top, bottom, left, right float64
+	vw, vh                   float64 // размер viewport документа
+}
+
+// far узел дальше axViewportSpan экранов от viewport: модели он не нужен
+func (b axBox) far() bool {
+	return b.bottom < -b.vh*axViewportSpan || b.top > b.vh*(1+axViewportSpan)
+}
+
+// offscreen узел вне viewport: модели нужно прокрутить к нему
+func (b axBox) offscreen() bool {
+	return b.bottom <= 0 || b.top >= b.vh || b.right <= 0 || b.left >= b.vw
+}
+
+// axLayout видимые узлы страницы и её iframe по backend node id
+type axLayout map[proto.DOMBackendNodeID]axBox
+
+// captureAXLayout снимает раскладку всей страницы одним DOMSnapshot вместо запроса
+// на каждый узел дерева доступности
+func captureAXLayout(page *rod.Page) (axLayout, error) {
+	snap, err := proto.DOMSnapshotCaptureSnapshot{ComputedStyles: []string{"visibility"}}.Call(page)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := proto.PageGetLayoutMetrics{}.Call(page)
+	if err != nil {
+		return nil, err
+	}
+	// Снимок в пикселях устройства, viewport - в CSS пикселях
+	scale, vw, vh := 1.0, 0.0, 0.0
+	if css := metrics.CSSLayoutViewport; css != nil {
+		vw, vh = float64(css.ClientWidth), float64(css.ClientHeight)
+		if dev := metrics.LayoutViewport; dev != nil && css.ClientWidth > 0 && dev.ClientWidth > 0 {
+			scale = float64(dev.ClientWidth) / float64(css.ClientWidth)
+		}
+	}
+	return newAXLayout(snap, scale, vw, vh), nil
+}
+
+// newAXLayout раскладывает DOMSnapshot: положение узлов каждого документа относительно
+// его viewport (у iframe - размер элемента iframe). Узлы без layout (display:none),
+// нулевого размера и с visibility:hidden не попадают в раскладку, как и документы
+// скрытых iframe. scale - пикселей снимка в CSS пикселе, vw, vh - viewport страницы
+func newAXLayout(snap *proto.DOMSnapshotCaptureSnapshotResult, scale, vw, vh float64) axLayout {
+	str := func(i proto.DOMSnapshotStringIndex) string {
+		if i < 0 || int(i) >= len(snap.Strings) {
+			return ""
+		}
+		return snap.Strings[i]
+	}
+
+	// Документ iframe -> размер элемента iframe в родительском документе
+	type size struct{ w, h float64 }
+	viewports := map[int]size{}
+	for _, doc := range snap.Documents {
+		if doc.Nodes == nil || doc.Nodes.ContentDocumentIndex == nil || doc.Layout == nil {
+			continue
+		}
+		owners := map[int]int{} // узел iframe -> индекс документа
+		cdi := doc.Nodes.ContentDocumentIndex
+		for k, node := range cdi.Index {
+			if k < len(cdi.Value) {
+				owners[node] = cdi.Value[k]
+			}
+		}
+		for li, node := range doc.Layout.NodeIndex {
+			if child, ok := owners[node]; ok && li < len(doc.Layout.Bounds) && len(doc.Layout.Bounds[li]) == 4 {
+				viewports[child] = size{doc.Layout.Bounds[li][2] / scale, doc.Layout.Bounds[li][3] / scale}
+			}
+		}
+	}
+
+	layout := axLayout{}
+	for d, doc := range snap.Documents {
+		if doc.Nodes == nil || doc.Layout == nil {
+			continue
+		}
+		vp, ok := viewports[d]
+		if !ok {
+			if d != 0 {
+				continue // iframe без layout не виден
+			}
+			vp = size{vw, vh}
+		}
+		var sx, sy float64
+		if doc.ScrollOffsetX != nil {
+			sx = *doc.ScrollOffsetX
+		}
+		if doc.ScrollOffsetY != nil {
+			sy = *doc.ScrollOffsetY
+		}
+		for li, node := range doc.Layout.NodeIndex {
+			if node < 0 || node >= len(doc.Nodes.BackendNodeID) || li >= len(doc.Layout.Bounds) {
+				continue
+			}
+			id := doc.Nodes.BackendNodeID[node]
+			if _, seen := layout[id]; seen {
+				continue
+			}
+			b := doc.Layout.Bounds[li]
+			if len(b) != 4 || b[2]/scale < 1 || b[3]/scale < 1 {
+				continue
+			}
+			if li < len(doc.Layout.Styles) && len(doc.Layout.Styles[li]) > 0 && str(doc.Layout.Styles[li][0]) == "hidden" {
+				continue
+			}
+			left, top := (b[0]-sx)/scale, (b[1]-sy)/scale
+			layout[id] = axBox{
+				top: top, bottom: top + b[3]/scale, left: left, right: left + b[2]/scale,
+				vw: vp.w, vh: vp.h,
+			}
+		}
+	}
+	return layout
+}
@@ -3,9 +3,11 @@ package dom
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/go-rod/rod"
+	"go.uber.org/zap"
 
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
@@ -25,11 +27,11 @@ func NewExtractor() *Extractor {
 func (e *Extractor) ExtractContext(ctx context.Context, page *rod.Page) (*domain.PageContext, error) {
 	info, err := page.Info()
 	if err != nil {
-		return e.emptyContext("Страница недоступна"), nil
+		return emptyContext("Страница недоступна"), nil
 	}
 
 	if info.URL == "" || info.URL == "about:blank" {
-		return e.emptyContext("Пустая страница"), nil
+		return emptyContext("Пустая страница"), nil
 	}
 
 	html, err := page.HTML()
@@ -42,19 +44,59 @@ func (e *Extractor) ExtractContext(ctx context.Context, page *rod.Page) (*domain
 		return nil, err
 	}
 
-	elements := e.extractElements(doc)
+	elements := e.extractElements(doc, "")
+	texts := []string{e.extractVisibleText(doc)}
+
+	// Содержимое shadow DOM и iframe не попадает в HTML страницы - разбираем отдельно,
+	// селекторы элементов получают префикс-цепочку (iframe#pay >> #card)
+	frags := e.fragments(page)
+	for _, f := range frags {
+		fdoc, err := goquery.NewDocumentFromReader(strings.NewReader(f.html))
+		if err != nil {
+			continue
+		}
+		elements = append(elements, e.extractElements(fdoc, f.prefix)...)
+		if text := e.extractVisibleText(fdoc); text != "" {
+			texts = append(texts, text)
+		}
+	}
 	elements = e.optimizeElements(elements)
 
-	logger.Info(ctx, "✅ Page context extracted")
+	logger.Info(ctx, "✅ Page context extracted", zap.Int("fragments", len(frags)))
 	return &domain.PageContext{
 		URL: info.URL, Title: info.Title,
 		InteractiveElems: elements,
-		VisibleText:      e.extractVisibleText(doc),
+		VisibleText:      e.truncateText(strings.Join(texts, " ")),
 		Metadata:         map[string]string{},
 	}, nil
 }
 
-func (e *Extractor) emptyContext(title string) *domain.PageContext {
+// fragment часть страницы вне её HTML: shadow root или документ iframe
+type fragment struct {
+	prefix string // цепочка селекторов до фрагмента
+	html   string
+}
+
+func (e *Extractor) fragments(page *rod.Page) []fragment {
+	var out []fragment
+	for _, s := range listShadowFragments(page) {
+		out = append(out, fragment{prefix: s.Chain, html: s.HTML})
+	}
+	for _, f := range ListFrames(page) {
+		html, err := f.Page.Timeout(2 * time.Second).HTML()
+		if err != nil {
+			continue
+		}
+		out = append(out, fragment{prefix: f.Chain, html: html})
+		for _, s := range listShadowFragments(f.Page) {
+			out = append(out, fragment{prefix: JoinChain(f.Chain, s.Chain), html: s.HTML})
+		}
+	}
+	return out
+}
+
+// emptyContext контекст страницы, на которой нечего извлекать
+func emptyContext(title string) *domain.PageContext {
 	return &domain.PageContext{
 		Title: title, InteractiveElems: []domain.Element{},
 		VisibleText: "Используйте navigate для перехода на сайт.",
@@ -62,7 +104,7 @@ func (e *Extractor) emptyContext(title string) *domain.PageContext {
 	}
 }
 
-func (e *Extractor) extractElements(doc *goquery.Document) []domain.Element {
+func (e *Extractor) extractElements(doc *goquery.Document, prefix string) []domain.Element {
 	var elems []domain.Element
 	selectors := map[string]string{
 		"button, input[type=submit], [role=button]": "button",
@@ -74,6 +116,7 @@ func (e *Extractor) extractElements(doc *goquery.Document) []domain.Element {
 	for sel, elemType := range selectors {
 		doc.Find(sel).Each(func(i int, s *goquery.Selection) {
 			elem := e.createElement(s, elemType)
+			elem.Selector = JoinChain(prefix, elem.Selector)
 			if elemType == "link" {
 				elem.Href, _ = s.Attr("href")
 			}
@@ -106,7 +149,10 @@ func (e *Extractor) extractVisibleText(doc *goquery.Document) string {
 		}
 	}
 
-	text := strings.Join(cleaned, " ")
+	return strings.Join(cleaned, " ")
+}
+
+func (e *Extractor) truncateText(text string) string {
 	if len(text) > e.maxTextChars {
 		text = text[:e.maxTextChars] + "..."
 	}
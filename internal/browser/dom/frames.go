@@ -0,0 +1,83 @@
+package dom
+
+import (
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+const (
+	maxFrameDepth = 3  // глубина вложенности iframe при обходе
+	maxFrames     = 20 // сколько фреймов страницы обходим
+)
+
+// Frame документ iframe, адресуемый цепочкой селекторов от страницы
+type Frame struct {
+	Chain string // iframe#pay или my-widget >> iframe:nth-of-type(1)
+	Page  *rod.Page
+}
+
+// ListFrames находит видимые iframe страницы, включая вложенные и лежащие в shadow DOM
+func ListFrames(page *rod.Page) []Frame {
+	var out []Frame
+	var walk func(p *rod.Page, prefix string, depth int)
+	walk = func(p *rod.Page, prefix string, depth int) {
+		if depth >= maxFrameDepth {
+			return
+		}
+		res, err := p.Timeout(2 * time.Second).Eval(frameChainsJS)
+		if err != nil {
+			return
+		}
+		for _, v := range res.Value.Arr() {
+			if len(out) >= maxFrames {
+				return
+			}
+			path, last := SplitChain(v.Str())
+			s, err := ResolveScope(p, append(path, last))
+			if err != nil {
+				continue
+			}
+			chain := JoinChain(prefix, v.Str())
+			out = append(out, Frame{Chain: chain, Page: s.Page})
+			walk(s.Page, chain, depth+1)
+		}
+	}
+	walk(page, "", 0)
+	return out
+}
+
+// shadowFragment содержимое открытого shadow root
+type shadowFragment struct {
+	Chain string
+	HTML  string
+}
+
+// listShadowFragments возвращает HTML всех открытых shadow roots документа
+func listShadowFragments(page *rod.Page) []shadowFragment {
+	res, err := page.Timeout(2 * time.Second).Eval(shadowFragmentsJS)
+	if err != nil {
+		return nil
+	}
+	var out []shadowFragment
+	for _, v := range res.Value.Arr() {
+		out = append(out, shadowFragment{Chain: v.Get("chain").Str(), HTML: v.Get("html").Str()})
+	}
+	return out
+}
+
+const frameChainsJS = `() => {` + JSHelpers + `
+	const out = [];
+	for (const {root, chain} of deepRoots(document)) {
+		for (const f of root.querySelectorAll('iframe, frame')) {
+			const r = f.getBoundingClientRect();
+			if (r.width < 2 || r.height < 2) continue;
+			out.push((chain ? chain + ' >> ' : '') + uniqueSelector(f));
+		}
+	}
+	return out;
+}`
+
+const shadowFragmentsJS = `() => {` + JSHelpers + `
+	return deepRoots(document).slice(1).map(({root, chain}) => ({chain, html: root.innerHTML}));
+}`
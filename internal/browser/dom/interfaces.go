@@ -1,9 +1,12 @@
 package dom
 
 import (
+	"context"
 	"time"
 
 	"github.com/go-rod/rod"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
 )
 
 // PageProvider - интерфейс для доступа к странице
@@ -11,3 +14,8 @@ type PageProvider interface {
 	GetPage() *rod.Page
 	GetTimeout() time.Duration
 }
+
+// ContextExtractor строит контекст страницы для модели
+type ContextExtractor interface {
+	ExtractContext(ctx context.Context, page *rod.Page) (*domain.PageContext, error)
+}
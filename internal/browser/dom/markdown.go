@@ -0,0 +1,356 @@
+package dom
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/go-rod/rod"
+)
+
+// MarkdownChunkSize сколько символов Markdown отдаёт один вызов read_page
+const MarkdownChunkSize = 8000
+
+// markdownNoise элементы, которые не относятся к основному содержимому страницы
+const markdownNoise = `script, style, noscript, template, svg, canvas, iframe, nav, aside,
+	body > header, body > footer, footer, button, input, select, textarea, dialog,
+	[hidden], [aria-hidden=true], [role=navigation], [role=banner], [role=contentinfo],
+	[role=complementary], [role=dialog]`
+
+// PageMarkdown фрагмент основного содержимого страницы в Markdown
+type PageMarkdown struct {
+	URL     string
+	Title   string
+	Content string
+	Offset  int // начало фрагмента в символах документа
+	Next    int // offset следующего фрагмента; 0 - документ прочитан до конца
+	Total   int // длина всего документа в символах
+}
+
+// ReadMarkdown переводит основное содержимое страницы в Markdown и возвращает фрагмент с offset
+func ReadMarkdown(page *rod.Page, offset int) (*PageMarkdown, error) {
+	info, err := page.Info()
+	if err != nil {
+		return nil, fmt.Errorf("page info: %w", err)
+	}
+	html, err := page.HTML()
+	if err != nil {
+		return nil, fmt.Errorf("page html: %w", err)
+	}
+	md, err := HTMLToMarkdown(html, info.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := []rune(md)
+	if offset < 0 || offset > len(doc) {
+		return nil, fmt.Errorf("offset %d is out of range (page has %d characters)", offset, len(doc))
+	}
+	end := chunkEnd(doc, offset, MarkdownChunkSize)
+	res := &PageMarkdown{URL: info.URL, Title: info.Title, Content: string(doc[offset:end]), Offset: offset, Total: len(doc)}
+	if end < len(doc) {
+		res.Next = end
+	}
+	return res, nil
+}
+
+// chunkEnd конец фрагмента: по границе абзаца или строки во второй половине фрагмента,
+// чтобы не резать текст посередине
+func chunkEnd(doc []rune, offset, size int) int {
+	end := offset + size
+	if end >= len(doc) {
+		return len(doc)
+	}
+	half := offset + size/2
+	for i := end - 1; i > half; i-- {
+		if doc[i] == '\n' && doc[i-1] == '\n' {
+			return i + 1
+		}
+	}
+	for i := end - 1; i > half; i-- {
+		if doc[i] == '\n' {
+			return i + 1
+		}
+	}
+	return end
+}
+
+// HTMLToMarkdown выделяет основное содержимое документа (без навигации, шапки, подвала)
+// и переводит его в Markdown: заголовки, абзацы, списки, ссылки, таблицы, код
+func HTMLToMarkdown(html, pageURL string) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return "", err
+	}
+	doc.Find(markdownNoise).Remove()
+
+	c := &mdConverter{}
+	c.base, _ = url.Parse(pageURL)
+	return cleanMarkdown(c.children(mainContent(doc))), nil
+}
+
+// mainContent находит основное содержимое: main/article, затем спускается по обёрткам
+// вёрстки, пока почти весь текст лежит в одном потомке
+func mainContent(doc *goquery.Document) *goquery.Selection {
+	root := doc.Find("body")
+	if root.Length() == 0 {
+		root = doc.Selection
+	}
+	for _, sel := range []string{"main", "[role=main]", "article"} {
+		if s := doc.Find(sel); s.Length() == 1 && textLen(s) > 200 {
+			root = s
+			break
+		}
+	}
+
+	for {
+		total := textLen(root)
+		var next *goquery.Selection
+		root.Children().EachWithBreak(func(_ int, s *goquery.Selection) bool {
+			if textLen(s)*10 >= total*9 {
+				next = s
+				return false
+			}
+			return true
+		})
+		if next == nil || total == 0 || isMarkdownBlock(next) {
+			return root
+		}
+		root = next
+	}
+}
+
+// isMarkdownBlock элемент содержимого, внутрь которого спускаться уже не нужно
+func isMarkdownBlock(s *goquery.Selection) bool {
+	switch goquery.NodeName(s) {
+	case "p", "ul", "ol", "table", "pre", "blockquote", "h1", "h2", "h3", "h4", "h5", "h6":
+		return true
+	}
+	return false
+}
+
+func textLen(s *goquery.Selection) int {
+	return len(strings.Join(strings.Fields(s.Text()), " "))
+}
+
+// mdBlocks элементы, которые начинают новый абзац
+var mdBlocks = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true, "header": true,
+	"figure": true, "figcaption": true, "details": true, "summary": true, "address": true,
+	"dl": true, "dt": true, "dd": true, "form": true, "fieldset": true, "center": true,
+}
+
+type mdConverter struct {
+	base *url.URL
+}
+
+func (c *mdConverter) children(s *goquery.Selection) string {
+	var sb strings.Builder
+	s.Contents().Each(func(_ int, n *goquery.Selection) {
+		sb.WriteString(c.node(n))
+	})
+	return sb.String()
+}
+
+func (c *mdConverter) node(s *goquery.Selection) string {
+	name := goquery.NodeName(s)
+	switch name {
+	case "#text":
+		return spaces.ReplaceAllString(s.Text(), " ")
+	case "#comment":
+		return ""
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := inline(c.children(s))
+		if text == "" {
+			return ""
+		}
+		return "\n\n" + strings.Repeat("#", int(name[1]-'0')) + " " + text + "\n\n"
+	case "br":
+		return "\n"
+	case "hr":
+		return "\n\n---\n\n"
+	case "a":
+		return c.link(s)
+	case "img":
+		alt := strings.TrimSpace(s.AttrOr("alt", ""))
+		if alt == "" {
+			return ""
+		}
+		return "![" + alt + "](" + c.resolve(s.AttrOr("src", "")) + ")"
+	case "strong", "b":
+		return wrapInline(c.children(s), "**")
+	case "em", "i":
+		return wrapInline(c.children(s), "*")
+	case "code":
+		if text := strings.TrimSpace(s.Text()); text != "" {
+			return "`" + text + "`"
+		}
+		return ""
+	case "pre":
+		return "\n\n```\n" + strings.Trim(s.Text(), "\n") + "\n```\n\n"
+	case "ul", "ol":
+		return "\n\n" + c.list(s, 0) + "\n\n"
+	case "table":
+		return "\n\n" + c.table(s) + "\n\n"
+	case "blockquote":
+		lines := strings.Split(cleanMarkdown(c.children(s)), "\n")
+		for i, l := range lines {
+			lines[i] = strings.TrimRight("> "+l, " ")
+		}
+		return "\n\n" + strings.Join(lines, "\n") + "\n\n"
+	}
+	if mdBlocks[name] {
+		return "\n\n" + strings.TrimSpace(c.children(s)) + "\n\n"
+	}
+	return c.children(s)
+}
+
+func (c *mdConverter) link(s *goquery.Selection) string {
+	text := inline(c.children(s))
+	href := strings.TrimSpace(s.AttrOr("href", ""))
+	if text == "" {
+		return ""
+	}
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return text
+	}
+	return "[" + text + "](" + c.resolve(href) + ")"
+}
+
+// list список с отступом по вложенности; вложенные списки выводятся под своим пунктом
+func (c *mdConverter) list(s *goquery.Selection, depth int) string {
+	ordered := goquery.NodeName(s) == "ol"
+	indent := strings.Repeat("  ", depth)
+	var lines []string
+	s.ChildrenFiltered("li").Each(func(i int, li *goquery.Selection) {
+		var text strings.Builder
+		var nested []string
+		li.Contents().Each(func(_ int, n *goquery.Selection) {
+			if name := goquery.NodeName(n); name == "ul" || name == "ol" {
+				nested = append(nested, c.list(n, depth+1))
+				return
+			}
+			text.WriteString(c.node(n))
+		})
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", i+1)
+		}
+		if t := inline(text.String()); t != "" || len(nested) == 0 {
+			lines = append(lines, indent+marker+t)
+		}
+		lines = append(lines, nested...)
+	})
+	return strings.Join(lines, "\n")
+}
+
+// table таблица Markdown; первая строка - заголовок. Таблицы в одну колонку
+// (обычно вёрстка) выводятся абзацами
+func (c *mdConverter) table(s *goquery.Selection) string {
+	var rows [][]string
+	cols := 0
+	s.Find("tr").Each(func(_ int, tr *goquery.Selection) {
+		// Строки вложенных таблиц попадут в ячейки своей таблицы
+		if !tr.Closest("table").IsSelection(s) {
+			return
+		}
+		var row []string
+		tr.ChildrenFiltered("th, td").Each(func(_ int, td *goquery.Selection) {
+			row = append(row, strings.ReplaceAll(inline(c.children(td)), "|", `\|`))
+		})
+		if len(row) > 0 {
+			rows = append(rows, row)
+			cols = max(cols, len(row))
+		}
+	})
+	if len(rows) == 0 {
+		return ""
+	}
+	if cols == 1 {
+		var paras []string
+		for _, r := range rows {
+			if r[0] != "" {
+				paras = append(paras, r[0])
+			}
+		}
+		return strings.Join(paras, "\n\n")
+	}
+
+	var sb strings.Builder
+	for i, r := range rows {
+		for len(r) < cols {
+			r = append(r, "")
+		}
+		sb.WriteString("| " + strings.Join(r, " | ") + " |\n")
+		if i == 0 {
+			sb.WriteString("|" + strings.Repeat(" --- |", cols) + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// resolve делает ссылку абсолютной относительно страницы
+func (c *mdConverter) resolve(href string) string {
+	if strings.HasPrefix(href, "data:") {
+		return ""
+	}
+	if c.base == nil {
+		return href
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return c.base.ResolveReference(u).String()
+}
+
+var (
+	spaces     = regexp.MustCompile(`\s+`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+	listLine   = regexp.MustCompile(`^\s*(- |\d+\. )`)
+)
+
+// inline схлопывает текст в одну строку (для ссылок, ячеек, пунктов списка)
+func inline(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// wrapInline оборачивает текст маркерами выделения, оставляя пробелы снаружи
+func wrapInline(s, mark string) string {
+	text := strings.TrimSpace(s)
+	if text == "" {
+		return s
+	}
+	lead, trail := "", ""
+	if strings.TrimLeft(s, " \n") != s {
+		lead = " "
+	}
+	if strings.TrimRight(s, " \n") != s {
+		trail = " "
+	}
+	return lead + mark + text + mark + trail
+}
+
+// cleanMarkdown убирает лишние пробелы и пустые строки, не трогая блоки кода и отступы списков
+func cleanMarkdown(s string) string {
+	lines := strings.Split(s, "\n")
+	inCode := false
+	for i, l := range lines {
+		if strings.HasPrefix(strings.TrimSpace(l), "```") {
+			inCode = !inCode
+			lines[i] = strings.TrimSpace(l)
+			continue
+		}
+		if inCode {
+			continue
+		}
+		l = strings.TrimRight(l, " \t")
+		if !listLine.MatchString(l) {
+			l = strings.TrimLeft(l, " \t")
+		}
+		lines[i] = l
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
@@ -0,0 +1,158 @@
+package dom
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// ChainSep разделяет шаги селектора, проходящего через iframe и shadow root:
+//
+//	iframe#payment >> input[name="card"]
+//	my-login-form >> text:Войти
+//
+// Каждый шаг кроме последнего - CSS селектор iframe (дальше ищем в его документе)
+// или хоста shadow root (дальше ищем внутри shadow root)
+const ChainSep = " >> "
+
+// Scope область поиска элементов: документ страницы или фрейма либо shadow root
+type Scope struct {
+	Page *rod.Page
+	Root *rod.Element // shadow root; nil = документ Page
+}
+
+// SplitChain делит селектор на путь до области поиска и последний шаг
+func SplitChain(selector string) (path []string, last string) {
+	parts := strings.Split(selector, ChainSep)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts[:len(parts)-1], parts[len(parts)-1]
+}
+
+// JoinChain собирает селектор из префикса-цепочки и шага
+func JoinChain(prefix, step string) string {
+	if prefix == "" {
+		return step
+	}
+	return prefix + ChainSep + step
+}
+
+// ResolveScope проходит шаги пути от документа страницы, спускаясь во фреймы и shadow roots
+func ResolveScope(page *rod.Page, path []string) (Scope, error) {
+	s := Scope{Page: page}
+	for _, step := range path {
+		el, err := s.Element(step, 5*time.Second)
+		if err != nil {
+			return s, fmt.Errorf("element not found: %s", step)
+		}
+		if s, err = s.enter(el); err != nil {
+			return s, fmt.Errorf("%s: %w", step, err)
+		}
+	}
+	return s, nil
+}
+
+// Element ищет элемент по CSS внутри области
+func (s Scope) Element(css string, timeout time.Duration) (*rod.Element, error) {
+	if s.Root != nil {
+		return s.Root.Timeout(timeout).Element(css)
+	}
+	return s.Page.Timeout(timeout).Element(css)
+}
+
+// Evaluate выполняет JS в области: this - shadow root или window документа
+func (s Scope) Evaluate(opts *rod.EvalOptions) (*proto.RuntimeRemoteObject, error) {
+	if s.Root != nil {
+		return s.Root.Evaluate(opts)
+	}
+	return s.Page.Evaluate(opts)
+}
+
+// Eval выполняет JS в области и возвращает значение
+func (s Scope) Eval(js string, args ...interface{}) (*proto.RuntimeRemoteObject, error) {
+	return s.Evaluate(rod.Eval(js, args...))
+}
+
+// ElementByJS выполняет JS, возвращающий DOM узел, и превращает его в элемент
+func (s Scope) ElementByJS(timeout time.Duration, js string, args ...interface{}) (*rod.Element, error) {
+	scope := s
+	if s.Root != nil {
+		scope.Root = s.Root.Timeout(timeout)
+	} else {
+		scope.Page = s.Page.Timeout(timeout)
+	}
+	obj, err := scope.Evaluate(rod.Eval(js, args...).ByObject())
+	if err != nil || obj.Subtype != proto.RuntimeRemoteObjectSubtypeNode {
+		return nil, fmt.Errorf("not found")
+	}
+	return s.Page.ElementFromObject(obj)
+}
+
+// enter делает областью поиска документ iframe или shadow root элемента
+func (s Scope) enter(el *rod.Element) (Scope, error) {
+	// Элемент найден с таймаутом - отвязываем его, чтобы фрейм жил дольше поиска
+	el = el.Context(s.Page.GetContext())
+	node, err := el.Describe(1, false)
+	if err != nil {
+		return s, err
+	}
+
+	switch {
+	case node.NodeName == "IFRAME" || node.NodeName == "FRAME":
+		frame, err := el.Frame()
+		if err != nil {
+			return s, fmt.Errorf("frame: %w", err)
+		}
+		return Scope{Page: frame.Context(s.Page.GetContext())}, nil
+	case len(node.ShadowRoots) > 0:
+		root, err := el.ShadowRoot()
+		if err != nil {
+			return s, fmt.Errorf("shadow root: %w", err)
+		}
+		return Scope{Page: s.Page, Root: root}, nil
+	default:
+		return s, fmt.Errorf("element is neither iframe nor shadow root host")
+	}
+}
+
+// JSHelpers общие JS функции для обхода shadow DOM; вставляются в начало тела функций.
+//   - uniqueSelector(el) - CSS селектор, однозначный внутри документа/shadow root элемента
+//   - deepRoots(doc) - документ и все открытые shadow roots: [{root, chain}], где chain -
+//     цепочка хостов через " >> " для адресации элементов внутри shadow root
+const JSHelpers = `
+	const uniqueSelector = (el) => {
+		const root = el.getRootNode();
+		const tag = el.tagName.toLowerCase();
+		const esc = v => v.replace(/\\/g, '\\\\').replace(/"/g, '\\"');
+		const cands = [];
+		if (el.id) cands.push(tag + '#' + CSS.escape(el.id));
+		for (const a of ['name', 'title', 'aria-label', 'data-testid', 'data-qa']) {
+			const v = el.getAttribute(a);
+			if (v && v.length < 100) cands.push(tag + '[' + a + '="' + esc(v) + '"]');
+		}
+		for (const c of cands) {
+			try { if (root.querySelectorAll(c).length === 1) return c; } catch (e) {}
+		}
+		const parts = [];
+		for (let n = el; n && n.nodeType === 1; n = n.parentElement) {
+			let i = 1;
+			for (let s = n.previousElementSibling; s; s = s.previousElementSibling) if (s.tagName === n.tagName) i++;
+			parts.unshift(n.tagName.toLowerCase() + ':nth-of-type(' + i + ')');
+		}
+		return parts.join(' > ');
+	};
+	const deepRoots = (doc) => {
+		const out = [{root: doc, chain: ''}];
+		for (let i = 0; i < out.length; i++) {
+			const {root, chain} = out[i];
+			for (const el of root.querySelectorAll('*')) {
+				if (el.shadowRoot) out.push({root: el.shadowRoot, chain: (chain ? chain + ' >> ' : '') + uniqueSelector(el)});
+			}
+		}
+		return out;
+	};
+`
@@ -0,0 +1,210 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"go.uber.org/zap"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
+)
+
+// download состояние одной загрузки из событий Browser.download*
+type download struct {
+	guid      string
+	dir       string
+	url       string
+	suggested string
+	state     proto.BrowserDownloadProgressState // пусто пока не пришёл первый progress
+	received  float64
+	result    *domain.Download
+}
+
+func (d *download) finished() bool {
+	return d.state == proto.BrowserDownloadProgressStateCompleted || d.state == proto.BrowserDownloadProgressStateCanceled
+}
+
+// downloadTracker перехватывает загрузки браузера в директорию текущей задачи
+type downloadTracker struct {
+	eventLog[*download]
+	dir    string
+	byGUID map[string]*download
+}
+
+func newDownloadTracker() *downloadTracker {
+	return &downloadTracker{eventLog: newEventLog[*download](), byGUID: map[string]*download{}}
+}
+
+// listen подписывается на начало и ход загрузок
+func (t *downloadTracker) listen(ctx context.Context, browser *rod.Browser) {
+	subscribe(browser, func(e *proto.BrowserDownloadWillBegin) {
+		t.mu.Lock()
+		d := &download{guid: e.GUID, dir: t.dir, url: e.URL, suggested: e.SuggestedFilename}
+		t.items = append(t.items, d)
+		t.byGUID[e.GUID] = d
+		t.notifyLocked()
+		t.mu.Unlock()
+		logger.Info(ctx, "📥 Download started", zap.String("file", e.SuggestedFilename), zap.String("url", e.URL))
+	}, func(e *proto.BrowserDownloadProgress) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		d, ok := t.byGUID[e.GUID]
+		if !ok || d.finished() {
+			return
+		}
+		d.state, d.received = e.State, e.ReceivedBytes
+		if e.State == proto.BrowserDownloadProgressStateCompleted {
+			d.result = finalizeDownload(ctx, d)
+		}
+		if d.finished() {
+			t.notifyLocked()
+		}
+	})
+}
+
+// setDir включает перехват загрузок в dir (пусто = поведение браузера по умолчанию)
+func (t *downloadTracker) setDir(browser *rod.Browser, dir string) error {
+	if dir == "" {
+		return proto.BrowserSetDownloadBehavior{Behavior: proto.BrowserSetDownloadBehaviorBehaviorDefault}.Call(browser)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return fmt.Errorf("create download dir: %w", err)
+	}
+
+	t.mu.Lock()
+	t.dir = abs
+	t.mu.Unlock()
+	return proto.BrowserSetDownloadBehavior{
+		Behavior:      proto.BrowserSetDownloadBehaviorBehaviorAllowAndName,
+		DownloadPath:  abs,
+		EventsEnabled: true,
+	}.Call(browser)
+}
+
+// collect ждёт завершения загрузок, начавшихся после отметки. Незавершённые
+// за timeout загрузки возвращаются без Path. При отмене ctx возвращает то, что
+// уже собрано, вместе с ctx.Err()
+func (t *downloadTracker) collect(ctx context.Context, mark int, timeout time.Duration) ([]domain.Download, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		t.mu.Lock()
+		items := t.sinceLocked(mark)
+		if len(items) == 0 {
+			t.mu.Unlock()
+			return nil, nil
+		}
+		pending := false
+		for _, d := range items {
+			pending = pending || !d.finished()
+		}
+		changed := t.changed
+		if !pending {
+			defer t.mu.Unlock()
+			return t.resultsLocked(mark), nil
+		}
+		t.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			logger.Warn(ctx, "⚠️ Download timeout", zap.Duration("timeout", timeout))
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			return t.resultsLocked(mark), nil
+		case <-ctx.Done():
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			return t.resultsLocked(mark), ctx.Err()
+		}
+	}
+}
+
+func (t *downloadTracker) resultsLocked(mark int) []domain.Download {
+	items := t.sinceLocked(mark)
+	out := make([]domain.Download, 0, len(items))
+	for _, d := range items {
+		switch {
+		case d.result != nil:
+			out = append(out, *d.result)
+		case d.state == proto.BrowserDownloadProgressStateCanceled:
+			out = append(out, domain.Download{Name: d.suggested, URL: d.url, Error: "canceled"})
+		default:
+			out = append(out, domain.Download{Name: d.suggested, URL: d.url, Size: int64(d.received), Error: "still downloading"})
+		}
+	}
+	return out
+}
+
+// finalizeDownload переименовывает файл из GUID в предложенное сайтом имя и определяет тип
+func finalizeDownload(ctx context.Context, d *download) *domain.Download {
+	src := filepath.Join(d.dir, d.guid)
+	dst := uniquePath(d.dir, sanitizeFileName(d.suggested))
+	if err := os.Rename(src, dst); err != nil {
+		logger.Warn(ctx, "⚠️ Download rename failed", zap.Error(err))
+		return &domain.Download{Name: d.suggested, URL: d.url, Error: err.Error()}
+	}
+
+	res := &domain.Download{Name: filepath.Base(dst), Path: dst, URL: d.url, MIME: detectMIME(dst)}
+	if info, err := os.Stat(dst); err == nil {
+		res.Size = info.Size()
+	}
+	logger.Info(ctx, "✅ Download completed", zap.String("path", dst), zap.Int64("size", res.Size))
+	return res
+}
+
+// sanitizeFileName убирает из имени файла разделители путей и пустые имена
+func sanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < 32 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" || name == "." || name == ".." {
+		return "download"
+	}
+	return name
+}
+
+// uniquePath добавляет к имени суффикс (2), (3)... если файл уже существует
+func uniquePath(dir, name string) string {
+	path := filepath.Join(dir, name)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s (%d)%s", base, i, ext))
+	}
+}
+
+// detectMIME определяет тип по расширению, а если его нет - по содержимому
+func detectMIME(path string) string {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return t
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, _ := f.Read(buf)
+	return http.DetectContentType(buf[:n])
+}
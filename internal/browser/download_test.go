@@ -0,0 +1,73 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+)
+
+func trackerWith(items ...*download) *downloadTracker {
+	t := newDownloadTracker()
+	for _, d := range items {
+		t.items = append(t.items, d)
+		t.byGUID[d.guid] = d
+	}
+	return t
+}
+
+// TestCollectCancelledKeepsPartialResults при отмене ожидания уже скачанные файлы не теряются
+func TestCollectCancelledKeepsPartialResults(t *testing.T) {
+	done := &download{
+		guid: "a", url: "https://example.com/report.pdf", suggested: "report.pdf",
+		state:  proto.BrowserDownloadProgressStateCompleted,
+		result: &domain.Download{Name: "report.pdf", Path: "/tmp/report.pdf", Size: 10},
+	}
+	pending := &download{
+		guid: "b", url: "https://example.com/big.zip", suggested: "big.zip",
+		state: proto.BrowserDownloadProgressStateInProgress, received: 512,
+	}
+	tracker := trackerWith(done, pending)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	got, err := tracker.collect(ctx, 0, time.Minute)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("downloads = %+v", got)
+	}
+	if got[0].Path != "/tmp/report.pdf" {
+		t.Errorf("completed download = %+v", got[0])
+	}
+	if got[1].Path != "" || got[1].Size != 512 || got[1].Error != "still downloading" {
+		t.Errorf("pending download = %+v", got[1])
+	}
+}
+
+func TestCollect(t *testing.T) {
+	done := &download{guid: "a", state: proto.BrowserDownloadProgressStateCompleted, result: &domain.Download{Name: "a.csv"}}
+	canceled := &download{guid: "b", suggested: "b.csv", state: proto.BrowserDownloadProgressStateCanceled}
+	tracker := trackerWith(done, canceled)
+
+	got, err := tracker.collect(context.Background(), 0, time.Minute)
+	if err != nil || len(got) != 2 || got[0].Name != "a.csv" || got[1].Error != "canceled" {
+		t.Errorf("collect = %+v, %v", got, err)
+	}
+	// Загрузки до отметки не относятся к действию
+	if got, err := tracker.collect(context.Background(), 2, time.Minute); got != nil || err != nil {
+		t.Errorf("collect after mark = %+v, %v", got, err)
+	}
+
+	stuck := trackerWith(&download{guid: "c", suggested: "c.bin"})
+	got, err = stuck.collect(context.Background(), 0, 10*time.Millisecond)
+	if err != nil || len(got) != 1 || got[0].Error != "still downloading" {
+		t.Errorf("collect on timeout = %+v, %v", got, err)
+	}
+}
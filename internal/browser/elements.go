@@ -6,50 +6,119 @@ import (
 	"strings"
 	"time"
 
+	"github.com/go-rod/rod"
 	"github.com/ysmood/gson"
 	"go.uber.org/zap"
 
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/browser/dom"
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
 )
 
-// FindElementsLive возвращает кликабельные элементы со страницы
+// maxLiveElements сколько элементов со всех фреймов показываем модели
+const maxLiveElements = 80
+
+// liveElement кликабельный элемент для вывода модели
+type liveElement struct {
+	ref      int
+	selector string
+	short    string
+	handle   *rod.Element
+}
+
+// FindElementsLive возвращает кликабельные элементы со страницы, её shadow DOM и iframe.
+// Каждый элемент получает номер [N], по которому действия принимают ref:N
 func (c *Controller) FindElementsLive(ctx context.Context, query string) (string, error) {
-	result, err := c.page.Timeout(5 * time.Second).Eval(findElementsJS)
+	if err := c.dialogErr(); err != nil {
+		return "", err
+	}
+	elements, err := c.discoverElements(ctx)
 	if err != nil {
 		logger.Error(ctx, "❌ FindElementsLive error", zap.Error(err))
 		return "Ошибка поиска.", nil
 	}
-	elements := result.Value.Arr()
 	if len(elements) == 0 {
 		return "Элементы не найдены.", nil
 	}
-	logger.Info(ctx, "🔍 FindElementsLive", zap.Int("found", len(elements)))
 	return formatElements(elements), nil
 }
 
-func formatElements(elements []gson.JSON) string {
-	var out strings.Builder
-	out.WriteString("🎯 КЛИКАБЕЛЬНЫЕ ЭЛЕМЕНТЫ:\n\n")
-	for i, elem := range elements {
+// discoverElements находит кликабельные элементы и заново выдаёт им номера ref:N
+func (c *Controller) discoverElements(ctx context.Context) ([]liveElement, error) {
+	result, err := c.page.Timeout(5 * time.Second).Eval(findElementsJS)
+	if err != nil {
+		return nil, err
+	}
+	elements := liveElements(result.Value.Arr(), "", refHandles(c.page))
+
+	// Элементы фреймов адресуются цепочкой: iframe#pay >> text:Оплатить
+	frames := dom.ListFrames(c.page)
+	for _, f := range frames {
+		if len(elements) >= maxLiveElements {
+			break
+		}
+		res, err := f.Page.Timeout(3 * time.Second).Eval(findElementsJS)
+		if err != nil {
+			logger.Debug(ctx, "Frame elements error", zap.String("frame", f.Chain), zap.Error(err))
+			continue
+		}
+		elements = append(elements, liveElements(res.Value.Arr(), f.Chain, refHandles(f.Page))...)
+	}
+
+	c.refs.reset()
+	if len(elements) > maxLiveElements {
+		elements = elements[:maxLiveElements]
+	}
+	for i := range elements {
+		if elements[i].handle != nil {
+			elements[i].ref = c.refs.add(elements[i].handle, elements[i].selector)
+		}
+	}
+	logger.Info(ctx, "🔍 FindElementsLive", zap.Int("found", len(elements)), zap.Int("frames", len(frames)))
+	return elements, nil
+}
+
+func liveElements(arr []gson.JSON, prefix string, handles map[int]*rod.Element) []liveElement {
+	out := make([]liveElement, 0, len(arr))
+	for i, elem := range arr {
 		obj := elem.Map()
 		sel := obj["displaySelector"].String()
 		if sel == "" {
 			continue
 		}
-		out.WriteString(fmt.Sprintf("%d. %s", i+1, sel))
+		e := liveElement{selector: dom.JoinChain(prefix, sel), handle: handles[i]}
+		if short := obj["shortSelector"].String(); short != "" && short != sel {
+			e.short = dom.JoinChain(prefix, short)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func formatElements(elements []liveElement) string {
+	var out strings.Builder
+	out.WriteString("🎯 КЛИКАБЕЛЬНЫЕ ЭЛЕМЕНТЫ:\n\n")
+	for _, elem := range elements {
+		if elem.ref > 0 {
+			out.WriteString(fmt.Sprintf("[%d] %s", elem.ref, elem.selector))
+		} else {
+			out.WriteString("• " + elem.selector)
+		}
 
 		// Показываем короткий вариант если текст длинный
-		if short := obj["shortSelector"].String(); short != "" && short != sel {
-			out.WriteString(fmt.Sprintf(" (или: %s)", short))
+		if elem.short != "" {
+			out.WriteString(fmt.Sprintf(" (или: %s)", elem.short))
 		}
 		out.WriteString("\n")
 	}
-	out.WriteString("\n💡 Для клика используй КОРОТКИЙ текст (первые 2-3 слова)")
+	out.WriteString("\n💡 Для клика/ввода используй ref:N (номер из списка) - это надёжнее текста")
 	return out.String()
 }
 
-const findElementsJS = `() => {
-	const results = [], seen = new Set(), counts = {};
+// findElementsJS собирает видимые кликабельные элементы документа и его открытых
+// shadow roots. Элементы shadow DOM без текста адресуются цепочкой host >> css
+const findElementsJS = `() => {` + dom.JSHelpers + `
+	const results = [], els = [], seen = new Set(), counts = {};
+	const roots = deepRoots(document);
 	
 	function isVisible(el) {
 		if (!el) return false;
@@ -94,14 +163,16 @@ const findElementsJS = `() => {
 	let hasModal = false;
 	for (const ms of modals) {
 		try {
-			for (const m of document.querySelectorAll(ms)) {
-				if (!isVisible(m)) continue;
-				hasModal = true;
-				for (const el of m.querySelectorAll(clickable.join(','))) {
-					if (isVisible(el)) {
-						const s = getSelector(el);
-						counts[s] = (counts[s] || 0) + 1;
-						all.push({ el, s });
+			for (const {root, chain} of roots) {
+				for (const m of root.querySelectorAll(ms)) {
+					if (!isVisible(m)) continue;
+					hasModal = true;
+					for (const el of m.querySelectorAll(clickable.join(','))) {
+						if (isVisible(el)) {
+							const s = getSelector(el);
+							counts[chain + s] = (counts[chain + s] || 0) + 1;
+							all.push({ el, s, chain });
+						}
 					}
 				}
 			}
@@ -111,11 +182,13 @@ const findElementsJS = `() => {
 	if (!hasModal) {
 		for (const sel of clickable) {
 			try {
-				for (const el of document.querySelectorAll(sel)) {
-					if (isVisible(el)) {
-						const s = getSelector(el);
-						counts[s] = (counts[s] || 0) + 1;
-						all.push({ el, s });
+				for (const {root, chain} of roots) {
+					for (const el of root.querySelectorAll(sel)) {
+						if (isVisible(el)) {
+							const s = getSelector(el);
+							counts[chain + s] = (counts[chain + s] || 0) + 1;
+							all.push({ el, s, chain });
+						}
 					}
 				}
 			} catch(e) {}
@@ -123,24 +196,29 @@ const findElementsJS = `() => {
 	}
 	
 	for (const item of all) {
-		const el = item.el, css = item.s;
+		const el = item.el, css = item.s, chain = item.chain;
 		const text = getText(el);
 		const r = el.getBoundingClientRect();
-		const key = css + '|' + text;
+		const key = chain + css + '|' + text;
 		
 		if (seen.has(key)) continue;
 		seen.add(key);
 		
-		const displaySelector = text ? 'text:' + text : css;
+		// text: ищется и внутри shadow DOM, CSS - только через цепочку хостов
+		const fullCSS = chain ? chain + ' >> ' + css : css;
+		const displaySelector = text ? 'text:' + text : fullCSS;
 		const shortSelector = getShortText(text);
 		
 		results.push({
 			displaySelector,
 			shortSelector,
-			cssSelector: counts[css] === 1 ? css : ''
+			cssSelector: counts[chain + css] === 1 ? fullCSS : ''
 		});
+		els.push(el);
 		
 		if (results.length >= 50) break;
 	}
+	// Узлы остаются в странице, чтобы контроллер взял на них ссылки для ref:N
+	window[Symbol.for('agentRefs')] = els;
 	return results;
 }`
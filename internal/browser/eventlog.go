@@ -0,0 +1,52 @@
+package browser
+
+import (
+	"sync"
+
+	"github.com/go-rod/rod"
+)
+
+// eventLog записи о событиях браузера (загрузках, диалогах) по порядку появления.
+// Трекер встраивает журнал: mu защищает и items, и собственные поля трекера
+type eventLog[T any] struct {
+	mu      sync.Mutex
+	items   []T
+	changed chan struct{} // закрывается и пересоздаётся при каждом событии
+}
+
+func newEventLog[T any]() eventLog[T] {
+	return eventLog[T]{changed: make(chan struct{})}
+}
+
+// subscribe слушает события браузера до его закрытия; обработчики - как у rod.Browser.EachEvent
+func subscribe(browser *rod.Browser, handlers ...interface{}) {
+	go browser.EachEvent(handlers...)()
+}
+
+// notifyLocked будит всех, кто ждёт на канале changes
+func (l *eventLog[T]) notifyLocked() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// changes канал, который закроется при следующем событии
+func (l *eventLog[T]) changes() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.changed
+}
+
+// mark возвращает отметку: записи после неё относятся к следующему действию
+func (l *eventLog[T]) mark() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.items)
+}
+
+// sinceLocked записи, появившиеся после отметки
+func (l *eventLog[T]) sinceLocked(mark int) []T {
+	if len(l.items) <= mark {
+		return nil
+	}
+	return l.items[mark:]
+}
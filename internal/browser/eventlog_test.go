@@ -0,0 +1,38 @@
+package browser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEventLog(t *testing.T) {
+	l := newEventLog[string]()
+	l.items = append(l.items, "a")
+	mark := l.mark()
+
+	changed := l.changes()
+	l.mu.Lock()
+	l.items = append(l.items, "b", "c")
+	l.notifyLocked()
+	l.mu.Unlock()
+
+	select {
+	case <-changed:
+	default:
+		t.Fatal("changes channel is not closed after an event")
+	}
+	select {
+	case <-l.changes():
+		t.Fatal("new changes channel is already closed")
+	default:
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if got := l.sinceLocked(mark); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("sinceLocked(%d) = %q", mark, got)
+	}
+	if got := l.sinceLocked(3); got != nil {
+		t.Errorf("sinceLocked(3) = %q, want nil", got)
+	}
+}
@@ -2,16 +2,51 @@ package browser
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/browser/action"
 	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
 )
 
+// ExecuteAction выполняет действие и дожидается загрузок, которые оно запустило.
+// Пока страница ждёт ответа на диалог, выполнить можно только handle_dialog.
+// Если ожидание загрузок прервано, результат с уже собранными загрузками
+// возвращается вместе с ошибкой контекста
 func (c *Controller) ExecuteAction(ctx context.Context, a domain.Action) (*domain.ActionResult, error) {
+	if d := c.OpenDialog(); d != nil && a.Type != domain.ActionTypeHandleDialog {
+		return fail(a, "The page is blocked by a dialog: "+d.String()+". Call handle_dialog first"), nil
+	}
+	mark, dialogMark := c.downloads.mark(), c.dialogs.mark()
+	r, err := c.runAction(ctx, a)
+	if err != nil || r == nil {
+		return r, err
+	}
+	c.reportDialogs(r, dialogMark)
+
+	downloads, err := c.downloads.collect(ctx, mark, c.opts.DownloadTimeout)
+	if len(downloads) == 0 {
+		return r, err
+	}
+	// Переход по прямой ссылке на файл браузер прерывает (ERR_ABORTED), но файл скачан
+	if !r.Success && a.Type == domain.ActionTypeNavigate {
+		r = ok(a, "Navigation started a download")
+	}
+	r.Downloads = downloads
+	lines := make([]string, 0, len(downloads))
+	for _, d := range downloads {
+		lines = append(lines, "📥 Downloaded: "+d.String())
+	}
+	r.Message += "\n" + strings.Join(lines, "\n")
+	return r, err
+}
+
+func (c *Controller) executeAction(ctx context.Context, a domain.Action) (*domain.ActionResult, error) {
 	switch a.Type {
 	case domain.ActionTypeNavigate:
 		return c.exec(ctx, a, func() error { return c.Navigate(ctx, a.URL) }, "Navigated to "+a.URL)
@@ -27,14 +62,36 @@ func (c *Controller) ExecuteAction(ctx context.Context, a domain.Action) (*domai
 			dir = "down"
 		}
 		return c.exec(ctx, a, func() error { return c.Scroll(ctx, dir, 500) }, "Scrolled "+dir)
+	case domain.ActionTypeScrollIntoView:
+		return c.execWithErr(ctx, a, func() error { return c.ScrollIntoView(ctx, a.Selector) }, "Scrolled into view "+a.Selector)
+	case domain.ActionTypeDrag:
+		return c.execDrag(ctx, a)
+	case domain.ActionTypeHover:
+		return c.execWithErr(ctx, a, func() error { return c.Hover(ctx, a.Selector) }, "Hovered "+a.Selector)
+	case domain.ActionTypeGoBack:
+		return c.execHistory(ctx, a, c.GoBack, "Went back")
+	case domain.ActionTypeGoForward:
+		return c.execHistory(ctx, a, c.GoForward, "Went forward")
+	case domain.ActionTypeReload:
+		return c.execHistory(ctx, a, c.Reload, "Reloaded")
 	case domain.ActionTypeWait:
 		return c.execWithErr(ctx, a, func() error { return c.WaitForElement(ctx, a.Selector, 10*time.Second) }, "Element appeared: "+a.Selector)
+	case domain.ActionTypeSelect:
+		return c.execSelect(ctx, a)
+	case domain.ActionTypeUploadFile:
+		return c.execUpload(ctx, a)
+	case domain.ActionTypeFillForm:
+		return c.execFillForm(ctx, a)
 	case domain.ActionTypePressEnter:
 		return c.exec(ctx, a, func() error { return c.PressEnter(ctx) }, "Pressed Enter")
+	case domain.ActionTypePressKey:
+		return c.exec(ctx, a, func() error { return c.PressKeys(ctx, a.Key, a.Repeat) }, "Pressed "+a.Key)
 	case domain.ActionTypeCompleteTask:
 		return ok(a, "Task completed"), nil
 	case domain.ActionTypeTakeScreenshot:
 		return c.execScreenshot(ctx, a)
+	case domain.ActionTypeReadPage:
+		return c.execReadPage(ctx, a)
 	case domain.ActionTypeQueryDOM:
 		return fail(a, "query_dom handled by agent"), nil
 	case domain.ActionTypeListTabs:
@@ -65,7 +122,23 @@ func (c *Controller) execWithErr(ctx context.Context, a domain.Action, fn func()
 	return ok(a, msg), nil
 }
 
+// execHistory переход по истории или перезагрузка; в сообщении - страница, на которой оказались
+func (c *Controller) execHistory(ctx context.Context, a domain.Action, fn func(context.Context) error, msg string) (*domain.ActionResult, error) {
+	r, err := c.exec(ctx, a, func() error { return fn(ctx) }, msg)
+	if err != nil || !r.Success {
+		return r, err
+	}
+	info, err := c.page.Info()
+	if err == nil {
+		r.Message = fmt.Sprintf("%s: %s (%s)", msg, info.Title, info.URL)
+	}
+	return r, nil
+}
+
 func (c *Controller) execScreenshot(ctx context.Context, a domain.Action) (*domain.ActionResult, error) {
+	if a.Annotate {
+		return c.execAnnotatedScreenshot(ctx, a)
+	}
 	s, err := c.TakeScreenshot(ctx, a.FullPage, "screenshots")
 	if err != nil {
 		return fail(a, "Screenshot failed"), nil
@@ -75,6 +148,125 @@ func (c *Controller) execScreenshot(ctx context.Context, a domain.Action) (*doma
 	return r, nil
 }
 
+func (c *Controller) execAnnotatedScreenshot(ctx context.Context, a domain.Action) (*domain.ActionResult, error) {
+	s, err := c.TakeAnnotatedScreenshot(ctx, "screenshots")
+	if err != nil {
+		return fail(a, "Annotated screenshot failed: "+err.Error()), nil
+	}
+	legend := s.Legend
+	if legend == "" {
+		legend = "No interactive elements in viewport\n"
+	}
+	r := ok(a, "Annotated screenshot: "+s.Path+"\nNumbered elements (use ref:N):\n"+legend)
+	r.Screenshot, r.ScreenshotB64, r.QueryResult = s.Path, s.Base64, legend
+	return r, nil
+}
+
+func (c *Controller) execSelect(ctx context.Context, a domain.Action) (*domain.ActionResult, error) {
+	q := action.OptionQuery{Value: a.Value, Label: a.Label, Index: a.OptionIndex}
+	selected, err := c.SelectOption(ctx, a.Selector, q)
+	if err != nil {
+		r := fail(a, fmt.Sprintf("Select %s in %s failed: %s", q, a.Selector, err))
+		if strings.HasPrefix(err.Error(), "element not found") {
+			r.ErrorContext = c.BuildErrorContext(ctx, a.Selector, err)
+		}
+		return r, nil
+	}
+	return ok(a, fmt.Sprintf("Selected %s in %s", selected, a.Selector)), nil
+}
+
+func (c *Controller) execDrag(ctx context.Context, a domain.Action) (*domain.ActionResult, error) {
+	target := action.DragTarget{Selector: a.Target, X: a.X, Y: a.Y, Point: a.AtPoint, DX: a.DX, DY: a.DY}
+	res, err := c.Drag(ctx, a.Selector, target)
+	if err != nil {
+		r := fail(a, fmt.Sprintf("Drag %s to %s failed: %s", a.Selector, target, err))
+		var locErr *action.DragLocateError
+		if errors.As(err, &locErr) {
+			r.ErrorContext = c.BuildErrorContext(ctx, locErr.Selector, locErr.Err)
+		}
+		return r, nil
+	}
+	return ok(a, fmt.Sprintf("Dragged %s to %s (%.0f,%.0f → %.0f,%.0f, %s): %s", a.Selector, target,
+		res.From.X, res.From.Y, res.To.X, res.To.Y, res.Method, strings.Join(res.Changes, ", "))), nil
+}
+
+// execFillForm успех, только если заполнены все поля (и форма отправлена, если просили);
+// в сообщении - итог по каждому полю
+func (c *Controller) execFillForm(ctx context.Context, a domain.Action) (*domain.ActionResult, error) {
+	res, err := c.FillForm(ctx, formFields(a.Fields), a.Submit)
+	if err != nil {
+		return fail(a, "Fill form failed: "+err.Error()), nil
+	}
+
+	failed := res.Failed()
+	lines := make([]string, 0, len(res.Fields)+2)
+	lines = append(lines, fmt.Sprintf("Filled %d of %d field(s)", len(res.Fields)-failed, len(res.Fields)))
+	for _, f := range res.Fields {
+		if f.Err != nil {
+			lines = append(lines, fmt.Sprintf("❌ %s: %s", f.Field, f.Err))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("✅ %s (%s): %q", f.Field, f.Kind, f.Value))
+	}
+	switch {
+	case res.SubmitErr != nil:
+		lines = append(lines, "❌ Submit: "+res.SubmitErr.Error())
+	case res.Submitted != "":
+		lines = append(lines, "📨 Submitted via "+res.Submitted)
+	}
+
+	msg := strings.Join(lines, "\n")
+	if failed > 0 || res.SubmitErr != nil {
+		return fail(a, msg), nil
+	}
+	return ok(a, msg), nil
+}
+
+func formFields(in []domain.FormField) []action.FormField {
+	fields := make([]action.FormField, 0, len(in))
+	for _, f := range in {
+		fields = append(fields, action.FormField{Field: f.Field, Value: f.Value})
+	}
+	return fields
+}
+
+func (c *Controller) execUpload(ctx context.Context, a domain.Action) (*domain.ActionResult, error) {
+	names, err := c.UploadFile(ctx, a.Selector, a.Files)
+	if err != nil {
+		r := fail(a, "Upload failed: "+err.Error())
+		if strings.HasPrefix(err.Error(), "element not found") {
+			r.ErrorContext = c.BuildErrorContext(ctx, a.Selector, err)
+		}
+		return r, nil
+	}
+	return ok(a, fmt.Sprintf("Attached %d file(s): %s", len(names), strings.Join(names, ", "))), nil
+}
+
+func (c *Controller) execReadPage(ctx context.Context, a domain.Action) (*domain.ActionResult, error) {
+	md, err := c.ReadPage(ctx, a.Offset)
+	if err != nil {
+		return fail(a, "Read page failed: "+err.Error()), nil
+	}
+	end := md.Total
+	if md.Next > 0 {
+		end = md.Next
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📖 %s (%s)\nCharacters %d-%d of %d\n\n", md.Title, md.URL, md.Offset, end, md.Total))
+	if md.Content == "" {
+		sb.WriteString("(no readable content)")
+	}
+	sb.WriteString(md.Content)
+	if md.Next > 0 {
+		sb.WriteString(fmt.Sprintf("\n\n[More content: call read_page with offset=%d]", md.Next))
+	} else {
+		sb.WriteString("\n\n[End of page]")
+	}
+	r := ok(a, sb.String())
+	r.QueryResult = r.Message
+	return r, nil
+}
+
 func (c *Controller) execListTabs(ctx context.Context, a domain.Action) (*domain.ActionResult, error) {
 	result := c.ListTabs(ctx)
 	r := ok(a, result)
@@ -0,0 +1,38 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/browser/dom"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
+)
+
+// PageMarkdown возвращает основное содержимое страницы в Markdown целиком
+func (c *Controller) PageMarkdown(ctx context.Context) (string, error) {
+	html, err := c.GetHTML(ctx)
+	if err != nil {
+		return "", err
+	}
+	info, err := c.page.Info()
+	if err != nil {
+		return "", fmt.Errorf("page info: %w", err)
+	}
+	return dom.HTMLToMarkdown(html, info.URL)
+}
+
+// ReadPage возвращает основное содержимое страницы в Markdown, фрагмент начиная с offset символов
+func (c *Controller) ReadPage(ctx context.Context, offset int) (*dom.PageMarkdown, error) {
+	if c.page == nil {
+		return nil, fmt.Errorf("page is nil")
+	}
+	md, err := dom.ReadMarkdown(c.page.Timeout(c.timeout), offset)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info(ctx, "📖 Page read", zap.String("url", md.URL),
+		zap.Int("offset", md.Offset), zap.Int("next", md.Next), zap.Int("total", md.Total))
+	return md, nil
+}
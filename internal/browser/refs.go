@@ -0,0 +1,93 @@
+package browser
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/browser/action"
+)
+
+// elementRefs узлы последнего query_dom, адресуемые как ref:N. Номера действуют
+// до следующего поиска элементов
+type elementRefs struct {
+	mu     sync.Mutex
+	elems  map[int]*rod.Element
+	labels map[int]string
+}
+
+func (r *elementRefs) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.elems, r.labels = map[int]*rod.Element{}, map[int]string{}
+}
+
+// add регистрирует узел и возвращает его номер
+func (r *elementRefs) add(elem *rod.Element, label string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(r.elems) + 1
+	r.elems[n], r.labels[n] = elem, label
+	return n
+}
+
+func (r *elementRefs) get(n int) (*rod.Element, string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	elem, ok := r.elems[n]
+	return elem, r.labels[n], ok
+}
+
+// ResolveRef возвращает узел по номеру из последнего query_dom
+func (c *Controller) ResolveRef(n int) (*rod.Element, error) {
+	elem, _, ok := c.refs.get(n)
+	if !ok {
+		return nil, fmt.Errorf("element not found: ref:%d is unknown, call query_dom to get element numbers", n)
+	}
+	// После навигации или перерисовки узел отсоединяется от документа
+	res, err := elem.Eval(`() => this.isConnected`)
+	if err != nil || !res.Value.Bool() {
+		return nil, fmt.Errorf("element not found: ref:%d is stale (page changed), call query_dom again", n)
+	}
+	return elem, nil
+}
+
+// ExpandRef дополняет ref:N описанием элемента (ref:17 text:Оплатить), чтобы проверка
+// безопасности и подтверждение видели, по чему кликает модель
+func (c *Controller) ExpandRef(selector string) string {
+	n, ok := action.ParseRef(selector)
+	if !ok {
+		return selector
+	}
+	if _, label, ok := c.refs.get(n); ok {
+		return selector + " " + label
+	}
+	return selector
+}
+
+// refHandles возвращает узлы, сохранённые findElementsJS, по индексам результата
+func refHandles(page *rod.Page) map[int]*rod.Element {
+	obj, err := page.Evaluate(rod.Eval(`() => window[Symbol.for('agentRefs')] || []`).ByObject())
+	if err != nil || obj.ObjectID == "" {
+		return nil
+	}
+	props, err := proto.RuntimeGetProperties{ObjectID: obj.ObjectID, OwnProperties: true}.Call(page)
+	if err != nil {
+		return nil
+	}
+
+	out := map[int]*rod.Element{}
+	for _, p := range props.Result {
+		i, err := strconv.Atoi(p.Name)
+		if err != nil || p.Value == nil || p.Value.Subtype != proto.RuntimeRemoteObjectSubtypeNode {
+			continue
+		}
+		if elem, err := page.ElementFromObject(p.Value); err == nil {
+			out[i] = elem
+		}
+	}
+	return out
+}
@@ -19,6 +19,17 @@ type ScreenshotResult struct {
 	Base64 string // base64 encoded image
 }
 
+// CaptureScreenshot возвращает PNG видимой части страницы без сохранения на диск
+func (c *Controller) CaptureScreenshot(ctx context.Context) ([]byte, error) {
+	if c.page == nil {
+		return nil, fmt.Errorf("page is nil")
+	}
+	if err := c.dialogErr(); err != nil {
+		return nil, err
+	}
+	return c.page.Timeout(c.timeout).Screenshot(false, nil)
+}
+
 // TakeScreenshot делает скриншот страницы
 func (c *Controller) TakeScreenshot(ctx context.Context, fullPage bool, saveDir string) (*ScreenshotResult, error) {
 	logger.Info(ctx, "📸 Taking screenshot", zap.Bool("full_page", fullPage))
@@ -7,6 +7,7 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/browser/action"
 	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
 )
 
@@ -20,7 +21,7 @@ func (c *Controller) WaitForElement(ctx context.Context, selector string, timeou
 		timeout = c.timeout
 	}
 
-	elem, err := c.page.Timeout(timeout).Element(selector)
+	elem, err := action.Locate(c, selector, timeout)
 	if err != nil {
 		return fmt.Errorf("element wait timeout: %w", err)
 	}
@@ -13,9 +13,12 @@ var appConfig *config
 type config struct {
 	Logger    LoggerConfig
 	Browser   BrowserConfig
+	LLM       LLMConfig
 	Anthropic AnthropicConfig
+	OpenAI    OpenAIConfig
 	Agent     AgentConfig
 	Security  SecurityConfig
+	Secrets   SecretsConfig
 }
 
 func Load(path ...string) error {
@@ -33,11 +36,21 @@ func Load(path ...string) error {
 		return err
 	}
 
+	llmCfg, err := env.NewLLMConfig()
+	if err != nil {
+		return err
+	}
+
 	anthropicCfg, err := env.NewAnthropicConfig()
 	if err != nil {
 		return err
 	}
 
+	openAICfg, err := env.NewOpenAIConfig()
+	if err != nil {
+		return err
+	}
+
 	agentCfg, err := env.NewAgentConfig()
 	if err != nil {
 		return err
@@ -48,12 +61,20 @@ func Load(path ...string) error {
 		return err
 	}
 
+	secretsCfg, err := env.NewSecretsConfig()
+	if err != nil {
+		return err
+	}
+
 	appConfig = &config{
 		Logger:    loggerCfg,
 		Browser:   browserCfg,
+		LLM:       llmCfg,
 		Anthropic: anthropicCfg,
+		OpenAI:    openAICfg,
 		Agent:     agentCfg,
 		Security:  securityCfg,
+		Secrets:   secretsCfg,
 	}
 
 	return nil
@@ -1,12 +1,27 @@
 package env
 
-import "github.com/caarlos0/env/v11"
+import (
+	"time"
+
+	"github.com/caarlos0/env/v11"
+)
 
 type agentEnvConfig struct {
-	MaxSteps       int    `env:"AGENT_MAX_STEPS" envDefault:"30"`
-	Interactive    bool   `env:"AGENT_INTERACTIVE" envDefault:"true"`
-	Screenshots    bool   `env:"AGENT_SCREENSHOTS" envDefault:"true"`
-	ScreenshotsDir string `env:"AGENT_SCREENSHOTS_DIR" envDefault:"screenshots"`
+	MaxSteps        int           `env:"AGENT_MAX_STEPS" envDefault:"30"`
+	MaxDuration     time.Duration `env:"AGENT_MAX_DURATION" envDefault:"0"`
+	MaxInputTokens  int           `env:"AGENT_MAX_INPUT_TOKENS" envDefault:"0"`
+	MaxOutputTokens int           `env:"AGENT_MAX_OUTPUT_TOKENS" envDefault:"0"`
+	Interactive     bool          `env:"AGENT_INTERACTIVE" envDefault:"true"`
+	Screenshots     bool          `env:"AGENT_SCREENSHOTS" envDefault:"true"`
+	ScreenshotsDir  string        `env:"AGENT_SCREENSHOTS_DIR" envDefault:"screenshots"`
+	Trace           bool          `env:"AGENT_TRACE" envDefault:"false"`
+	TraceDir        string        `env:"AGENT_TRACE_DIR" envDefault:"runs"`
+
+	// Сжатие истории диалога (0 = стратегия выключена)
+	ContextKeepPages       int `env:"AGENT_CONTEXT_KEEP_PAGES" envDefault:"3"`
+	ContextKeepImages      int `env:"AGENT_CONTEXT_KEEP_IMAGES" envDefault:"2"`
+	ContextSummarizeTokens int `env:"AGENT_CONTEXT_SUMMARIZE_TOKENS" envDefault:"0"`
+	ContextKeepMessages    int `env:"AGENT_CONTEXT_KEEP_MESSAGES" envDefault:"10"`
 }
 
 type agentConfig struct {
@@ -21,7 +36,16 @@ func NewAgentConfig() (*agentConfig, error) {
 	return &agentConfig{raw: raw}, nil
 }
 
-func (c *agentConfig) MaxSteps() int          { return c.raw.MaxSteps }
-func (c *agentConfig) Interactive() bool      { return c.raw.Interactive }
-func (c *agentConfig) Screenshots() bool      { return c.raw.Screenshots }
-func (c *agentConfig) ScreenshotsDir() string { return c.raw.ScreenshotsDir }
+func (c *agentConfig) MaxSteps() int               { return c.raw.MaxSteps }
+func (c *agentConfig) MaxDuration() time.Duration  { return c.raw.MaxDuration }
+func (c *agentConfig) MaxInputTokens() int         { return c.raw.MaxInputTokens }
+func (c *agentConfig) MaxOutputTokens() int        { return c.raw.MaxOutputTokens }
+func (c *agentConfig) Interactive() bool           { return c.raw.Interactive }
+func (c *agentConfig) Screenshots() bool           { return c.raw.Screenshots }
+func (c *agentConfig) ScreenshotsDir() string      { return c.raw.ScreenshotsDir }
+func (c *agentConfig) Trace() bool                 { return c.raw.Trace }
+func (c *agentConfig) TraceDir() string            { return c.raw.TraceDir }
+func (c *agentConfig) ContextKeepPages() int       { return c.raw.ContextKeepPages }
+func (c *agentConfig) ContextKeepImages() int      { return c.raw.ContextKeepImages }
+func (c *agentConfig) ContextSummarizeTokens() int { return c.raw.ContextSummarizeTokens }
+func (c *agentConfig) ContextKeepMessages() int    { return c.raw.ContextKeepMessages }
@@ -3,11 +3,12 @@ package env
 import "github.com/caarlos0/env/v11"
 
 type anthropicEnvConfig struct {
-	APIKey      string  `env:"ANTHROPIC_API_KEY,required"`
-	BaseURL     string  `env:"ANTHROPIC_BASE_URL"`
-	Model       string  `env:"ANTHROPIC_MODEL" envDefault:"claude-sonnet-4-5-20250929"`
-	MaxTokens   int     `env:"ANTHROPIC_MAX_TOKENS" envDefault:"4096"`
-	Temperature float64 `env:"ANTHROPIC_TEMPERATURE" envDefault:"0"`
+	APIKey      string   `env:"ANTHROPIC_API_KEY"`
+	BaseURL     string   `env:"ANTHROPIC_BASE_URL"`
+	Model       string   `env:"ANTHROPIC_MODEL" envDefault:"claude-sonnet-4-5-20250929"`
+	MaxTokens   int      `env:"ANTHROPIC_MAX_TOKENS" envDefault:"4096"`
+	Temperature *float64 `env:"ANTHROPIC_TEMPERATURE"` // nil = значение API по умолчанию
+	PromptCache bool     `env:"ANTHROPIC_PROMPT_CACHE" envDefault:"true"`
 }
 
 type anthropicConfig struct {
@@ -22,8 +23,9 @@ func NewAnthropicConfig() (*anthropicConfig, error) {
 	return &anthropicConfig{raw: raw}, nil
 }
 
-func (c *anthropicConfig) APIKey() string       { return c.raw.APIKey }
-func (c *anthropicConfig) BaseURL() string      { return c.raw.BaseURL }
-func (c *anthropicConfig) Model() string        { return c.raw.Model }
-func (c *anthropicConfig) MaxTokens() int       { return c.raw.MaxTokens }
-func (c *anthropicConfig) Temperature() float64 { return c.raw.Temperature }
+func (c *anthropicConfig) APIKey() string        { return c.raw.APIKey }
+func (c *anthropicConfig) BaseURL() string       { return c.raw.BaseURL }
+func (c *anthropicConfig) Model() string         { return c.raw.Model }
+func (c *anthropicConfig) MaxTokens() int        { return c.raw.MaxTokens }
+func (c *anthropicConfig) Temperature() *float64 { return c.raw.Temperature }
+func (c *anthropicConfig) PromptCache() bool     { return c.raw.PromptCache }
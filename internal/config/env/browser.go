@@ -3,9 +3,14 @@ package env
 import "github.com/caarlos0/env/v11"
 
 type browserEnvConfig struct {
-	Headless    bool   `env:"BROWSER_HEADLESS" envDefault:"false"`
-	UserDataDir string `env:"BROWSER_USER_DATA_DIR" envDefault:".browser-data"`
-	Timeout     int    `env:"BROWSER_TIMEOUT" envDefault:"30"`
+	Headless        bool   `env:"BROWSER_HEADLESS" envDefault:"false"`
+	UserDataDir     string `env:"BROWSER_USER_DATA_DIR" envDefault:".browser-data"`
+	Timeout         int    `env:"BROWSER_TIMEOUT" envDefault:"30"`
+	UploadDir       string `env:"BROWSER_UPLOAD_DIR"`
+	DownloadDir     string `env:"BROWSER_DOWNLOAD_DIR" envDefault:"downloads"`
+	DownloadTimeout int    `env:"BROWSER_DOWNLOAD_TIMEOUT" envDefault:"120"`
+	Extractor       string `env:"BROWSER_EXTRACTOR" envDefault:"dom"`
+	DialogPolicy    string `env:"BROWSER_DIALOG_POLICY" envDefault:"ask"`
 }
 
 type browserConfig struct {
@@ -20,6 +25,11 @@ func NewBrowserConfig() (*browserConfig, error) {
 	return &browserConfig{raw: raw}, nil
 }
 
-func (c *browserConfig) Headless() bool      { return c.raw.Headless }
-func (c *browserConfig) UserDataDir() string { return c.raw.UserDataDir }
-func (c *browserConfig) Timeout() int        { return c.raw.Timeout }
+func (c *browserConfig) Headless() bool       { return c.raw.Headless }
+func (c *browserConfig) UserDataDir() string  { return c.raw.UserDataDir }
+func (c *browserConfig) Timeout() int         { return c.raw.Timeout }
+func (c *browserConfig) UploadDir() string    { return c.raw.UploadDir }
+func (c *browserConfig) DownloadDir() string  { return c.raw.DownloadDir }
+func (c *browserConfig) DownloadTimeout() int { return c.raw.DownloadTimeout }
+func (c *browserConfig) Extractor() string    { return c.raw.Extractor }
+func (c *browserConfig) DialogPolicy() string { return c.raw.DialogPolicy }
@@ -0,0 +1,25 @@
+package env
+
+import "github.com/caarlos0/env/v11"
+
+type llmEnvConfig struct {
+	Provider     string `env:"LLM_PROVIDER" envDefault:"anthropic"`
+	ReplayScript string `env:"LLM_REPLAY_SCRIPT"`
+	RecordScript string `env:"LLM_RECORD_SCRIPT"`
+}
+
+type llmConfig struct {
+	raw llmEnvConfig
+}
+
+func NewLLMConfig() (*llmConfig, error) {
+	var raw llmEnvConfig
+	if err := env.Parse(&raw); err != nil {
+		return nil, err
+	}
+	return &llmConfig{raw: raw}, nil
+}
+
+func (c *llmConfig) Provider() string     { return c.raw.Provider }
+func (c *llmConfig) ReplayScript() string { return c.raw.ReplayScript }
+func (c *llmConfig) RecordScript() string { return c.raw.RecordScript }
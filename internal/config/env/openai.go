@@ -0,0 +1,29 @@
+package env
+
+import "github.com/caarlos0/env/v11"
+
+type openAIEnvConfig struct {
+	APIKey      string   `env:"OPENAI_API_KEY"`
+	BaseURL     string   `env:"OPENAI_BASE_URL" envDefault:"https://api.openai.com/v1"`
+	Model       string   `env:"OPENAI_MODEL" envDefault:"gpt-4o"`
+	MaxTokens   int      `env:"OPENAI_MAX_TOKENS" envDefault:"4096"`
+	Temperature *float64 `env:"OPENAI_TEMPERATURE"` // nil = значение сервера по умолчанию
+}
+
+type openAIConfig struct {
+	raw openAIEnvConfig
+}
+
+func NewOpenAIConfig() (*openAIConfig, error) {
+	var raw openAIEnvConfig
+	if err := env.Parse(&raw); err != nil {
+		return nil, err
+	}
+	return &openAIConfig{raw: raw}, nil
+}
+
+func (c *openAIConfig) APIKey() string        { return c.raw.APIKey }
+func (c *openAIConfig) BaseURL() string       { return c.raw.BaseURL }
+func (c *openAIConfig) Model() string         { return c.raw.Model }
+func (c *openAIConfig) MaxTokens() int        { return c.raw.MaxTokens }
+func (c *openAIConfig) Temperature() *float64 { return c.raw.Temperature }
@@ -0,0 +1,23 @@
+package env
+
+import "github.com/caarlos0/env/v11"
+
+type secretsEnvConfig struct {
+	File      string `env:"SECRETS_FILE" envDefault:"secrets.json"`
+	MasterKey string `env:"SECRETS_MASTER_KEY"`
+}
+
+type secretsConfig struct {
+	raw secretsEnvConfig
+}
+
+func NewSecretsConfig() (*secretsConfig, error) {
+	var raw secretsEnvConfig
+	if err := env.Parse(&raw); err != nil {
+		return nil, err
+	}
+	return &secretsConfig{raw: raw}, nil
+}
+
+func (c *secretsConfig) File() string      { return c.raw.File }
+func (c *secretsConfig) MasterKey() string { return c.raw.MasterKey }
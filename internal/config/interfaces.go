@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 // LoggerConfig конфигурация логгера
 type LoggerConfig interface {
 	Level() string
@@ -12,6 +14,11 @@ type BrowserConfig interface {
 	Headless() bool
 	UserDataDir() string
 	Timeout() int
+	UploadDir() string
+	DownloadDir() string
+	DownloadTimeout() int
+	Extractor() string
+	DialogPolicy() string
 }
 
 // AnthropicConfig конфигурация Anthropic API
@@ -20,15 +27,47 @@ type AnthropicConfig interface {
 	BaseURL() string
 	Model() string
 	MaxTokens() int
-	Temperature() float64
+	Temperature() *float64 // nil если не задана
+	PromptCache() bool
+}
+
+// LLMConfig выбор LLM провайдера
+type LLMConfig interface {
+	Provider() string     // "anthropic", "openai" или "replay"
+	ReplayScript() string // сценарий для LLM_PROVIDER=replay
+	RecordScript() string // куда записывать ответы модели (пусто = не записывать)
+}
+
+// OpenAIConfig конфигурация OpenAI-совместимого API
+type OpenAIConfig interface {
+	APIKey() string
+	BaseURL() string
+	Model() string
+	MaxTokens() int
+	Temperature() *float64 // nil если не задана
 }
 
 // AgentConfig конфигурация агента
 type AgentConfig interface {
 	MaxSteps() int
+	MaxDuration() time.Duration
+	MaxInputTokens() int
+	MaxOutputTokens() int
 	Interactive() bool
 	Screenshots() bool
 	ScreenshotsDir() string
+	Trace() bool
+	TraceDir() string
+	ContextKeepPages() int
+	ContextKeepImages() int
+	ContextSummarizeTokens() int
+	ContextKeepMessages() int
+}
+
+// SecretsConfig конфигурация хранилища секретов
+type SecretsConfig interface {
+	File() string
+	MasterKey() string // пусто = хранилище не используется
 }
 
 // SecurityConfig конфигурация security checker
@@ -1,20 +1,46 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Action представляет действие браузера
 type Action struct {
-	Type      ActionType
-	Selector  string
-	Value     string
-	URL       string
-	Direction string
-	Query     string // для query_dom
-	Question  string // для analyze_page
-	FullPage  bool   // для take_screenshot
-	X         int    // для click_at_position
-	Y         int    // для click_at_position
-	TabIndex  int    // для switch_tab
+	Type        ActionType
+	Selector    string
+	Value       string
+	URL         string
+	Direction   string
+	Query       string                 // для query_dom; для extract_data - какие данные извлечь
+	Question    string                 // для analyze_page
+	FullPage    bool                   // для take_screenshot
+	Annotate    bool                   // для take_screenshot: пронумеровать элементы (set-of-marks)
+	X           int                    // для click_at_position и drag (точка отпускания)
+	Y           int                    // для click_at_position и drag (точка отпускания)
+	TabIndex    int                    // для switch_tab
+	Label       string                 // для select_option: видимый текст варианта
+	OptionIndex int                    // для select_option: номер варианта с 1
+	Files       []string               // для upload_file: имена файлов в директории загрузок
+	Offset      int                    // для read_page: с какого символа читать
+	Schema      map[string]interface{} // для extract_data: JSON Schema результата
+	Data        interface{}            // для complete_task: структурированный результат
+	Accept      bool                   // для handle_dialog: принять (true) или отклонить диалог; Value - ответ на prompt
+	Key         string                 // для press_key: клавиша или сочетание (Escape, Control+A)
+	Repeat      int                    // для press_key: сколько раз нажать
+	Target      string                 // для drag: элемент, на который отпустить (Selector - что тащим); для fill_form - кнопка отправки (заполняет агент для проверки)
+	AtPoint     bool                   // для drag: X, Y заданы явно (точка (0,0) тоже допустима)
+	DX          int                    // для drag: смещение по горизонтали от точки захвата
+	DY          int                    // для drag: смещение по вертикали от точки захвата
+	Fields      []FormField            // для fill_form: поля и значения
+	Submit      bool                   // для fill_form: отправить форму после заполнения
+}
+
+// FormField поле для fill_form: подпись, name, ref:N или селектор и значение
+type FormField struct {
+	Field string
+	Value string
 }
 
 // ActionType тип действия браузера
@@ -36,6 +62,18 @@ const (
 	ActionTypeListTabs        ActionType = "list_tabs"
 	ActionTypeSwitchTab       ActionType = "switch_tab"
 	ActionTypeCloseTab        ActionType = "close_tab"
+	ActionTypeUploadFile      ActionType = "upload_file"
+	ActionTypeReadPage        ActionType = "read_page"
+	ActionTypeExtractData     ActionType = "extract_data"
+	ActionTypeHandleDialog    ActionType = "handle_dialog"
+	ActionTypeGoBack          ActionType = "go_back"
+	ActionTypeGoForward       ActionType = "go_forward"
+	ActionTypeReload          ActionType = "reload"
+	ActionTypeHover           ActionType = "hover"
+	ActionTypePressKey        ActionType = "press_key"
+	ActionTypeScrollIntoView  ActionType = "scroll_into_view"
+	ActionTypeDrag            ActionType = "drag"
+	ActionTypeFillForm        ActionType = "fill_form"
 )
 
 // ErrorContext контекст ошибки для адаптации агента
@@ -45,6 +83,30 @@ type ErrorContext struct {
 	Suggestion      string   // Рекомендация что делать
 }
 
+// FormatErrorContextMessage форматирует контекст ошибки для модели и отчётов
+func FormatErrorContextMessage(message string, ec *ErrorContext) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("❌ Действие не выполнено: %s\n", message))
+	if ec == nil {
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("Селектор: %s\n\n", ec.FailedSelector))
+
+	if len(ec.SimilarElements) > 0 {
+		sb.WriteString("📋 Похожие элементы на странице:\n")
+		for i, elem := range ec.SimilarElements {
+			sb.WriteString(fmt.Sprintf("  %d. %s\n", i+1, elem))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("💡 Рекомендация: %s\n", ec.Suggestion))
+	sb.WriteString("\nПопробуй использовать один из похожих селекторов или другой подход.")
+
+	return sb.String()
+}
+
 // ActionResult результат выполнения действия
 type ActionResult struct {
 	Success       bool
@@ -55,6 +117,56 @@ type ActionResult struct {
 	Screenshot    string        // путь к скриншоту
 	ScreenshotB64 string        // base64 скриншота для передачи в Claude
 	QueryResult   string        // результат query_dom
+	Downloads     []Download    // файлы, скачанные в результате действия
+	Dialogs       []Dialog      // JavaScript диалоги, открывшиеся во время действия
 	Duration      time.Duration
 	Timestamp     time.Time
 }
+
+// Download файл, скачанный браузером во время задачи
+type Download struct {
+	Name  string
+	Path  string // пусто если загрузка не завершилась
+	URL   string
+	Size  int64
+	MIME  string
+	Error string
+}
+
+// String описание загрузки для модели и вывода пользователю
+func (d Download) String() string {
+	if d.Error != "" {
+		return fmt.Sprintf("%s (%s)", d.Name, d.Error)
+	}
+	return fmt.Sprintf("%s (%s, %s) → %s", d.Name, FormatBytes(d.Size), d.MIME, d.Path)
+}
+
+// Dialog JavaScript диалог страницы: alert, confirm, prompt или beforeunload
+type Dialog struct {
+	Type          string
+	Message       string
+	URL           string
+	DefaultPrompt string // для prompt: значение по умолчанию
+	Outcome       string // accepted или dismissed; пусто пока диалог открыт
+}
+
+// String описание диалога для модели и вывода пользователю
+func (d Dialog) String() string {
+	s := fmt.Sprintf("%s %q", d.Type, d.Message)
+	if d.Outcome == "" {
+		return s + " (open)"
+	}
+	return s + " → " + d.Outcome
+}
+
+// FormatBytes размер в человекочитаемом виде
+func FormatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -20,4 +20,7 @@ type Element struct {
 	Href      string
 	ID        string
 	Classes   []string
+	Role      string   // роль из дерева доступности (пусто у DOM экстрактора)
+	States    []string // состояния: disabled, checked, expanded...
+	Depth     int      // вложенность в дереве доступности
 }
@@ -1,13 +1,49 @@
 package domain
 
-// Decision представляет решение AI о следующем действии
+// Decision представляет решение AI о следующих действиях
 type Decision struct {
-	Action     Action
+	Calls      []ToolCall // вызовы инструментов в порядке ответа модели
 	Reasoning  string
 	Confidence float64
 	Complete   bool
 	Result     string
-	ToolUseID  string
+	Usage      TokenUsage
+}
+
+// ToolCall один вызов инструмента из ответа модели
+type ToolCall struct {
+	ID     string
+	Action Action
+	Err    string // аргументы вызова не разобраны: инструмент не выполняется, модель получает ошибку
+}
+
+// ToolResult результат вызова инструмента для передачи модели
+type ToolResult struct {
+	ToolUseID string
+	Content   string
+	ImageB64  string // скриншот в base64 (PNG), если есть
+	IsError   bool
+}
+
+// TokenUsage расход токенов модели. InputTokens не включает токены из кэша промпта
+type TokenUsage struct {
+	InputTokens         int
+	OutputTokens        int
+	CacheReadTokens     int
+	CacheCreationTokens int
+}
+
+// Add суммирует расход
+func (u *TokenUsage) Add(other TokenUsage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheReadTokens += other.CacheReadTokens
+	u.CacheCreationTokens += other.CacheCreationTokens
+}
+
+// TotalInput все входные токены запроса: новые, прочитанные из кэша и записанные в кэш
+func (u TokenUsage) TotalInput() int {
+	return u.InputTokens + u.CacheReadTokens + u.CacheCreationTokens
 }
 
 // DecisionRequest запрос для принятия решения
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"encoding/json"
 	"errors"
 	"time"
 
@@ -23,6 +24,17 @@ type Task struct {
 	CompletedAt *time.Time
 	Result      string
 	Error       error
+	Usage       TokenUsage // суммарный расход токенов модели на задачу
+	Downloads   []Download // файлы, скачанные во время задачи
+	Dialogs     []Dialog   // JavaScript диалоги, открывавшиеся во время задачи
+	// ExtractSchema JSON Schema от пользователя для extract_data (nil = схему задаёт модель)
+	ExtractSchema map[string]interface{}
+	// Extracted данные, собранные extract_data со всех страниц: массив записей
+	// или объект, если корень ExtractSchema - object
+	Extracted interface{}
+	// OutputSchema JSON Schema результата: complete_task должен вернуть data по ней
+	OutputSchema map[string]interface{}
+	Data         interface{} // структурированный результат из complete_task
 }
 
 // NewTask создает новую задачу
@@ -68,6 +80,104 @@ func (t *Task) Fail(err error) error {
 	return nil
 }
 
+// AddExtracted добавляет результат extract_data к данным задачи и возвращает число новых записей.
+// Элементы массива становятся отдельными записями, повторы пропускаются. При схеме с корнем
+// object результат сливается с уже собранным объектом: массивы в полях дополняются, остальные
+// поля берутся из нового результата
+func (t *Task) AddExtracted(data interface{}) int {
+	if obj, ok := data.(map[string]interface{}); ok && t.extractsObject() {
+		acc, _ := t.Extracted.(map[string]interface{})
+		merged, added := mergeObject(acc, obj)
+		t.Extracted = merged
+		return added
+	}
+	records, _ := t.Extracted.([]interface{})
+	items, ok := data.([]interface{})
+	if !ok {
+		items = []interface{}{data}
+	}
+	merged, added := appendNew(records, items)
+	t.Extracted = merged
+	return added
+}
+
+// ExtractedCount число записей в собранных данных (объект - одна запись)
+func (t *Task) ExtractedCount() int {
+	switch v := t.Extracted.(type) {
+	case nil:
+		return 0
+	case []interface{}:
+		return len(v)
+	default:
+		return 1
+	}
+}
+
+// extractsObject true если пользовательская схема extract_data ждёт объект
+func (t *Task) extractsObject() bool {
+	typ, _ := t.ExtractSchema["type"].(string)
+	return typ == "object"
+}
+
+// mergeObject сливает новый объект с собранным. Возвращает число новых записей
+// в полях-массивах; объект без них считается одной записью, если что-то изменил
+func mergeObject(acc, obj map[string]interface{}) (map[string]interface{}, int) {
+	merged := make(map[string]interface{}, len(acc)+len(obj))
+	for k, v := range acc {
+		merged[k] = v
+	}
+	added, changed, arrays := 0, false, false
+	for k, v := range obj {
+		if items, ok := v.([]interface{}); ok {
+			arrays = true
+			prev, _ := merged[k].([]interface{})
+			var n int
+			merged[k], n = appendNew(prev, items)
+			added += n
+			continue
+		}
+		if v == nil {
+			continue
+		}
+		if old, ok := merged[k]; !ok || !sameJSON(old, v) {
+			merged[k], changed = v, true
+		}
+	}
+	if !arrays && changed {
+		added = 1
+	}
+	return merged, added
+}
+
+// appendNew добавляет к records элементы items, которых там ещё нет
+func appendNew(records, items []interface{}) ([]interface{}, int) {
+	seen := make(map[string]bool, len(records)+len(items))
+	for _, r := range records {
+		seen[jsonKey(r)] = true
+	}
+	added := 0
+	for _, item := range items {
+		key := jsonKey(item)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		records = append(records, item)
+		added++
+	}
+	return records, added
+}
+
+func sameJSON(a, b interface{}) bool {
+	return jsonKey(a) == jsonKey(b)
+}
+
+// jsonKey значение в JSON: ключи объектов сортируются, поэтому равные записи дают равные ключи
+func jsonKey(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
 // IsRunning проверяет запущена ли задача
 func (t *Task) IsRunning() bool {
 	return t.Status == TaskStatusRunning
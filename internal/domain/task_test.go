@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func encode(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func TestAddExtractedRecords(t *testing.T) {
+	task := NewTask("collect plans")
+	if n := task.AddExtracted(decode(t, `[{"plan": "Basic", "price": 10}, {"plan": "Pro", "price": 20}]`)); n != 2 {
+		t.Errorf("first page added %d", n)
+	}
+	// Вторая страница повторяет запись первой (ключи в другом порядке) и дублирует свою
+	if n := task.AddExtracted(decode(t, `[{"price": 20, "plan": "Pro"}, {"plan": "Team", "price": 50}, {"plan": "Team", "price": 50}]`)); n != 1 {
+		t.Errorf("second page added %d, want 1", n)
+	}
+	if n := task.AddExtracted(decode(t, `{"plan": "Enterprise"}`)); n != 1 {
+		t.Errorf("single record added %d", n)
+	}
+	want := `[{"plan":"Basic","price":10},{"plan":"Pro","price":20},{"plan":"Team","price":50},{"plan":"Enterprise"}]`
+	if got := encode(task.Extracted); got != want {
+		t.Errorf("Extracted = %s", got)
+	}
+	if task.ExtractedCount() != 4 {
+		t.Errorf("ExtractedCount = %d", task.ExtractedCount())
+	}
+}
+
+func TestAddExtractedObjectRoot(t *testing.T) {
+	task := NewTask("collect shop")
+	task.ExtractSchema = map[string]interface{}{"type": "object"}
+
+	if n := task.AddExtracted(decode(t, `{"shop": "A", "items": [{"id": 1}, {"id": 2}]}`)); n != 2 {
+		t.Errorf("first page added %d", n)
+	}
+	if n := task.AddExtracted(decode(t, `{"shop": "A", "total": 3, "note": null, "items": [{"id": 2}, {"id": 3}]}`)); n != 1 {
+		t.Errorf("second page added %d, want 1", n)
+	}
+	want := `{"items":[{"id":1},{"id":2},{"id":3}],"shop":"A","total":3}`
+	if got := encode(task.Extracted); got != want {
+		t.Errorf("Extracted = %s, want %s", got, want)
+	}
+	if task.ExtractedCount() != 1 {
+		t.Errorf("ExtractedCount = %d", task.ExtractedCount())
+	}
+
+	// Объект без массивов - одна запись, если что-то изменилось
+	plain := NewTask("collect profile")
+	plain.ExtractSchema = map[string]interface{}{"type": "object"}
+	if n := plain.AddExtracted(decode(t, `{"name": "Ann"}`)); n != 1 {
+		t.Errorf("new object added %d", n)
+	}
+	if n := plain.AddExtracted(decode(t, `{"name": "Ann"}`)); n != 0 {
+		t.Errorf("same object added %d", n)
+	}
+}
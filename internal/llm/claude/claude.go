@@ -13,11 +13,13 @@ import (
 
 // Provider реализация LLM для Claude
 type Provider struct {
-	client *anthropic.Client
+	client      *anthropic.Client
+	promptCache bool
 }
 
-// New создаёт нового Claude провайдера
-func New(apiKey, baseURL string) (*Provider, error) {
+// New создаёт нового Claude провайдера. promptCache включает cache_control
+// для запросов с ChatRequest.Cache
+func New(apiKey, baseURL string, promptCache bool) (*Provider, error) {
 	if apiKey == "" {
 		return nil, fmt.Errorf("API key is required")
 	}
@@ -28,7 +30,7 @@ func New(apiKey, baseURL string) (*Provider, error) {
 	}
 
 	client := anthropic.NewClient(opts...)
-	return &Provider{client: &client}, nil
+	return &Provider{client: &client, promptCache: promptCache}, nil
 }
 
 // Chat отправляет сообщения и получает ответ
@@ -43,10 +45,18 @@ func (p *Provider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatRes
 		params.System = []anthropic.TextBlockParam{{Text: req.System}}
 	}
 
+	if req.Temperature != nil {
+		params.Temperature = anthropic.Float(*req.Temperature)
+	}
+
 	if len(req.Tools) > 0 {
 		params.Tools = convertTools(req.Tools)
 	}
 
+	if p.promptCache && req.Cache {
+		setCacheBreakpoints(&params)
+	}
+
 	resp, err := p.client.Messages.New(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("claude chat: %w", err)
@@ -86,8 +96,8 @@ func convertMessages(msgs []llm.Message) []anthropic.MessageParam {
 	for _, m := range msgs {
 		var content []anthropic.ContentBlockParamUnion
 		for _, c := range m.Content {
-			if c.IsText() {
-				content = append(content, anthropic.NewTextBlock(c.Text))
+			if block, ok := convertBlock(c); ok {
+				content = append(content, block)
 			}
 		}
 		if len(content) > 0 {
@@ -100,25 +110,96 @@ func convertMessages(msgs []llm.Message) []anthropic.MessageParam {
 	return result
 }
 
+func convertBlock(c llm.ContentBlock) (anthropic.ContentBlockParamUnion, bool) {
+	switch {
+	case c.IsText():
+		// API не принимает пустые текстовые блоки
+		if c.Text == "" {
+			return anthropic.ContentBlockParamUnion{}, false
+		}
+		return anthropic.NewTextBlock(c.Text), true
+	case c.IsImage():
+		return anthropic.NewImageBlockBase64(c.ImageType, c.ImageBase64), true
+	case c.IsToolUse():
+		input := c.ToolInput
+		if input == nil {
+			input = map[string]interface{}{}
+		}
+		return anthropic.NewToolUseBlock(c.ToolUseID, input, c.ToolName), true
+	case c.IsToolResult():
+		block := anthropic.ToolResultBlockParam{ToolUseID: c.ToolUseID, IsError: anthropic.Bool(c.IsError)}
+		for _, inner := range c.Content {
+			switch {
+			case inner.IsText() && inner.Text != "":
+				block.Content = append(block.Content, anthropic.ToolResultBlockParamContentUnion{
+					OfText: &anthropic.TextBlockParam{Text: inner.Text},
+				})
+			case inner.IsImage():
+				block.Content = append(block.Content, anthropic.ToolResultBlockParamContentUnion{
+					OfImage: &anthropic.ImageBlockParam{Source: anthropic.ImageBlockParamSourceUnion{
+						OfBase64: &anthropic.Base64ImageSourceParam{
+							MediaType: anthropic.Base64ImageSourceMediaType(inner.ImageType),
+							Data:      inner.ImageBase64,
+						},
+					}},
+				})
+			}
+		}
+		return anthropic.ContentBlockParamUnion{OfToolResult: &block}, true
+	}
+	return anthropic.ContentBlockParamUnion{}, false
+}
+
 func convertTools(tools []llm.Tool) []anthropic.ToolUnionParam {
 	result := make([]anthropic.ToolUnionParam, 0, len(tools))
 	for _, t := range tools {
+		schema := anthropic.ToolInputSchemaParam{Properties: t.InputSchema["properties"]}
+		if required, ok := t.InputSchema["required"].([]string); ok {
+			schema.Required = required
+		}
 		tool := anthropic.ToolParam{
 			Name:        t.Name,
 			Description: anthropic.String(t.Description),
-			InputSchema: anthropic.ToolInputSchemaParam{
-				Properties: t.InputSchema,
-			},
+			InputSchema: schema,
 		}
 		result = append(result, anthropic.ToolUnionParam{OfTool: &tool})
 	}
 	return result
 }
 
+// setCacheBreakpoints ставит cache_control на системный промпт, последний инструмент
+// и последний блок истории. Кэш префикса создаётся в порядке tools -> system -> messages,
+// поэтому на следующем шаге переиспользуется всё кроме новых сообщений. Сжатие истории
+// переписывает старые сообщения пачкой раз в несколько шагов, между ними префикс стабилен
+func setCacheBreakpoints(params *anthropic.MessageNewParams) {
+	if n := len(params.System); n > 0 {
+		params.System[n-1].CacheControl = anthropic.NewCacheControlEphemeralParam()
+	}
+	if n := len(params.Tools); n > 0 {
+		if tool := params.Tools[n-1].OfTool; tool != nil {
+			tool.CacheControl = anthropic.NewCacheControlEphemeralParam()
+		}
+	}
+	if n := len(params.Messages); n > 0 {
+		content := params.Messages[n-1].Content
+		if m := len(content); m > 0 {
+			if cc := content[m-1].GetCacheControl(); cc != nil {
+				*cc = anthropic.NewCacheControlEphemeralParam()
+			}
+		}
+	}
+}
+
 func convertResponse(resp *anthropic.Message) *llm.ChatResponse {
 	result := &llm.ChatResponse{
 		StopReason: string(resp.StopReason),
 		Content:    make([]llm.ContentBlock, 0, len(resp.Content)),
+		Usage: llm.Usage{
+			InputTokens:              int(resp.Usage.InputTokens),
+			OutputTokens:             int(resp.Usage.OutputTokens),
+			CacheReadInputTokens:     int(resp.Usage.CacheReadInputTokens),
+			CacheCreationInputTokens: int(resp.Usage.CacheCreationInputTokens),
+		},
 	}
 
 	for _, block := range resp.Content {
@@ -136,6 +217,9 @@ func convertResponse(resp *anthropic.Message) *llm.ChatResponse {
 					cb.ToolInput = input
 				}
 			}
+		default:
+			// thinking и прочие служебные блоки не передаём дальше
+			continue
 		}
 		result.Content = append(result.Content, cb)
 	}
@@ -10,14 +10,35 @@ type Provider interface {
 	ChatWithVision(ctx context.Context, req *VisionRequest) (*ChatResponse, error)
 }
 
+// Причины остановки генерации (нормализованные для всех провайдеров)
+const (
+	StopReasonEndTurn   = "end_turn"
+	StopReasonToolUse   = "tool_use"
+	StopReasonMaxTokens = "max_tokens"
+)
+
+// Виды запросов к модели (ChatRequest.Kind). Запись и воспроизведение сценариев
+// раскладывают ответы по очередям вида, чтобы вызовы разных компонентов не путались
+const (
+	KindAgent     = "agent"     // основной цикл агента (с инструментами)
+	KindSubAgent  = "subagent"  // DOM sub-agent: анализ страницы и ошибок
+	KindExtract   = "extract"   // извлечение данных по схеме
+	KindSummarize = "summarize" // пересказ ранней истории при сжатии
+	KindVision    = "vision"    // ChatWithVision
+)
+
 // ChatRequest запрос к LLM
 type ChatRequest struct {
+	Kind        string // вид запроса (Kind*); пусто - agent при наличии инструментов, иначе subagent
 	Model       string
 	MaxTokens   int
 	System      string
 	Messages    []Message
 	Tools       []Tool
-	Temperature float64
+	Temperature *float64 // nil = значение провайдера по умолчанию; 0 тоже передаётся
+	// Cache помечает системный промпт, инструменты и историю как стабильный
+	// префикс для кэширования (если провайдер это поддерживает)
+	Cache bool
 }
 
 // VisionRequest запрос с изображением
@@ -34,15 +55,29 @@ type VisionRequest struct {
 type ChatResponse struct {
 	Content    []ContentBlock
 	StopReason string
+	Usage      Usage
+}
+
+// Usage расход токенов на запрос. InputTokens не включает токены из кэша
+type Usage struct {
+	InputTokens              int
+	OutputTokens             int
+	CacheReadInputTokens     int // прочитано из кэша промпта
+	CacheCreationInputTokens int // записано в кэш промпта
 }
 
-// ContentBlock блок контента в ответе
+// ContentBlock блок контента сообщения
 type ContentBlock struct {
-	Type      string // "text" или "tool_use"
-	Text      string
-	ToolUseID string
-	ToolName  string
-	ToolInput map[string]interface{}
+	Type        string // "text", "tool_use", "tool_result" или "image"
+	Text        string
+	ToolUseID   string // для tool_use и tool_result
+	ToolName    string
+	ToolInput   map[string]interface{}
+	InputError  string         // для tool_use: аргументы не разобраны (невалидный JSON от модели)
+	IsError     bool           // для tool_result
+	Content     []ContentBlock // содержимое tool_result (text и image)
+	ImageBase64 string         // для image
+	ImageType   string         // "image/png", "image/jpeg"
 }
 
 // Message сообщение в диалоге
@@ -55,7 +90,22 @@ type Message struct {
 type Tool struct {
 	Name        string
 	Description string
-	InputSchema map[string]interface{}
+	InputSchema map[string]interface{} // JSON Schema объекта аргументов
+}
+
+// TextBlock создаёт текстовый блок
+func TextBlock(text string) ContentBlock {
+	return ContentBlock{Type: "text", Text: text}
+}
+
+// ImageBlock создаёт блок изображения в base64
+func ImageBlock(imageB64, imageType string) ContentBlock {
+	return ContentBlock{Type: "image", ImageBase64: imageB64, ImageType: imageType}
+}
+
+// ToolResultBlock создаёт блок результата инструмента
+func ToolResultBlock(toolUseID string, isError bool, content ...ContentBlock) ContentBlock {
+	return ContentBlock{Type: "tool_result", ToolUseID: toolUseID, IsError: isError, Content: content}
 }
 
 // IsToolUse проверяет является ли блок вызовом инструмента
@@ -67,3 +117,13 @@ func (c *ContentBlock) IsToolUse() bool {
 func (c *ContentBlock) IsText() bool {
 	return c.Type == "text"
 }
+
+// IsToolResult проверяет является ли блок результатом инструмента
+func (c *ContentBlock) IsToolResult() bool {
+	return c.Type == "tool_result"
+}
+
+// IsImage проверяет является ли блок изображением
+func (c *ContentBlock) IsImage() bool {
+	return c.Type == "image"
+}
@@ -0,0 +1,229 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
+)
+
+// --- Формат Chat Completions API ---
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	Messages    []chatMessage `json:"messages"`
+	Tools       []chatTool    `json:"tools,omitempty"`
+}
+
+type chatMessage struct {
+	Role       string      `json:"role"`
+	Content    interface{} `json:"content"` // string, []contentPart или nil
+	ToolCalls  []toolCall  `json:"tool_calls,omitempty"`
+	ToolCallID string      `json:"tool_call_id,omitempty"`
+}
+
+type contentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *imageURL `json:"image_url,omitempty"`
+}
+
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+type toolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function functionCall `json:"function"`
+}
+
+type functionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+type chatTool struct {
+	Type     string       `json:"type"`
+	Function functionSpec `json:"function"`
+}
+
+type functionSpec struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content   *string    `json:"content"`
+			ToolCalls []toolCall `json:"tool_calls"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens        int `json:"prompt_tokens"`
+		CompletionTokens    int `json:"completion_tokens"`
+		PromptTokensDetails struct {
+			CachedTokens int `json:"cached_tokens"`
+		} `json:"prompt_tokens_details"`
+	} `json:"usage"`
+}
+
+// --- llm -> OpenAI ---
+
+func convertMessages(system string, msgs []llm.Message) []chatMessage {
+	var result []chatMessage
+	if system != "" {
+		result = append(result, chatMessage{Role: "system", Content: system})
+	}
+	for _, m := range msgs {
+		if m.Role == "assistant" {
+			result = append(result, convertAssistant(m))
+			continue
+		}
+		result = append(result, convertUser(m)...)
+	}
+	return result
+}
+
+// convertAssistant переводит текст и tool_use в content и tool_calls
+func convertAssistant(m llm.Message) chatMessage {
+	msg := chatMessage{Role: "assistant"}
+	var text []string
+	for _, c := range m.Content {
+		switch {
+		case c.IsText() && c.Text != "":
+			text = append(text, c.Text)
+		case c.IsToolUse():
+			args, _ := json.Marshal(c.ToolInput)
+			if c.ToolInput == nil {
+				args = []byte("{}")
+			}
+			msg.ToolCalls = append(msg.ToolCalls, toolCall{
+				ID: c.ToolUseID, Type: "function",
+				Function: functionCall{Name: c.ToolName, Arguments: string(args)},
+			})
+		}
+	}
+	if len(text) > 0 {
+		msg.Content = strings.Join(text, "\n")
+	}
+	return msg
+}
+
+// convertUser раскладывает сообщение пользователя на tool-сообщения и обычное user-сообщение.
+// Tool-сообщения должны идти сразу после assistant с tool_calls, а изображения
+// в них не поддерживаются - поэтому картинки из tool_result уходят в user-сообщение
+func convertUser(m llm.Message) []chatMessage {
+	var result []chatMessage
+	var parts []contentPart
+
+	for _, c := range m.Content {
+		switch {
+		case c.IsToolResult():
+			var text []string
+			for _, inner := range c.Content {
+				switch {
+				case inner.IsText():
+					text = append(text, inner.Text)
+				case inner.IsImage():
+					parts = append(parts, imagePart(inner.ImageBase64, inner.ImageType))
+				}
+			}
+			content := strings.Join(text, "\n")
+			if c.IsError {
+				content = "Error: " + content
+			}
+			result = append(result, chatMessage{Role: "tool", ToolCallID: c.ToolUseID, Content: content})
+		case c.IsText() && c.Text != "":
+			parts = append(parts, contentPart{Type: "text", Text: c.Text})
+		case c.IsImage():
+			parts = append(parts, imagePart(c.ImageBase64, c.ImageType))
+		}
+	}
+
+	if len(parts) > 0 {
+		result = append(result, chatMessage{Role: "user", Content: parts})
+	}
+	return result
+}
+
+func imagePart(imageB64, imageType string) contentPart {
+	if imageType == "" {
+		imageType = "image/png"
+	}
+	return contentPart{Type: "image_url", ImageURL: &imageURL{URL: "data:" + imageType + ";base64," + imageB64}}
+}
+
+func convertTools(tools []llm.Tool) []chatTool {
+	result := make([]chatTool, 0, len(tools))
+	for _, t := range tools {
+		params := t.InputSchema
+		if params == nil {
+			params = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		result = append(result, chatTool{
+			Type:     "function",
+			Function: functionSpec{Name: t.Name, Description: t.Description, Parameters: params},
+		})
+	}
+	return result
+}
+
+// --- OpenAI -> llm ---
+
+func convertResponse(resp *chatResponse) *llm.ChatResponse {
+	choice := resp.Choices[0]
+	result := &llm.ChatResponse{
+		StopReason: convertFinishReason(choice.FinishReason),
+		Usage: llm.Usage{
+			// prompt_tokens включает кэшированные токены, в llm.Usage они учитываются отдельно
+			InputTokens:          resp.Usage.PromptTokens - resp.Usage.PromptTokensDetails.CachedTokens,
+			OutputTokens:         resp.Usage.CompletionTokens,
+			CacheReadInputTokens: resp.Usage.PromptTokensDetails.CachedTokens,
+		},
+	}
+
+	if choice.Message.Content != nil && *choice.Message.Content != "" {
+		result.Content = append(result.Content, llm.TextBlock(*choice.Message.Content))
+	}
+
+	for _, tc := range choice.Message.ToolCalls {
+		// Локальные серверы иногда не присылают id вызова
+		if tc.ID == "" {
+			tc.ID = "call_" + uuid.NewString()
+		}
+		block := llm.ContentBlock{Type: "tool_use", ToolUseID: tc.ID, ToolName: tc.Function.Name, ToolInput: map[string]interface{}{}}
+		if tc.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(tc.Function.Arguments), &block.ToolInput); err != nil {
+				block.ToolInput = map[string]interface{}{}
+				block.InputError = fmt.Sprintf("arguments are not a JSON object (%s): %s", err, truncate(tc.Function.Arguments, 200))
+			}
+		}
+		result.Content = append(result.Content, block)
+	}
+
+	// Некоторые серверы (Ollama) возвращают "stop" вместе с tool_calls
+	if len(choice.Message.ToolCalls) > 0 {
+		result.StopReason = llm.StopReasonToolUse
+	}
+	return result
+}
+
+func convertFinishReason(reason string) string {
+	switch reason {
+	case "tool_calls", "function_call":
+		return llm.StopReasonToolUse
+	case "length":
+		return llm.StopReasonMaxTokens
+	default:
+		return llm.StopReasonEndTurn
+	}
+}
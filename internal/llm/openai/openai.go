@@ -0,0 +1,116 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
+)
+
+// DefaultBaseURL адрес OpenAI API по умолчанию
+const DefaultBaseURL = "https://api.openai.com/v1"
+
+// Provider реализация LLM для OpenAI-совместимых серверов (OpenAI, vLLM, llama.cpp, Ollama)
+type Provider struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// New создаёт OpenAI-совместимого провайдера. Ключ не обязателен для локальных серверов
+func New(apiKey, baseURL string) (*Provider, error) {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Provider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{},
+	}, nil
+}
+
+// Chat отправляет сообщения и получает ответ
+func (p *Provider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	body := chatRequest{
+		Model:       req.Model,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		Messages:    convertMessages(req.System, req.Messages),
+		Tools:       convertTools(req.Tools),
+	}
+
+	resp, err := p.send(ctx, body)
+	if err != nil {
+		return nil, fmt.Errorf("openai chat: %w", err)
+	}
+	return convertResponse(resp), nil
+}
+
+// ChatWithVision отправляет сообщение с изображением
+func (p *Provider) ChatWithVision(ctx context.Context, req *llm.VisionRequest) (*llm.ChatResponse, error) {
+	var messages []chatMessage
+	if req.System != "" {
+		messages = append(messages, chatMessage{Role: "system", Content: req.System})
+	}
+	messages = append(messages, chatMessage{Role: "user", Content: []contentPart{
+		imagePart(req.ImageBase64, req.ImageType),
+		{Type: "text", Text: req.Query},
+	}})
+
+	resp, err := p.send(ctx, chatRequest{Model: req.Model, MaxTokens: req.MaxTokens, Messages: messages})
+	if err != nil {
+		return nil, fmt.Errorf("openai vision: %w", err)
+	}
+	return convertResponse(resp), nil
+}
+
+func (p *Provider) send(ctx context.Context, body chatRequest) (*chatResponse, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	httpResp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	raw, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if httpResp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("status %d: %s", httpResp.StatusCode, truncate(string(raw), 500))
+	}
+
+	var resp chatResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("empty choices")
+	}
+	return &resp, nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max] + "..."
+	}
+	return s
+}
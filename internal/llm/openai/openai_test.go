@@ -0,0 +1,231 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
+)
+
+// standIn OpenAI-совместимый сервер в процессе: запоминает тело последнего запроса
+// и отвечает заданным JSON
+type standIn struct {
+	*httptest.Server
+	path, auth string
+	body       map[string]interface{}
+}
+
+func newStandIn(t *testing.T, status int, response string) *standIn {
+	t.Helper()
+	s := &standIn{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.path, s.auth = r.URL.Path, r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		s.body = nil
+		if err := json.Unmarshal(data, &s.body); err != nil {
+			t.Errorf("request body is not JSON: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newProvider(t *testing.T, s *standIn) *Provider {
+	t.Helper()
+	p, err := New("sk-test", s.URL+"/v1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func temperature(v float64) *float64 { return &v }
+
+const toolCallResponse = `{
+	"choices": [{
+		"message": {
+			"content": "Opening the page",
+			"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "navigate", "arguments": "{\"url\":\"https://go.dev\"}"}}]
+		},
+		"finish_reason": "tool_calls"
+	}],
+	"usage": {"prompt_tokens": 120, "completion_tokens": 15, "prompt_tokens_details": {"cached_tokens": 100}}
+}`
+
+func TestChatRequestMapping(t *testing.T) {
+	s := newStandIn(t, http.StatusOK, toolCallResponse)
+	p := newProvider(t, s)
+
+	_, err := p.Chat(context.Background(), &llm.ChatRequest{
+		Model: "gpt-test", MaxTokens: 512, Temperature: temperature(0),
+		System: "you are a browser agent",
+		Messages: []llm.Message{
+			{Role: "user", Content: []llm.ContentBlock{llm.TextBlock("open go.dev")}},
+			{Role: "assistant", Content: []llm.ContentBlock{
+				llm.TextBlock("taking a screenshot"),
+				{Type: "tool_use", ToolUseID: "toolu_1", ToolName: "take_screenshot", ToolInput: map[string]interface{}{"full_page": true}},
+			}},
+			{Role: "user", Content: []llm.ContentBlock{
+				llm.ToolResultBlock("toolu_1", false, llm.ImageBlock("aW1n", "image/png"), llm.TextBlock("Screenshot taken")),
+				llm.TextBlock("Current page context"),
+			}},
+		},
+		Tools: []llm.Tool{{
+			Name: "navigate", Description: "Open URL",
+			InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{"url": map[string]interface{}{"type": "string"}}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.path != "/v1/chat/completions" {
+		t.Errorf("path = %q", s.path)
+	}
+	if s.auth != "Bearer sk-test" {
+		t.Errorf("Authorization = %q", s.auth)
+	}
+	if s.body["model"] != "gpt-test" || s.body["max_tokens"] != float64(512) {
+		t.Errorf("model/max_tokens = %v/%v", s.body["model"], s.body["max_tokens"])
+	}
+	if temp, ok := s.body["temperature"]; !ok || temp != float64(0) {
+		t.Errorf("temperature 0 not sent: %v, %v", temp, ok)
+	}
+
+	msgs := s.body["messages"].([]interface{})
+	roles := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		roles = append(roles, m.(map[string]interface{})["role"].(string))
+	}
+	if got := strings.Join(roles, ","); got != "system,user,assistant,tool,user" {
+		t.Fatalf("roles = %s", got)
+	}
+
+	assistant := msgs[2].(map[string]interface{})
+	call := assistant["tool_calls"].([]interface{})[0].(map[string]interface{})
+	fn := call["function"].(map[string]interface{})
+	if call["id"] != "toolu_1" || fn["name"] != "take_screenshot" || fn["arguments"] != `{"full_page":true}` {
+		t.Errorf("tool call = %v", call)
+	}
+
+	tool := msgs[3].(map[string]interface{})
+	if tool["tool_call_id"] != "toolu_1" || tool["content"] != "Screenshot taken" {
+		t.Errorf("tool message = %v", tool)
+	}
+
+	// Картинка из tool_result переезжает в user сообщение: tool сообщения изображений не принимают
+	parts := msgs[4].(map[string]interface{})["content"].([]interface{})
+	image := parts[0].(map[string]interface{})
+	if image["type"] != "image_url" || image["image_url"].(map[string]interface{})["url"] != "data:image/png;base64,aW1n" {
+		t.Errorf("image part = %v", image)
+	}
+
+	tools := s.body["tools"].([]interface{})
+	spec := tools[0].(map[string]interface{})["function"].(map[string]interface{})
+	if spec["name"] != "navigate" || spec["parameters"].(map[string]interface{})["type"] != "object" {
+		t.Errorf("tool spec = %v", spec)
+	}
+}
+
+func TestChatResponseMapping(t *testing.T) {
+	s := newStandIn(t, http.StatusOK, toolCallResponse)
+	resp, err := newProvider(t, s).Chat(context.Background(), &llm.ChatRequest{Model: "gpt-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StopReason != llm.StopReasonToolUse {
+		t.Errorf("StopReason = %q", resp.StopReason)
+	}
+	if len(resp.Content) != 2 || resp.Content[0].Text != "Opening the page" {
+		t.Fatalf("content = %+v", resp.Content)
+	}
+	call := resp.Content[1]
+	if !call.IsToolUse() || call.ToolUseID != "call_1" || call.ToolName != "navigate" || call.ToolInput["url"] != "https://go.dev" {
+		t.Errorf("tool_use = %+v", call)
+	}
+	if call.InputError != "" {
+		t.Errorf("unexpected InputError %q", call.InputError)
+	}
+	if u := resp.Usage; u.InputTokens != 20 || u.CacheReadInputTokens != 100 || u.OutputTokens != 15 {
+		t.Errorf("usage = %+v", u)
+	}
+}
+
+func TestTemperatureOmittedWhenNotSet(t *testing.T) {
+	s := newStandIn(t, http.StatusOK, `{"choices":[{"message":{"content":"ok"},"finish_reason":"stop"}]}`)
+	resp, err := newProvider(t, s).Chat(context.Background(), &llm.ChatRequest{Model: "gpt-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.body["temperature"]; ok {
+		t.Errorf("temperature sent without configuration: %v", s.body["temperature"])
+	}
+	if _, ok := s.body["tools"]; ok {
+		t.Error("empty tools list sent")
+	}
+	if resp.StopReason != llm.StopReasonEndTurn {
+		t.Errorf("StopReason = %q", resp.StopReason)
+	}
+}
+
+func TestMalformedToolArguments(t *testing.T) {
+	s := newStandIn(t, http.StatusOK, `{"choices":[{"message":{"tool_calls":[
+		{"type":"function","function":{"name":"click","arguments":"{\"selector\": \"#submit\""}}
+	]},"finish_reason":"stop"}]}`)
+	resp, err := newProvider(t, s).Chat(context.Background(), &llm.ChatRequest{Model: "gpt-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Content) != 1 {
+		t.Fatalf("content = %+v", resp.Content)
+	}
+	call := resp.Content[0]
+	if call.InputError == "" {
+		t.Error("malformed arguments were not reported")
+	}
+	if !strings.HasPrefix(call.ToolUseID, "call_") {
+		t.Errorf("generated id = %q", call.ToolUseID)
+	}
+	// Ollama отвечает "stop" вместе с tool_calls
+	if resp.StopReason != llm.StopReasonToolUse {
+		t.Errorf("StopReason = %q", resp.StopReason)
+	}
+}
+
+func TestChatWithVision(t *testing.T) {
+	s := newStandIn(t, http.StatusOK, `{"choices":[{"message":{"content":"a login form"},"finish_reason":"stop"}]}`)
+	resp, err := newProvider(t, s).ChatWithVision(context.Background(), &llm.VisionRequest{
+		Model: "gpt-test", System: "describe", ImageBase64: "aW1n", ImageType: "image/jpeg", Query: "what is on the page?",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content[0].Text != "a login form" {
+		t.Errorf("content = %+v", resp.Content)
+	}
+
+	msgs := s.body["messages"].([]interface{})
+	parts := msgs[1].(map[string]interface{})["content"].([]interface{})
+	image := parts[0].(map[string]interface{})["image_url"].(map[string]interface{})
+	text := parts[1].(map[string]interface{})
+	if image["url"] != "data:image/jpeg;base64,aW1n" || text["text"] != "what is on the page?" {
+		t.Errorf("vision parts = %v", parts)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	s := newStandIn(t, http.StatusTooManyRequests, `{"error":{"message":"rate limited"}}`)
+	_, err := newProvider(t, s).Chat(context.Background(), &llm.ChatRequest{Model: "gpt-test"})
+	if err == nil || !strings.Contains(err.Error(), "status 429") || !strings.Contains(err.Error(), "rate limited") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package llm
+
+import "context"
+
+// redactingProvider прогоняет весь текст запроса через redact перед отправкой в модель
+type redactingProvider struct {
+	provider Provider
+	redact   func(string) string
+}
+
+// WithRedaction оборачивает провайдер: системный промпт, история диалога, результаты
+// инструментов и вопросы к vision уходят в модель только после redact
+func WithRedaction(provider Provider, redact func(string) string) Provider {
+	return &redactingProvider{provider: provider, redact: redact}
+}
+
+func (r *redactingProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	cp := *req
+	cp.System = r.redact(req.System)
+	cp.Messages = make([]Message, len(req.Messages))
+	for i, m := range req.Messages {
+		cp.Messages[i] = Message{Role: m.Role, Content: r.blocks(m.Content)}
+	}
+	return r.provider.Chat(ctx, &cp)
+}
+
+func (r *redactingProvider) ChatWithVision(ctx context.Context, req *VisionRequest) (*ChatResponse, error) {
+	cp := *req
+	cp.System, cp.Query = r.redact(req.System), r.redact(req.Query)
+	return r.provider.ChatWithVision(ctx, &cp)
+}
+
+// blocks копия блоков с обработанным текстом; историю диалога не меняем
+func (r *redactingProvider) blocks(in []ContentBlock) []ContentBlock {
+	out := make([]ContentBlock, len(in))
+	for i, b := range in {
+		b.Text = r.redact(b.Text)
+		if len(b.Content) > 0 {
+			b.Content = r.blocks(b.Content)
+		}
+		out[i] = b
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// captureProvider запоминает последний запрос
+type captureProvider struct {
+	chat   *ChatRequest
+	vision *VisionRequest
+}
+
+func (p *captureProvider) Chat(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+	p.chat = req
+	return &ChatResponse{StopReason: StopReasonEndTurn}, nil
+}
+
+func (p *captureProvider) ChatWithVision(_ context.Context, req *VisionRequest) (*ChatResponse, error) {
+	p.vision = req
+	return &ChatResponse{StopReason: StopReasonEndTurn}, nil
+}
+
+func hideSecret(s string) string { return strings.ReplaceAll(s, "hunter2", "{{secret:pass}}") }
+
+func TestWithRedactionChat(t *testing.T) {
+	inner := &captureProvider{}
+	p := WithRedaction(inner, hideSecret)
+
+	req := &ChatRequest{
+		System: "system hunter2",
+		Messages: []Message{
+			{Role: "user", Content: []ContentBlock{TextBlock("task: type hunter2")}},
+			{Role: "assistant", Content: []ContentBlock{{Type: "tool_use", ToolUseID: "t1", ToolName: "type_text",
+				ToolInput: map[string]interface{}{"text": "{{secret:pass}}"}}}},
+			{Role: "user", Content: []ContentBlock{
+				ToolResultBlock("t1", false, TextBlock("field value: hunter2"), ImageBlock("aGVsbG8=", "image/png")),
+			}},
+		},
+	}
+	if _, err := p.Chat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	got := inner.chat
+	if got.System != "system {{secret:pass}}" {
+		t.Errorf("System = %q", got.System)
+	}
+	if text := got.Messages[0].Content[0].Text; text != "task: type {{secret:pass}}" {
+		t.Errorf("user text = %q", text)
+	}
+	result := got.Messages[2].Content[0]
+	if text := result.Content[0].Text; text != "field value: {{secret:pass}}" {
+		t.Errorf("tool result text = %q", text)
+	}
+	if result.Content[1].ImageBase64 != "aGVsbG8=" || result.ToolUseID != "t1" {
+		t.Errorf("tool result block changed: %+v", result)
+	}
+
+	// Запрос вызывающего (история диалога) не меняется
+	if req.System != "system hunter2" || req.Messages[2].Content[0].Content[0].Text != "field value: hunter2" {
+		t.Error("original request was modified")
+	}
+}
+
+func TestWithRedactionVision(t *testing.T) {
+	inner := &captureProvider{}
+	p := WithRedaction(inner, hideSecret)
+
+	if _, err := p.ChatWithVision(context.Background(), &VisionRequest{System: "hunter2", Query: "is hunter2 visible?", ImageBase64: "aGVsbG8="}); err != nil {
+		t.Fatal(err)
+	}
+	if inner.vision.System != "{{secret:pass}}" || inner.vision.Query != "is {{secret:pass}} visible?" {
+		t.Errorf("vision request not redacted: %+v", inner.vision)
+	}
+	if inner.vision.ImageBase64 != "aGVsbG8=" {
+		t.Error("image changed")
+	}
+}
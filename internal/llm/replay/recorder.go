@@ -0,0 +1,56 @@
+package replay
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
+)
+
+// Recorder проксирует запросы в реальный провайдер и записывает ответы в сценарий
+type Recorder struct {
+	mu       sync.Mutex
+	provider llm.Provider
+	path     string
+	script   Script
+}
+
+// NewRecorder создаёт записывающую обёртку над провайдером
+func NewRecorder(provider llm.Provider, path string) *Recorder {
+	return &Recorder{provider: provider, path: path}
+}
+
+// Chat вызывает провайдер и записывает ответ
+func (r *Recorder) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	resp, err := r.provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if q := r.script.queue(requestKind(req)); q != nil {
+		*q = append(*q, fromChatResponse(resp))
+	}
+	return resp, nil
+}
+
+// ChatWithVision вызывает провайдер и записывает ответ
+func (r *Recorder) ChatWithVision(ctx context.Context, req *llm.VisionRequest) (*llm.ChatResponse, error) {
+	resp, err := r.provider.ChatWithVision(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.script.Vision = append(r.script.Vision, fromChatResponse(resp))
+	return resp, nil
+}
+
+// Save сохраняет записанный сценарий
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.script.Save(r.path)
+}
@@ -0,0 +1,69 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
+)
+
+// Provider воспроизводит ответы модели из сценария - для детерминированных офлайн прогонов
+type Provider struct {
+	mu      sync.Mutex
+	script  *Script
+	next    map[string]int
+	counter int
+}
+
+// New создаёт провайдер воспроизведения сценария из файла
+func New(path string) (*Provider, error) {
+	script, err := LoadScript(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromScript(script), nil
+}
+
+// NewFromScript создаёт провайдер воспроизведения из готового сценария
+func NewFromScript(script *Script) *Provider {
+	return &Provider{script: script, next: map[string]int{}}
+}
+
+// Chat возвращает следующий ответ из очереди вида запроса
+func (p *Provider) Chat(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	return p.pop(requestKind(req))
+}
+
+// ChatWithVision возвращает следующий ответ из очереди vision
+func (p *Provider) ChatWithVision(_ context.Context, _ *llm.VisionRequest) (*llm.ChatResponse, error) {
+	return p.pop(llm.KindVision)
+}
+
+// Remaining возвращает количество невоспроизведённых ответов основного агента
+func (p *Provider) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.script.Agent) - p.next["agent"]
+}
+
+func (p *Provider) pop(queue string) (*llm.ChatResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	q := p.script.queue(queue)
+	if q == nil {
+		return nil, fmt.Errorf("replay: unknown request kind %q", queue)
+	}
+	responses := *q
+	i := p.next[queue]
+	if i >= len(responses) {
+		return nil, fmt.Errorf("replay: %s script exhausted after %d responses", queue, len(responses))
+	}
+	p.next[queue] = i + 1
+
+	return responses[i].toChatResponse(func() string {
+		p.counter++
+		return fmt.Sprintf("toolu_replay_%d", p.counter)
+	}), nil
+}
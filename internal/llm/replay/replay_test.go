@@ -0,0 +1,182 @@
+package replay_test
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/ai"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm/replay"
+)
+
+func chat(t *testing.T, p llm.Provider, kind string) *llm.ChatResponse {
+	t.Helper()
+	resp, err := p.Chat(context.Background(), &llm.ChatRequest{Kind: kind})
+	if err != nil {
+		t.Fatalf("%s: %v", kind, err)
+	}
+	return resp
+}
+
+func text(resp *llm.ChatResponse) string {
+	for _, b := range resp.Content {
+		if b.IsText() {
+			return b.Text
+		}
+	}
+	return ""
+}
+
+// TestReplayQueuesByKind вызовы разных компонентов вперемешку получают ответы своих очередей
+func TestReplayQueuesByKind(t *testing.T) {
+	p, err := replay.New(filepath.Join("testdata", "mixed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := chat(t, p, llm.KindAgent); resp.Content[0].ToolName != "query_dom" || resp.StopReason != llm.StopReasonToolUse {
+		t.Errorf("agent #1 = %+v", resp)
+	}
+	if got := text(chat(t, p, llm.KindSubAgent)); got != "Таблица тарифов: table.pricing" {
+		t.Errorf("subagent = %q", got)
+	}
+	vision, err := p.ChatWithVision(context.Background(), &llm.VisionRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := text(vision); !strings.Contains(got, "тремя тарифами") {
+		t.Errorf("vision = %q", got)
+	}
+	if got := text(chat(t, p, llm.KindSummarize)); !strings.Contains(got, "страница тарифов") {
+		t.Errorf("summarize = %q", got)
+	}
+	if resp := chat(t, p, llm.KindAgent); resp.Content[0].ToolUseID != "toolu_fixed" {
+		t.Errorf("agent #2 tool id = %q", resp.Content[0].ToolUseID)
+	}
+	if got := text(chat(t, p, llm.KindExtract)); !strings.HasPrefix(got, `[{"plan"`) {
+		t.Errorf("extract = %q", got)
+	}
+	if p.Remaining() != 1 {
+		t.Errorf("Remaining = %d, want 1", p.Remaining())
+	}
+	if resp := chat(t, p, llm.KindAgent); resp.StopReason != llm.StopReasonEndTurn || text(resp) != "Готово" {
+		t.Errorf("agent #3 = %+v", resp)
+	}
+
+	if _, err := p.Chat(context.Background(), &llm.ChatRequest{Kind: llm.KindSubAgent}); err == nil {
+		t.Error("exhausted subagent queue returned a response")
+	}
+	if _, err := p.Chat(context.Background(), &llm.ChatRequest{Kind: "unknown"}); err == nil {
+		t.Error("unknown kind returned a response")
+	}
+}
+
+// TestReplayKindFallback запросы без Kind раскладываются по наличию инструментов
+func TestReplayKindFallback(t *testing.T) {
+	p := replay.NewFromScript(&replay.Script{
+		Agent:    []replay.Response{{Text: "agent"}},
+		SubAgent: []replay.Response{{Text: "subagent"}},
+	})
+	resp, err := p.Chat(context.Background(), &llm.ChatRequest{Tools: []llm.Tool{{Name: "navigate"}}})
+	if err != nil || text(resp) != "agent" {
+		t.Errorf("with tools = %v, %v", resp, err)
+	}
+	resp, err = p.Chat(context.Background(), &llm.ChatRequest{})
+	if err != nil || text(resp) != "subagent" {
+		t.Errorf("without tools = %v, %v", resp, err)
+	}
+}
+
+// TestReplaySearchFixture сценарий из README разбирается в решения основного агента
+func TestReplaySearchFixture(t *testing.T) {
+	ctx := context.Background()
+	p, err := replay.New(filepath.Join("testdata", "search.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := ai.New(ctx, p, "replay", 1024, nil, ai.CompactionConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.NewConversation()
+
+	var steps [][]domain.ActionType
+	var usage domain.TokenUsage
+	for step := 1; step <= 3; step++ {
+		if err := client.AddUserMessage("Найди на тестовой странице go.dev", &domain.PageContext{URL: "about:blank"}); err != nil {
+			t.Fatal(err)
+		}
+		d, err := client.DecideNextAction(ctx)
+		if err != nil {
+			t.Fatalf("step %d: %v", step, err)
+		}
+		usage.Add(d.Usage)
+		var types []domain.ActionType
+		results := make([]domain.ToolResult, 0, len(d.Calls))
+		for _, call := range d.Calls {
+			if call.ID == "" || call.Err != "" {
+				t.Errorf("step %d: bad call %+v", step, call)
+			}
+			types = append(types, call.Action.Type)
+			results = append(results, domain.ToolResult{ToolUseID: call.ID, Content: "ok"})
+		}
+		steps = append(steps, types)
+		client.AddToolResults(results)
+		if d.Complete {
+			if !strings.Contains(d.Result, "go.dev") {
+				t.Errorf("result = %q", d.Result)
+			}
+			break
+		}
+	}
+
+	want := [][]domain.ActionType{
+		{domain.ActionTypeNavigate},
+		{domain.ActionTypeType, domain.ActionTypePressEnter},
+		{domain.ActionTypeCompleteTask},
+	}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("steps = %v, want %v", steps, want)
+	}
+	if usage.InputTokens != 4300 || usage.OutputTokens != 125 {
+		t.Errorf("usage = %+v", usage)
+	}
+	if p.Remaining() != 0 {
+		t.Errorf("Remaining = %d", p.Remaining())
+	}
+}
+
+// TestRecorderRoundTrip записанный сценарий воспроизводится с теми же ответами по видам
+func TestRecorderRoundTrip(t *testing.T) {
+	source, err := replay.New(filepath.Join("testdata", "mixed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "recorded.yaml")
+	rec := replay.NewRecorder(source, path)
+
+	chat(t, rec, llm.KindAgent)
+	chat(t, rec, llm.KindExtract)
+	chat(t, rec, llm.KindSubAgent)
+	if _, err := rec.ChatWithVision(context.Background(), &llm.VisionRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	script, err := replay.LoadScript(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(script.Agent) != 1 || len(script.Extract) != 1 || len(script.SubAgent) != 1 || len(script.Vision) != 1 || len(script.Summarize) != 0 {
+		t.Fatalf("recorded queues = %+v", script)
+	}
+	if script.Agent[0].ToolCalls[0].Name != "query_dom" || script.Extract[0].Text != `[{"plan": "Basic", "price": 10}]` {
+		t.Errorf("recorded responses = %+v", script)
+	}
+}
@@ -0,0 +1,162 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/llm"
+)
+
+// Script записанный сценарий ответов модели. У каждого вида запроса (llm.Kind*) своя
+// очередь: основной агент, DOM sub-agent, извлечение данных, пересказ истории и vision
+type Script struct {
+	Agent     []Response `yaml:"agent,omitempty" json:"agent,omitempty"`
+	SubAgent  []Response `yaml:"subagent,omitempty" json:"subagent,omitempty"`
+	Extract   []Response `yaml:"extract,omitempty" json:"extract,omitempty"`
+	Summarize []Response `yaml:"summarize,omitempty" json:"summarize,omitempty"`
+	Vision    []Response `yaml:"vision,omitempty" json:"vision,omitempty"`
+}
+
+// queue очередь ответов для вида запроса; nil - вид неизвестен
+func (s *Script) queue(kind string) *[]Response {
+	switch kind {
+	case llm.KindAgent:
+		return &s.Agent
+	case llm.KindSubAgent:
+		return &s.SubAgent
+	case llm.KindExtract:
+		return &s.Extract
+	case llm.KindSummarize:
+		return &s.Summarize
+	case llm.KindVision:
+		return &s.Vision
+	}
+	return nil
+}
+
+// requestKind вид запроса; без явного Kind - по наличию инструментов
+func requestKind(req *llm.ChatRequest) string {
+	switch {
+	case req.Kind != "":
+		return req.Kind
+	case len(req.Tools) > 0:
+		return llm.KindAgent
+	default:
+		return llm.KindSubAgent
+	}
+}
+
+// Response один ответ модели
+type Response struct {
+	Text       string     `yaml:"text,omitempty" json:"text,omitempty"`
+	ToolCalls  []ToolCall `yaml:"tool_calls,omitempty" json:"tool_calls,omitempty"`
+	StopReason string     `yaml:"stop_reason,omitempty" json:"stop_reason,omitempty"`
+	Usage      *Usage     `yaml:"usage,omitempty" json:"usage,omitempty"`
+}
+
+// ToolCall вызов инструмента
+type ToolCall struct {
+	ID    string                 `yaml:"id,omitempty" json:"id,omitempty"`
+	Name  string                 `yaml:"name" json:"name"`
+	Input map[string]interface{} `yaml:"input,omitempty" json:"input,omitempty"`
+}
+
+// Usage расход токенов
+type Usage struct {
+	InputTokens              int `yaml:"input_tokens" json:"input_tokens"`
+	OutputTokens             int `yaml:"output_tokens" json:"output_tokens"`
+	CacheReadInputTokens     int `yaml:"cache_read_input_tokens,omitempty" json:"cache_read_input_tokens,omitempty"`
+	CacheCreationInputTokens int `yaml:"cache_creation_input_tokens,omitempty" json:"cache_creation_input_tokens,omitempty"`
+}
+
+// LoadScript читает сценарий из YAML или JSON файла
+func LoadScript(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read script: %w", err)
+	}
+	var s Script
+	// YAML - надмножество JSON, поэтому один парсер подходит для обоих форматов
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse script %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Save записывает сценарий в файл. Формат определяется расширением (.json или YAML)
+func (s *Script) Save(path string) error {
+	var (
+		data []byte
+		err  error
+	)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err = json.MarshalIndent(s, "", "  ")
+	} else {
+		data, err = yaml.Marshal(s)
+	}
+	if err != nil {
+		return fmt.Errorf("marshal script: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("create script dir: %w", err)
+		}
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// toChatResponse переводит записанный ответ в формат llm
+func (r Response) toChatResponse(callID func() string) *llm.ChatResponse {
+	resp := &llm.ChatResponse{StopReason: r.StopReason}
+	if r.Text != "" {
+		resp.Content = append(resp.Content, llm.TextBlock(r.Text))
+	}
+	for _, tc := range r.ToolCalls {
+		id := tc.ID
+		if id == "" {
+			id = callID()
+		}
+		input := tc.Input
+		if input == nil {
+			input = map[string]interface{}{}
+		}
+		resp.Content = append(resp.Content, llm.ContentBlock{
+			Type: "tool_use", ToolUseID: id, ToolName: tc.Name, ToolInput: input,
+		})
+	}
+	if resp.StopReason == "" {
+		resp.StopReason = llm.StopReasonEndTurn
+		if len(r.ToolCalls) > 0 {
+			resp.StopReason = llm.StopReasonToolUse
+		}
+	}
+	if r.Usage != nil {
+		resp.Usage = llm.Usage(*r.Usage)
+	}
+	return resp
+}
+
+// fromChatResponse переводит ответ llm в записываемый формат
+func fromChatResponse(resp *llm.ChatResponse) Response {
+	r := Response{StopReason: resp.StopReason}
+	var text []string
+	for _, b := range resp.Content {
+		switch {
+		case b.IsText():
+			text = append(text, b.Text)
+		case b.IsToolUse():
+			r.ToolCalls = append(r.ToolCalls, ToolCall{ID: b.ToolUseID, Name: b.ToolName, Input: b.ToolInput})
+		}
+	}
+	r.Text = strings.Join(text, "\n")
+	if resp.Usage != (llm.Usage{}) {
+		u := Usage(resp.Usage)
+		r.Usage = &u
+	}
+	return r
+}
@@ -0,0 +1,73 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// minRedactLen значения короче не вырезаем: "1" или "ok" испортили бы любой текст
+const minRedactLen = 3
+
+var placeholderRe = regexp.MustCompile(`\{\{\s*secret:([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// ErrNotConfigured в тексте есть плейсхолдер, а хранилище не открыто
+var ErrNotConfigured = errors.New("secrets store is not configured: set SECRETS_MASTER_KEY")
+
+// Placeholder плейсхолдер секрета для задач и ввода: {{secret:name}}
+func Placeholder(name string) string { return "{{secret:" + name + "}}" }
+
+// HasPlaceholders true если в тексте есть {{secret:name}}
+func HasPlaceholders(text string) bool {
+	return strings.Contains(text, "{{") && placeholderRe.MatchString(text)
+}
+
+// Resolve подставляет значения секретов вместо плейсхолдеров. Неизвестное имя - ошибка:
+// буквальный {{secret:...}} в поле пароля хуже, чем неудачное действие
+func (s *Store) Resolve(text string) (string, error) {
+	if !HasPlaceholders(text) {
+		return text, nil
+	}
+	if s == nil {
+		return "", ErrNotConfigured
+	}
+	var missing []string
+	out := placeholderRe.ReplaceAllStringFunc(text, func(m string) string {
+		name := placeholderRe.FindStringSubmatch(m)[1]
+		v, ok := s.Get(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("unknown secret %q (see agent secrets list)", missing[0])
+	}
+	return out, nil
+}
+
+// Redact заменяет значения секретов в тексте их плейсхолдерами. Длинные значения
+// заменяются первыми, чтобы секрет, содержащий другой, не раскрылся частично
+func (s *Store) Redact(text string) string {
+	if s == nil || text == "" {
+		return text
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.values) == 0 {
+		return text
+	}
+	names := make([]string, 0, len(s.values))
+	for name, v := range s.values {
+		if len(v) >= minRedactLen && strings.Contains(text, v) {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return len(s.values[names[i]]) > len(s.values[names[j]]) })
+	for _, name := range names {
+		text = strings.ReplaceAll(text, s.values[name], Placeholder(name))
+	}
+	return text
+}
@@ -0,0 +1,82 @@
+package secrets
+
+import (
+	"errors"
+	"testing"
+)
+
+// memStore хранилище с заданными значениями без файла и шифрования
+func memStore(values map[string]string) *Store {
+	return &Store{values: values}
+}
+
+func TestResolve(t *testing.T) {
+	s := memStore(map[string]string{"mail_pass": "hunter2!", "otp": "123456"})
+
+	got, err := s.Resolve("login: {{secret:mail_pass}}, code {{ secret:otp }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "login: hunter2!, code 123456"; got != want {
+		t.Errorf("Resolve = %q, want %q", got, want)
+	}
+
+	if got, err := s.Resolve("no placeholders"); err != nil || got != "no placeholders" {
+		t.Errorf("Resolve without placeholders = %q, %v", got, err)
+	}
+}
+
+func TestResolveUnknownName(t *testing.T) {
+	s := memStore(map[string]string{"mail_pass": "hunter2!"})
+	got, err := s.Resolve("{{secret:mail_pass}} {{secret:bank_pass}}")
+	if err == nil {
+		t.Fatalf("Resolve with unknown name succeeded: %q", got)
+	}
+	if got != "" {
+		t.Errorf("Resolve returned partial text %q on error", got)
+	}
+}
+
+func TestResolveWithoutStore(t *testing.T) {
+	var s *Store
+	if _, err := s.Resolve("{{secret:mail_pass}}"); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("nil store: err = %v, want ErrNotConfigured", err)
+	}
+	if got, err := s.Resolve("plain text"); err != nil || got != "plain text" {
+		t.Errorf("nil store without placeholders = %q, %v", got, err)
+	}
+	if got := s.Redact("plain text"); got != "plain text" {
+		t.Errorf("nil store Redact = %q", got)
+	}
+}
+
+// TestRedactLongestFirst секрет, содержащий другой, заменяется целиком
+func TestRedactLongestFirst(t *testing.T) {
+	s := memStore(map[string]string{"short": "pass", "long": "pass-word-123"})
+	got := s.Redact("typed pass-word-123 and pass")
+	if want := "typed {{secret:long}} and {{secret:short}}"; got != want {
+		t.Errorf("Redact = %q, want %q", got, want)
+	}
+}
+
+func TestRedactSkipsShortValues(t *testing.T) {
+	s := memStore(map[string]string{"pin": "42", "code": "123"})
+	got := s.Redact("answer 42, code 123")
+	if want := "answer 42, code {{secret:code}}"; got != want {
+		t.Errorf("Redact = %q, want %q", got, want)
+	}
+}
+
+func TestHasPlaceholders(t *testing.T) {
+	for text, want := range map[string]bool{
+		"{{secret:a}}":         true,
+		"x {{ secret:a.b-c }}": true,
+		"{{secret:}}":          false,
+		"{{ other:a }}":        false,
+		"secret:a":             false,
+	} {
+		if got := HasPlaceholders(text); got != want {
+			t.Errorf("HasPlaceholders(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// Package secrets хранит пароли и токены для задач агента в локальном зашифрованном файле.
+// Модель видит только плейсхолдеры {{secret:name}}: значения подставляются при вводе в поле
+// и вырезаются из всего, что уходит в модель, логи и вывод прогресса
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+const (
+	fileVersion = 1
+	kdfIters    = 600_000 // PBKDF2-SHA256, рекомендация OWASP
+	saltSize    = 16
+	keySize     = 32 // AES-256
+	checkText   = "browser-agent-secrets"
+)
+
+var (
+	// ErrNoMasterKey хранилище нельзя открыть без мастер-ключа
+	ErrNoMasterKey = errors.New("SECRETS_MASTER_KEY is not set")
+	// ErrWrongKey мастер-ключ не подходит к файлу (или файл повреждён)
+	ErrWrongKey = errors.New("wrong master key or corrupted secrets file")
+
+	validName = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+)
+
+// storeFile формат файла: значения зашифрованы по отдельности, имена открыты
+type storeFile struct {
+	Version    int               `json:"version"`
+	Iterations int               `json:"iterations"`
+	Salt       string            `json:"salt"`
+	Check      string            `json:"check"` // шифротекст checkText: проверка ключа без секретов
+	Secrets    map[string]string `json:"secrets"`
+}
+
+// Store хранилище секретов: файл JSON, значения зашифрованы AES-256-GCM ключом,
+// выведенным из мастер-ключа через PBKDF2-SHA256. Имя секрета - AAD его шифротекста
+type Store struct {
+	mu     sync.RWMutex
+	path   string
+	aead   cipher.AEAD
+	file   storeFile
+	values map[string]string
+}
+
+// Open открывает хранилище и расшифровывает все значения. Если файла нет -
+// хранилище пустое, файл создастся при первом Set
+func Open(path, masterKey string) (*Store, error) {
+	if masterKey == "" {
+		return nil, ErrNoMasterKey
+	}
+	s := &Store{path: path, values: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		s.file = storeFile{Version: fileVersion, Iterations: kdfIters, Salt: base64.StdEncoding.EncodeToString(salt), Secrets: map[string]string{}}
+		if s.aead, err = newAEAD(masterKey, salt, kdfIters); err != nil {
+			return nil, err
+		}
+		if s.file.Check, err = s.seal(checkText, ""); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case err != nil:
+		return nil, fmt.Errorf("read secrets file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.file); err != nil {
+		return nil, fmt.Errorf("parse secrets file %s: %w", path, err)
+	}
+	if s.file.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported secrets file version %d", s.file.Version)
+	}
+	salt, err := base64.StdEncoding.DecodeString(s.file.Salt)
+	if err != nil || len(salt) == 0 || s.file.Iterations <= 0 {
+		return nil, ErrWrongKey
+	}
+	if s.aead, err = newAEAD(masterKey, salt, s.file.Iterations); err != nil {
+		return nil, err
+	}
+	if check, err := s.open(s.file.Check, ""); err != nil || check != checkText {
+		return nil, ErrWrongKey
+	}
+	if s.file.Secrets == nil {
+		s.file.Secrets = map[string]string{}
+	}
+	for name, enc := range s.file.Secrets {
+		value, err := s.open(enc, name)
+		if err != nil {
+			return nil, fmt.Errorf("secret %q: %w", name, ErrWrongKey)
+		}
+		s.values[name] = value
+	}
+	return s, nil
+}
+
+// Names имена секретов по алфавиту
+func (s *Store) Names() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.values))
+	for name := range s.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get значение секрета
+func (s *Store) Get(name string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.values[name]
+	return v, ok
+}
+
+// Set сохраняет секрет и записывает файл
+func (s *Store) Set(name, value string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q: use letters, digits, '_', '-', '.'", name)
+	}
+	if value == "" {
+		return errors.New("secret value is empty")
+	}
+	enc, err := s.seal(value, name)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[name] = value
+	s.file.Secrets[name] = enc
+	return s.saveLocked()
+}
+
+// Remove удаляет секрет и записывает файл
+func (s *Store) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.values[name]; !ok {
+		return fmt.Errorf("secret %q not found", name)
+	}
+	delete(s.values, name)
+	delete(s.file.Secrets, name)
+	return s.saveLocked()
+}
+
+// saveLocked пишет файл атомарно (через временный файл) с правами только для владельца
+func (s *Store) saveLocked() error {
+	data, err := json.MarshalIndent(s.file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(s.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("create secrets dir: %w", err)
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write secrets file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write secrets file: %w", err)
+	}
+	return nil
+}
+
+func newAEAD(masterKey string, salt []byte, iters int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, masterKey, salt, iters, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal шифрует значение: base64(nonce | ciphertext)
+func (s *Store) seal(value, name string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := s.aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	return base64.StdEncoding.EncodeToString(out), nil
+}
+
+func (s *Store) open(enc, name string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil || len(data) < s.aead.NonceSize() {
+		return "", ErrWrongKey
+	}
+	n := s.aead.NonceSize()
+	plain, err := s.aead.Open(nil, data[:n], data[n:], []byte(name))
+	if err != nil {
+		return "", ErrWrongKey
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,139 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testKey = "correct horse battery staple"
+
+func newStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	s, err := Open(path, testKey)
+	if err != nil {
+		t.Fatalf("open new store: %v", err)
+	}
+	return s, path
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	s, path := newStore(t)
+	if err := s.Set("mail_pass", "hunter2!"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("api.token", "tok-ÄÖÜ \"quoted\""); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(path, testKey)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	for name, want := range map[string]string{"mail_pass": "hunter2!", "api.token": "tok-ÄÖÜ \"quoted\""} {
+		if got, ok := reopened.Get(name); !ok || got != want {
+			t.Errorf("Get(%q) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+	if got := reopened.Names(); len(got) != 2 || got[0] != "api.token" || got[1] != "mail_pass" {
+		t.Errorf("Names() = %v", got)
+	}
+
+	// Значения не лежат в файле открытым текстом
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2!") || strings.Contains(string(data), "quoted") {
+		t.Error("secret value stored in plain text")
+	}
+
+	if err := reopened.Remove("mail_pass"); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Open(path, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := again.Get("mail_pass"); ok {
+		t.Error("removed secret is still in the store")
+	}
+}
+
+func TestStoreWrongKey(t *testing.T) {
+	s, path := newStore(t)
+	if err := s.Set("mail_pass", "hunter2!"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path, "wrong key"); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Open with wrong key: err = %v, want ErrWrongKey", err)
+	}
+	if _, err := Open(path, ""); !errors.Is(err, ErrNoMasterKey) {
+		t.Errorf("Open without key: err = %v, want ErrNoMasterKey", err)
+	}
+}
+
+// TestStoreNameIsAAD шифротекст одного секрета нельзя выдать за другой, переставив значения в файле
+func TestStoreNameIsAAD(t *testing.T) {
+	s, path := newStore(t)
+	if err := s.Set("bank", "bank-password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("forum", "forum-password"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f storeFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatal(err)
+	}
+	f.Secrets["bank"], f.Secrets["forum"] = f.Secrets["forum"], f.Secrets["bank"]
+	data, err = json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(path, testKey); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Open with swapped values: err = %v, want ErrWrongKey", err)
+	}
+}
+
+func TestStoreFilePermissions(t *testing.T) {
+	s, path := newStore(t)
+	if err := s.Set("mail_pass", "hunter2!"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("file mode = %o, want 600", perm)
+	}
+	if _, err := os.Stat(path + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Error("temporary file left behind")
+	}
+}
+
+func TestStoreSetValidation(t *testing.T) {
+	s, _ := newStore(t)
+	for _, name := range []string{"", "with space", "slash/name", "{{x}}"} {
+		if err := s.Set(name, "value"); err == nil {
+			t.Errorf("Set(%q) accepted invalid name", name)
+		}
+	}
+	if err := s.Set("empty", ""); err == nil {
+		t.Error("Set accepted empty value")
+	}
+}
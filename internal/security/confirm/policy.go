@@ -0,0 +1,43 @@
+package confirm
+
+import (
+	"fmt"
+	"os"
+)
+
+// Policy политика подтверждения опасных действий
+type Policy string
+
+const (
+	PolicyPrompt Policy = "prompt" // спрашивать пользователя в терминале
+	PolicyAllow  Policy = "allow"  // подтверждать всё автоматически
+	PolicyDeny   Policy = "deny"   // отклонять всё (fail closed)
+)
+
+// ParsePolicy разбирает политику из строки. Пустая строка - автоматический выбор
+func ParsePolicy(s string) (Policy, error) {
+	switch Policy(s) {
+	case "":
+		return DefaultPolicy(), nil
+	case PolicyPrompt, PolicyAllow, PolicyDeny:
+		return Policy(s), nil
+	}
+	return "", fmt.Errorf("unknown confirm policy: %q (expected prompt, allow or deny)", s)
+}
+
+// DefaultPolicy спрашивает пользователя только если есть терминал, иначе отклоняет
+func DefaultPolicy() Policy {
+	if HasTTY() {
+		return PolicyPrompt
+	}
+	return PolicyDeny
+}
+
+// HasTTY проверяет что stdin подключён к терминалу
+func HasTTY() bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
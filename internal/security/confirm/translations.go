@@ -15,6 +15,18 @@ func GetActionName(actionType domain.ActionType) string {
 		domain.ActionTypeTakeScreenshot:  "Скриншот",
 		domain.ActionTypeQueryDOM:        "Запрос DOM",
 		domain.ActionTypeClickAtPosition: "Клик по координатам",
+		domain.ActionTypeUploadFile:      "Загрузка файлов",
+		domain.ActionTypeReadPage:        "Чтение страницы",
+		domain.ActionTypeExtractData:     "Извлечение данных",
+		domain.ActionTypeHandleDialog:    "Ответ на диалог сайта",
+		domain.ActionTypeGoBack:          "Назад",
+		domain.ActionTypeGoForward:       "Вперёд",
+		domain.ActionTypeReload:          "Перезагрузка страницы",
+		domain.ActionTypeHover:           "Наведение курсора",
+		domain.ActionTypePressKey:        "Нажатие клавиш",
+		domain.ActionTypeScrollIntoView:  "Прокрутка к элементу",
+		domain.ActionTypeDrag:            "Перетаскивание",
+		domain.ActionTypeFillForm:        "Заполнение формы",
 	}
 	if name, ok := names[actionType]; ok {
 		return name
@@ -36,6 +48,8 @@ func TranslateReason(reason string) string {
 		"placing order":                 "Оформление заказа",
 		"attempting to delete email":    "Удаление письма",
 		"deleting data":                 "Удаление данных",
+		"uploading local files":         "Загрузка локальных файлов на сайт",
+		"submitting form":               "Отправка формы",
 	}
 	if translated, ok := translations[reason]; ok {
 		return translated
@@ -60,6 +74,8 @@ func TranslateSuggestion(suggestion string) string {
 		"Email will be moved to trash":       "Письмо будет перемещено в корзину",
 		"May involve real money":             "Может затронуть реальные деньги",
 		"Data may be lost":                   "Данные могут быть потеряны",
+		"Files will be sent to the website":  "Файлы будут отправлены на сайт",
+		"Filled data will be sent to the website": "Заполненные данные будут отправлены на сайт",
 		"Это может включать реальные деньги": "Это может включать реальные деньги",
 		"Проверьте детали платежа внимательно": "Проверьте детали платежа внимательно",
 	}
@@ -18,7 +18,7 @@ func CriticalRules() []Rule {
 				"All data will be permanently lost",
 			},
 			Matcher: func(action domain.Action, ctx *domain.PageContext) bool {
-				if action.Type != domain.ActionTypeClick {
+				if action.Type != domain.ActionTypeClick && !IsDialogAccept(action) {
 					return false
 				}
 				text := GetActionText(action)
@@ -37,7 +37,7 @@ func CriticalRules() []Rule {
 				"Проверьте детали платежа внимательно",
 			},
 			Matcher: func(action domain.Action, ctx *domain.PageContext) bool {
-				if action.Type != domain.ActionTypeClick {
+				if action.Type != domain.ActionTypeClick && !IsDialogAccept(action) && !IsFormSubmit(action) {
 					return false
 				}
 				text := GetActionText(action)
@@ -49,6 +49,15 @@ func CriticalRules() []Rule {
 					"заказать", "checkout", "подтвердить оплату",
 					"proceed to payment", "complete order", "завершить заказ",
 				}
+
+				// Отправка формы через "Оплатить", форма с данными карты или на странице оплаты -
+				// тот же платёж, что и клик по кнопке
+				if IsFormSubmit(action) {
+					return ContainsAny(strings.ToLower(action.Target), paymentWords) ||
+						ContainsAny(strings.ToLower(action.Selector), []string{"card", "cvv", "cvc", "номер карт", "security code"}) ||
+						ctx != nil && ContainsAny(strings.ToLower(ctx.URL), []string{"payment", "checkout", "billing", "pay", "order"})
+				}
+
 				if ContainsAny(text, paymentWords) {
 					return true
 				}
@@ -25,10 +25,10 @@ func HighRiskRules() []Rule {
 			Pattern: "job_application", Level: RiskLevelHigh, Reason: "sending job application",
 			Suggestions: []string{"Application will be sent", "Verify info is correct"},
 			Matcher: func(a domain.Action, c *domain.PageContext) bool {
-				if a.Type != domain.ActionTypeClick || c == nil {
+				if (a.Type != domain.ActionTypeClick && !IsFormSubmit(a)) || c == nil {
 					return false
 				}
-				url, text := strings.ToLower(c.URL), GetActionText(a)
+				url, text := strings.ToLower(c.URL), SubmitText(a)
 				return ContainsAny(url, []string{"hh.ru", "headhunter", "superjob", "rabota"}) &&
 					ContainsAny(text, []string{"откликнуться", "отправить", "apply", "submit", "отклик"})
 			},
@@ -37,10 +37,10 @@ func HighRiskRules() []Rule {
 			Pattern: "order_placement", Level: RiskLevelHigh, Reason: "placing order",
 			Suggestions: []string{"Order will be placed", "May involve real money"},
 			Matcher: func(a domain.Action, c *domain.PageContext) bool {
-				if a.Type != domain.ActionTypeClick || c == nil {
+				if (a.Type != domain.ActionTypeClick && !IsFormSubmit(a)) || c == nil {
 					return false
 				}
-				url, text := strings.ToLower(c.URL), GetActionText(a)
+				url, text := strings.ToLower(c.URL), SubmitText(a)
 				return ContainsAny(url, []string{"lavka.yandex", "eda.yandex", "checkout", "cart", "ozon", "wildberries"}) &&
 					ContainsAny(text, []string{"оформить", "заказать", "оплатить", "купить", "checkout", "pay", "order"})
 			},
@@ -49,7 +49,7 @@ func HighRiskRules() []Rule {
 			Pattern: "data_deletion", Level: RiskLevelHigh, Reason: "deleting data",
 			Suggestions: []string{"Data may be permanently lost", "Consider backup"},
 			Matcher: func(a domain.Action, c *domain.PageContext) bool {
-				if !IsClickAction(a) {
+				if !IsClickAction(a) && !IsDialogAccept(a) {
 					return false
 				}
 				return ContainsAny(GetActionText(a), []string{"delete", "remove", "trash", "clear", "удалить", "очистить", "permanently", "навсегда"})
@@ -59,11 +59,18 @@ func HighRiskRules() []Rule {
 			Pattern: "sensitive_form", Level: RiskLevelHigh, Reason: "submitting sensitive info",
 			Suggestions: []string{"Verify info is correct", "Check website is legitimate"},
 			Matcher: func(a domain.Action, c *domain.PageContext) bool {
-				if a.Type != domain.ActionTypeClick && a.Type != domain.ActionTypeType {
+				if a.Type != domain.ActionTypeClick && a.Type != domain.ActionTypeType && a.Type != domain.ActionTypeFillForm {
 					return false
 				}
 				return ContainsAny(GetActionText(a), []string{"password", "credit card", "ssn", "bank account"})
 			},
 		},
+		{
+			Pattern: "file_upload", Level: RiskLevelHigh, Reason: "uploading local files",
+			Suggestions: []string{"Files will be sent to the website", "Verify info is correct"},
+			Matcher: func(a domain.Action, c *domain.PageContext) bool {
+				return a.Type == domain.ActionTypeUploadFile
+			},
+		},
 	}
 }
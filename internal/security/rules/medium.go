@@ -17,10 +17,10 @@ func MediumRiskRules() []Rule {
 				"Email will be sent to recipients",
 			},
 			Matcher: func(action domain.Action, ctx *domain.PageContext) bool {
-				if action.Type != domain.ActionTypeClick {
+				if action.Type != domain.ActionTypeClick && !IsFormSubmit(action) {
 					return false
 				}
-				text := GetActionText(action)
+				text := SubmitText(action)
 				return ContainsAny(text, []string{"send", "send email", "отправить"}) &&
 					ctx != nil && ContainsAny(strings.ToLower(ctx.URL), []string{"mail", "почта"})
 			},
@@ -61,3 +61,25 @@ func GetActionText(action domain.Action) string {
 func IsClickAction(action domain.Action) bool {
 	return action.Type == domain.ActionTypeClick || action.Type == domain.ActionTypeClickAtPosition
 }
+
+// IsFormSubmit проверяет, что fill_form отправит форму. Агент передаёт в Selector все поля,
+// в Value все значения формы, а в Target текст кнопки отправки, поэтому правила оценивают форму целиком
+func IsFormSubmit(action domain.Action) bool {
+	return action.Type == domain.ActionTypeFillForm && action.Submit
+}
+
+// SubmitText текст, по которому судят об отправке: у fill_form с отправкой - текст кнопки
+// отправки, иначе - селектор и значение действия. Отправка формы кнопкой "Откликнуться"
+// проверяется так же, как клик по ней
+func SubmitText(action domain.Action) string {
+	if IsFormSubmit(action) {
+		return strings.ToLower(action.Target)
+	}
+	return GetActionText(action)
+}
+
+// IsDialogAccept проверяет, что действие принимает JavaScript диалог (confirm, prompt, beforeunload).
+// Текст диалога агент передаёт в Selector, поэтому правила для кликов применимы и к нему
+func IsDialogAccept(action domain.Action) bool {
+	return action.Type == domain.ActionTypeHandleDialog && action.Accept
+}
@@ -0,0 +1,52 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+)
+
+// matched паттерны правил, под которые подходит действие
+func matched(action domain.Action, ctx *domain.PageContext) []string {
+	var patterns []string
+	for _, r := range BuildRules() {
+		if r.Matches(action, ctx) {
+			patterns = append(patterns, r.Pattern)
+		}
+	}
+	return patterns
+}
+
+// TestFormSubmitRules отправка fill_form сама по себе не опасна: её оценивают те же правила,
+// что и клик по кнопке отправки, по полям формы и тексту кнопки
+func TestFormSubmitRules(t *testing.T) {
+	page := func(url string) *domain.PageContext { return &domain.PageContext{URL: url} }
+	form := func(fields, values, button string) domain.Action {
+		return domain.Action{Type: domain.ActionTypeFillForm, Selector: fields, Value: values, Target: button, Submit: true}
+	}
+	tests := []struct {
+		name   string
+		action domain.Action
+		ctx    *domain.PageContext
+		want   []string
+	}{
+		{"search form", form("search", "golang", "Найти"), page("https://example.com"), nil},
+		{"not submitted", domain.Action{Type: domain.ActionTypeFillForm, Selector: "q", Target: "Оплатить"}, page("https://example.com"), nil},
+		{"pay button", form("name", "Ivan", "Оплатить"), page("https://shop.example.com"), []string{"financial_transaction"}},
+		{"card fields", form("card number, cvc", "{{secret:card}}", "Далее"), page("https://shop.example.com"), []string{"financial_transaction"}},
+		{"password", form("login, password", "ivan, {{secret:pass}}", "Войти"), page("https://example.com/login"), []string{"sensitive_form"}},
+		{"job application", form("cover letter", "Hello", "Откликнуться"), page("https://hh.ru/vacancy/1"), []string{"job_application"}},
+		{"order", form("address", "Moscow", "Оформить заказ"), page("https://ozon.ru/cart"), []string{"financial_transaction", "order_placement"}},
+		{"email", form("to, subject", "boss@example.com, report", "Отправить"), page("https://mail.yandex.ru/compose"), []string{"email_send"}},
+		// Значения полей не считаются текстом кнопки: "order" в поле не делает форму заказом
+		{"order in field", form("order id", "order 42", "Найти"), page("https://ozon.ru/search"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matched(tt.action, tt.ctx); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matched = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
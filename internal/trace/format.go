@@ -0,0 +1,110 @@
+package trace
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+)
+
+// Файлы внутри директории прогона
+const (
+	TaskFile  = "task.json"
+	StepsFile = "steps.jsonl"
+)
+
+// Task описание задачи прогона (task.json)
+type Task struct {
+	ID          string            `json:"id"`
+	Description string            `json:"description"`
+	Status      string            `json:"status"`
+	CreatedAt   time.Time         `json:"created_at"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+	Result      string            `json:"result,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	Usage       Usage             `json:"usage"`
+	Steps       int               `json:"steps"`
+	Downloads   []domain.Download `json:"downloads,omitempty"`
+	Dialogs     []domain.Dialog   `json:"dialogs,omitempty"`
+	Extracted   json.RawMessage   `json:"extracted,omitempty"` // данные extract_data
+	Data        json.RawMessage   `json:"data,omitempty"`      // структурированный результат complete_task
+}
+
+// Step одна строка steps.jsonl
+type Step struct {
+	Step        int                 `json:"step"`
+	StartedAt   time.Time           `json:"started_at"`
+	DurationMs  int64               `json:"duration_ms"`
+	PageContext *domain.PageContext `json:"page_context,omitempty"`
+	HTML        string              `json:"html,omitempty"`       // путь к HTML снимку относительно директории прогона
+	Screenshot  string              `json:"screenshot,omitempty"` // путь к скриншоту относительно директории прогона
+	Reasoning   string              `json:"reasoning,omitempty"`
+	Usage       Usage               `json:"usage"`
+	Calls       []Call              `json:"calls,omitempty"`
+	Error       string              `json:"error,omitempty"`
+}
+
+// Call выполнение одного вызова инструмента
+type Call struct {
+	ToolUseID       string        `json:"tool_use_id"`
+	Action          domain.Action `json:"action"`
+	SecurityVerdict string        `json:"security_verdict"`
+	SecurityReason  string        `json:"security_reason,omitempty"`
+	Result          *Result       `json:"result,omitempty"`
+	Analysis        string        `json:"analysis,omitempty"`
+	Skipped         string        `json:"skipped,omitempty"`
+	Error           string        `json:"error,omitempty"`
+	DurationMs      int64         `json:"duration_ms"`
+}
+
+// Result результат действия
+type Result struct {
+	Success      bool                 `json:"success"`
+	Message      string               `json:"message"`
+	Error        string               `json:"error,omitempty"`
+	ErrorContext *domain.ErrorContext `json:"error_context,omitempty"`
+	Screenshot   string               `json:"screenshot,omitempty"` // путь относительно директории прогона
+	QueryResult  string               `json:"query_result,omitempty"`
+}
+
+// Usage расход токенов
+type Usage struct {
+	InputTokens         int `json:"input_tokens"`
+	OutputTokens        int `json:"output_tokens"`
+	CacheReadTokens     int `json:"cache_read_tokens,omitempty"`
+	CacheCreationTokens int `json:"cache_creation_tokens,omitempty"`
+}
+
+func newUsage(u domain.TokenUsage) Usage {
+	return Usage{
+		InputTokens:         u.InputTokens,
+		OutputTokens:        u.OutputTokens,
+		CacheReadTokens:     u.CacheReadTokens,
+		CacheCreationTokens: u.CacheCreationTokens,
+	}
+}
+
+func newTask(t *domain.Task, steps int) Task {
+	out := Task{
+		ID:          t.ID,
+		Description: t.Description,
+		Status:      string(t.Status),
+		CreatedAt:   t.CreatedAt,
+		CompletedAt: t.CompletedAt,
+		Result:      t.Result,
+		Usage:       newUsage(t.Usage),
+		Steps:       steps,
+		Downloads:   t.Downloads,
+		Dialogs:     t.Dialogs,
+	}
+	if t.Error != nil {
+		out.Error = t.Error.Error()
+	}
+	if t.Data != nil {
+		out.Data, _ = json.MarshalIndent(t.Data, "", "  ")
+	}
+	if t.Extracted != nil {
+		out.Extracted, _ = json.MarshalIndent(t.Extracted, "", "  ")
+	}
+	return out
+}
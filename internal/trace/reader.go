@@ -0,0 +1,53 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Run записанный прогон задачи
+type Run struct {
+	Dir   string
+	Task  Task
+	Steps []Step
+}
+
+// ReadRun читает прогон из директории, созданной Recorder
+func ReadRun(dir string) (*Run, error) {
+	run := &Run{Dir: dir}
+
+	data, err := os.ReadFile(filepath.Join(dir, TaskFile))
+	if err != nil {
+		return nil, fmt.Errorf("read task: %w", err)
+	}
+	if err := json.Unmarshal(data, &run.Task); err != nil {
+		return nil, fmt.Errorf("parse task: %w", err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, StepsFile))
+	if err != nil {
+		return nil, fmt.Errorf("read steps: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	// Шаг содержит полный контекст страницы - строки бывают длинными
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var step Step
+		if err := json.Unmarshal(scanner.Bytes(), &step); err != nil {
+			return nil, fmt.Errorf("parse step at line %d: %w", line, err)
+		}
+		run.Steps = append(run.Steps, step)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read steps: %w", err)
+	}
+	return run, nil
+}
@@ -0,0 +1,174 @@
+package trace
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/agent"
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+	"github.com/Daniil-Sakharov/BrowserAgent/pkg/logger"
+)
+
+// Recorder пишет трейс каждой задачи в <baseDir>/<task.ID>/.
+// Ошибки записи только логируются и не влияют на выполнение задачи
+type Recorder struct {
+	baseDir string
+
+	mu     sync.Mutex
+	runDir string
+	steps  *os.File
+	count  int
+}
+
+var _ agent.Observer = (*Recorder)(nil)
+
+// NewRecorder создаёт recorder, пишущий прогоны в baseDir
+func NewRecorder(baseDir string) *Recorder {
+	return &Recorder{baseDir: baseDir}
+}
+
+// OnTaskStart создаёт директорию прогона
+func (r *Recorder) OnTaskStart(ctx context.Context, task *domain.Task) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closeSteps(ctx)
+	r.runDir, r.count = filepath.Join(r.baseDir, task.ID), 0
+	if err := os.MkdirAll(r.runDir, 0o755); err != nil {
+		logger.Warn(ctx, "⚠️ Trace: failed to create run dir", zap.Error(err))
+		r.runDir = ""
+		return
+	}
+
+	f, err := os.Create(filepath.Join(r.runDir, StepsFile))
+	if err != nil {
+		logger.Warn(ctx, "⚠️ Trace: failed to create steps file", zap.Error(err))
+		r.runDir = ""
+		return
+	}
+	r.steps = f
+	r.writeTask(ctx, task)
+
+	logger.Info(ctx, "🎞️ Recording run", zap.String("dir", r.runDir))
+}
+
+// OnStep дописывает шаг в steps.jsonl вместе со снимками страницы
+func (r *Recorder) OnStep(ctx context.Context, rec *agent.StepRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.steps == nil {
+		return
+	}
+	r.count++
+
+	step := Step{
+		Step:        rec.Step,
+		StartedAt:   rec.StartedAt,
+		DurationMs:  rec.Duration.Milliseconds(),
+		PageContext: rec.PageContext,
+		Reasoning:   rec.Reasoning,
+		Usage:       newUsage(rec.Usage),
+		Error:       rec.Error,
+	}
+	if rec.HTML != "" {
+		step.HTML = r.writeFile(ctx, fmt.Sprintf("step-%03d.html", rec.Step), []byte(rec.HTML))
+	}
+	if len(rec.Screenshot) > 0 {
+		step.Screenshot = r.writeFile(ctx, fmt.Sprintf("step-%03d.png", rec.Step), rec.Screenshot)
+	}
+	for i, c := range rec.Calls {
+		step.Calls = append(step.Calls, r.newCall(ctx, rec.Step, i+1, c))
+	}
+
+	line, err := json.Marshal(step)
+	if err != nil {
+		logger.Warn(ctx, "⚠️ Trace: failed to encode step", zap.Error(err))
+		return
+	}
+	if _, err := r.steps.Write(append(line, '\n')); err != nil {
+		logger.Warn(ctx, "⚠️ Trace: failed to write step", zap.Error(err))
+	}
+}
+
+// OnTaskEnd фиксирует итог задачи в task.json
+func (r *Recorder) OnTaskEnd(ctx context.Context, task *domain.Task) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.runDir == "" {
+		return
+	}
+	r.writeTask(ctx, task)
+	r.closeSteps(ctx)
+	logger.Info(ctx, "🎞️ Run recorded", zap.String("dir", r.runDir), zap.Int("steps", r.count))
+}
+
+func (r *Recorder) newCall(ctx context.Context, step, n int, c agent.CallRecord) Call {
+	call := Call{
+		ToolUseID:       c.ToolUseID,
+		Action:          c.Action,
+		SecurityVerdict: c.SecurityVerdict,
+		SecurityReason:  c.SecurityReason,
+		Analysis:        c.Analysis,
+		Skipped:         c.Skipped,
+		Error:           c.Error,
+		DurationMs:      c.Duration.Milliseconds(),
+	}
+	if c.Result == nil {
+		return call
+	}
+
+	res := &Result{
+		Success:      c.Result.Success,
+		Message:      c.Result.Message,
+		ErrorContext: c.Result.ErrorContext,
+		QueryResult:  c.Result.QueryResult,
+	}
+	if c.Result.Error != nil {
+		res.Error = c.Result.Error.Error()
+	}
+	// Скриншот действия копируем в прогон, чтобы трейс не зависел от AGENT_SCREENSHOTS_DIR
+	if c.Result.ScreenshotB64 != "" {
+		if data, err := base64.StdEncoding.DecodeString(c.Result.ScreenshotB64); err == nil {
+			res.Screenshot = r.writeFile(ctx, fmt.Sprintf("step-%03d-call-%d.png", step, n), data)
+		}
+	}
+	call.Result = res
+	return call
+}
+
+// writeFile пишет файл в директорию прогона и возвращает относительный путь
+func (r *Recorder) writeFile(ctx context.Context, name string, data []byte) string {
+	if err := os.WriteFile(filepath.Join(r.runDir, name), data, 0o644); err != nil {
+		logger.Warn(ctx, "⚠️ Trace: failed to write file", zap.String("file", name), zap.Error(err))
+		return ""
+	}
+	return name
+}
+
+func (r *Recorder) writeTask(ctx context.Context, task *domain.Task) {
+	data, err := json.MarshalIndent(newTask(task, r.count), "", "  ")
+	if err != nil {
+		logger.Warn(ctx, "⚠️ Trace: failed to encode task", zap.Error(err))
+		return
+	}
+	r.writeFile(ctx, TaskFile, data)
+}
+
+func (r *Recorder) closeSteps(ctx context.Context) {
+	if r.steps == nil {
+		return
+	}
+	if err := r.steps.Close(); err != nil {
+		logger.Warn(ctx, "⚠️ Trace: failed to close steps file", zap.Error(err))
+	}
+	r.steps = nil
+}
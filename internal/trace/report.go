@@ -0,0 +1,133 @@
+package trace
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Daniil-Sakharov/BrowserAgent/internal/domain"
+)
+
+//go:embed report.html.tmpl
+var reportTemplate string
+
+// RenderHTML пишет самодостаточный HTML отчёт по прогону: скриншоты и HTML снимки страниц
+// встраиваются в страницу, поэтому отчёт можно переслать одним файлом
+func RenderHTML(w io.Writer, run *Run) error {
+	tmpl, err := template.New("report").Funcs(template.FuncMap{
+		"image":        run.imageURL,
+		"snapshot":     run.snapshotHTML,
+		"params":       actionParams,
+		"errorContext": formatErrorContext,
+	}).Parse(reportTemplate)
+	if err != nil {
+		return fmt.Errorf("parse template: %w", err)
+	}
+	return tmpl.Execute(w, run)
+}
+
+// imageURL встраивает картинку из директории прогона как data URI
+func (r *Run) imageURL(name string) template.URL {
+	if name == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(r.Dir, name))
+	if err != nil {
+		return ""
+	}
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(data))
+}
+
+// snapshotHTML HTML снимок страницы из директории прогона. Встраивается в iframe srcdoc:
+// шаблон экранирует его как значение атрибута, а sandbox не даёт выполнить скрипты страницы
+func (r *Run) snapshotHTML(name string) string {
+	if name == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(r.Dir, name))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// actionParams параметры действия без пустых полей
+func actionParams(a domain.Action) map[string]string {
+	p := map[string]string{}
+	set := func(key, value string) {
+		if value != "" {
+			p[key] = value
+		}
+	}
+	set("selector", a.Selector)
+	set("value", a.Value)
+	set("url", a.URL)
+	set("direction", a.Direction)
+	set("query", a.Query)
+	set("question", a.Question)
+	set("label", a.Label)
+	set("files", strings.Join(a.Files, ", "))
+	if len(a.Fields) > 0 {
+		fields := make([]string, 0, len(a.Fields))
+		for _, f := range a.Fields {
+			fields = append(fields, f.Field+"="+f.Value)
+		}
+		set("fields", strings.Join(fields, "; "))
+	}
+	if a.Submit {
+		set("submit", "true")
+	}
+	set("key", a.Key)
+	if a.Repeat > 1 {
+		set("repeat", strconv.Itoa(a.Repeat))
+	}
+	if a.OptionIndex > 0 {
+		set("option_index", strconv.Itoa(a.OptionIndex))
+	}
+	set("target", a.Target)
+	if a.Type == domain.ActionTypeClickAtPosition || (a.Type == domain.ActionTypeDrag && a.AtPoint) {
+		set("x", strconv.Itoa(a.X))
+		set("y", strconv.Itoa(a.Y))
+	}
+	if a.DX != 0 || a.DY != 0 {
+		set("offset", fmt.Sprintf("%+d,%+d", a.DX, a.DY))
+	}
+	if a.Type == domain.ActionTypeSwitchTab {
+		set("tab_index", strconv.Itoa(a.TabIndex))
+	}
+	if a.Data != nil {
+		data, _ := json.Marshal(a.Data)
+		set("data", string(data))
+	}
+	if a.Schema != nil {
+		schema, _ := json.Marshal(a.Schema)
+		set("schema", string(schema))
+	}
+	if a.Type == domain.ActionTypeHandleDialog {
+		set("accept", strconv.FormatBool(a.Accept))
+	}
+	if a.Type == domain.ActionTypeReadPage {
+		set("offset", strconv.Itoa(a.Offset))
+	}
+	if a.FullPage {
+		set("full_page", "true")
+	}
+	if a.Annotate {
+		set("annotate", "true")
+	}
+	return p
+}
+
+func formatErrorContext(r *Result) string {
+	if r == nil || r.ErrorContext == nil {
+		return ""
+	}
+	return domain.FormatErrorContextMessage(r.Message, r.ErrorContext)
+}
@@ -0,0 +1,50 @@
+package trace
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRenderHTMLEmbedsSnapshot HTML снимок шага встраивается в отчёт, а не остаётся ссылкой
+// на файл рядом с ним: отчёт переносят одним файлом
+func TestRenderHTMLEmbedsSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		TaskFile:        `{"id": "t1", "description": "open the page", "status": "completed", "steps": 2}`,
+		StepsFile:       `{"step": 1, "html": "step-001.html"}` + "\n" + `{"step": 2, "html": "step-002.html"}` + "\n",
+		"step-001.html": `<html><body><h1 class="x">Оплата "картой"</h1><script>alert(1)</script></body></html>`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run, err := ReadRun(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := RenderHTML(&out, run); err != nil {
+		t.Fatal(err)
+	}
+	report := out.String()
+
+	if strings.Contains(report, `href="step-001.html"`) {
+		t.Error("snapshot is linked instead of embedded")
+	}
+	// Снимок экранирован как значение атрибута: его разметка и скрипты не становятся частью отчёта
+	if !strings.Contains(report, `<iframe sandbox srcdoc="&lt;html&gt;&lt;body&gt;&lt;h1 class=&#34;x&#34;&gt;Оплата &#34;картой&#34;`) {
+		t.Errorf("snapshot is not embedded into iframe srcdoc:\n%s", report)
+	}
+	if strings.Contains(report, "<script>alert(1)</script>") {
+		t.Error("snapshot script leaked into the report")
+	}
+	// Снимок второго шага не записан на диск - блока нет
+	if n := strings.Count(report, "<iframe"); n != 1 {
+		t.Errorf("iframes = %d, want 1", n)
+	}
+}
@@ -0,0 +1,331 @@
+// Package jsonschema проверяет JSON значения по подмножеству JSON Schema:
+// type, properties, required, additionalProperties, items, enum, const,
+// minItems/maxItems, minLength/maxLength, minimum/maximum, pattern, anyOf/oneOf
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxProblems сколько несоответствий перечислять в ошибке
+const maxProblems = 20
+
+// Schema разобранная JSON Schema
+type Schema struct {
+	raw map[string]interface{}
+}
+
+// Parse разбирает схему из JSON
+func Parse(data []byte) (*Schema, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	return New(raw)
+}
+
+// New создаёт схему из уже разобранного JSON объекта (например, аргумента инструмента)
+func New(raw map[string]interface{}) (*Schema, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("schema is empty")
+	}
+	if err := checkSchema(raw, "$"); err != nil {
+		return nil, err
+	}
+	return &Schema{raw: raw}, nil
+}
+
+// Map возвращает схему как JSON объект
+func (s *Schema) Map() map[string]interface{} { return s.raw }
+
+// String схема в компактном JSON
+func (s *Schema) String() string {
+	data, _ := json.Marshal(s.raw)
+	return string(data)
+}
+
+// IsArray true если корень схемы - массив
+func (s *Schema) IsArray() bool {
+	return hasType(s.raw, "array")
+}
+
+// AcceptsString true если корень схемы может быть строкой (type string или тип не задан)
+func (s *Schema) AcceptsString() bool {
+	_, typed := s.raw["type"]
+	return !typed || hasType(s.raw, "string")
+}
+
+// ValidationError несоответствия значения схеме; пути в формате $.items[2].price
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "schema mismatch: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate проверяет значение, полученное через json.Unmarshal в interface{}
+func (s *Schema) Validate(v interface{}) error {
+	var problems []string
+	validate(s.raw, v, "$", &problems)
+	if len(problems) == 0 {
+		return nil
+	}
+	if len(problems) > maxProblems {
+		problems = append(problems[:maxProblems], fmt.Sprintf("... and %d more", len(problems)-maxProblems))
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// ValidateJSON разбирает JSON и проверяет его по схеме
+func (s *Schema) ValidateJSON(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return v, s.Validate(v)
+}
+
+// checkSchema проверяет, что ключевые слова схемы имеют ожидаемые типы
+func checkSchema(raw map[string]interface{}, path string) error {
+	if props, ok := raw["properties"]; ok {
+		m, ok := props.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s.properties: must be an object", path)
+		}
+		for name, p := range m {
+			sub, ok := p.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s.properties.%s: must be a schema object", path, name)
+			}
+			if err := checkSchema(sub, path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	if items, ok := raw["items"]; ok {
+		sub, ok := items.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s.items: must be a schema object", path)
+		}
+		if err := checkSchema(sub, path+"[]"); err != nil {
+			return err
+		}
+	}
+	if req, ok := raw["required"]; ok {
+		if _, ok := req.([]interface{}); !ok {
+			return fmt.Errorf("%s.required: must be an array", path)
+		}
+	}
+	if p, ok := raw["pattern"].(string); ok {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("%s.pattern: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func validate(schema map[string]interface{}, v interface{}, path string, problems *[]string) {
+	add := func(format string, args ...interface{}) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if t, ok := schema["type"]; ok && !matchesType(t, v) {
+		add("expected %s, got %s", typeNames(t), typeOf(v))
+		return
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !contains(enum, v) {
+		add("must be one of %s", compact(enum))
+	}
+	if c, ok := schema["const"]; ok && !equal(c, v) {
+		add("must be %s", compact(c))
+	}
+	if alts, ok := schema["anyOf"].([]interface{}); ok && countMatches(alts, v, path) == 0 {
+		add("does not match any of anyOf alternatives")
+	}
+	if alts, ok := schema["oneOf"].([]interface{}); ok {
+		if n := countMatches(alts, v, path); n != 1 {
+			add("matches %d of oneOf alternatives, want exactly one", n)
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		validateObject(schema, val, path, problems)
+	case []interface{}:
+		if n, ok := number(schema["minItems"]); ok && float64(len(val)) < n {
+			add("must have at least %v items, got %d", n, len(val))
+		}
+		if n, ok := number(schema["maxItems"]); ok && float64(len(val)) > n {
+			add("must have at most %v items, got %d", n, len(val))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				validate(items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	case string:
+		n := float64(len([]rune(val)))
+		if m, ok := number(schema["minLength"]); ok && n < m {
+			add("must be at least %v characters", m)
+		}
+		if m, ok := number(schema["maxLength"]); ok && n > m {
+			add("must be at most %v characters", m)
+		}
+		if p, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(p); err == nil && !re.MatchString(val) {
+				add("must match pattern %q", p)
+			}
+		}
+	case float64:
+		if m, ok := number(schema["minimum"]); ok && val < m {
+			add("must be >= %v", m)
+		}
+		if m, ok := number(schema["maximum"]); ok && val > m {
+			add("must be <= %v", m)
+		}
+	}
+}
+
+func validateObject(schema map[string]interface{}, obj map[string]interface{}, path string, problems *[]string) {
+	props, _ := schema["properties"].(map[string]interface{})
+	if req, ok := schema["required"].([]interface{}); ok {
+		for _, r := range req {
+			name, _ := r.(string)
+			if _, ok := obj[name]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: missing required property %q", path, name))
+			}
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sub, ok := props[name].(map[string]interface{})
+		if !ok {
+			if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+				*problems = append(*problems, fmt.Sprintf("%s: unexpected property %q", path, name))
+			}
+			continue
+		}
+		validate(sub, obj[name], path+"."+name, problems)
+	}
+}
+
+// countMatches сколько альтернатив anyOf/oneOf принимают значение
+func countMatches(alts []interface{}, v interface{}, path string) int {
+	n := 0
+	for _, a := range alts {
+		sub, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var p []string
+		validate(sub, v, path, &p)
+		if len(p) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// matchesType проверяет type: строку или массив типов
+func matchesType(t interface{}, v interface{}) bool {
+	switch tt := t.(type) {
+	case string:
+		return isType(tt, v)
+	case []interface{}:
+		for _, x := range tt {
+			if s, ok := x.(string); ok && isType(s, v) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func isType(name string, v interface{}) bool {
+	switch name {
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	default:
+		return typeOf(v) == name
+	}
+}
+
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func hasType(schema map[string]interface{}, name string) bool {
+	switch t := schema["type"].(type) {
+	case string:
+		return t == name
+	case []interface{}:
+		return contains(t, name)
+	}
+	return false
+}
+
+func typeNames(t interface{}) string {
+	if arr, ok := t.([]interface{}); ok {
+		names := make([]string, 0, len(arr))
+		for _, x := range arr {
+			names = append(names, fmt.Sprint(x))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func number(v interface{}) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func contains(list []interface{}, v interface{}) bool {
+	for _, x := range list {
+		if equal(x, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func equal(a, b interface{}) bool {
+	return compact(a) == compact(b)
+}
+
+func compact(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,128 @@
+package jsonschema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func mustParse(t *testing.T, schema string) *Schema {
+	t.Helper()
+	s, err := Parse([]byte(schema))
+	if err != nil {
+		t.Fatalf("parse %s: %v", schema, err)
+	}
+	return s
+}
+
+func TestValidate(t *testing.T) {
+	product := `{
+		"type": "object",
+		"required": ["name", "price"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "maxLength": 10},
+			"price": {"type": "number", "minimum": 0, "maximum": 1000},
+			"qty": {"type": "integer"},
+			"sku": {"type": "string", "pattern": "^[A-Z]{2}-\\d+$"},
+			"tier": {"enum": ["free", "pro"]},
+			"currency": {"const": "USD"},
+			"note": {"type": ["string", "null"]}
+		}
+	}`
+	list := `{"type": "array", "minItems": 1, "maxItems": 2, "items": ` + product + `}`
+
+	tests := []struct {
+		name, schema, value string
+		problems            []string // подстроки ожидаемых несоответствий, nil - значение подходит
+	}{
+		{"valid object", product, `{"name": "Pro", "price": 10, "qty": 2, "sku": "AB-1", "tier": "pro", "currency": "USD", "note": null}`, nil},
+		{"wrong root type", product, `[]`, []string{"$: expected object, got array"}},
+		{"missing required", product, `{"name": "Pro"}`, []string{`$: missing required property "price"`}},
+		{"unexpected property", product, `{"name": "Pro", "price": 1, "color": "red"}`, []string{`$: unexpected property "color"`}},
+		{"integer", product, `{"name": "Pro", "price": 1, "qty": 1.5}`, []string{"$.qty: expected integer, got number"}},
+		{"string length", product, `{"name": "", "price": 1}`, []string{"$.name: must be at least 1 characters"}},
+		{"length in runes", product, `{"name": "Подписка", "price": 1}`, nil},
+		{"number range", product, `{"name": "Pro", "price": -1}`, []string{"$.price: must be >= 0"}},
+		{"pattern", product, `{"name": "Pro", "price": 1, "sku": "ab-1"}`, []string{"$.sku: must match pattern"}},
+		{"enum", product, `{"name": "Pro", "price": 1, "tier": "team"}`, []string{`$.tier: must be one of ["free","pro"]`}},
+		{"const", product, `{"name": "Pro", "price": 1, "currency": "EUR"}`, []string{`$.currency: must be "USD"`}},
+		{"type list", product, `{"name": "Pro", "price": 1, "note": 5}`, []string{"$.note: expected string or null, got number"}},
+		{"items paths", list, `[{"name": "A", "price": 1}, {"name": "B"}]`, []string{`$[1]: missing required property "price"`}},
+		{"min items", list, `[]`, []string{"$: must have at least 1 items, got 0"}},
+		{"max items", list, `[{"name": "A", "price": 1}, {"name": "B", "price": 2}, {"name": "C", "price": 3}]`, []string{"$: must have at most 2 items, got 3"}},
+		{"anyOf match", `{"anyOf": [{"type": "string"}, {"type": "number"}]}`, `5`, nil},
+		{"anyOf both match", `{"anyOf": [{"type": "number"}, {"minimum": 0}]}`, `5`, nil},
+		{"anyOf no match", `{"anyOf": [{"type": "string"}, {"type": "number"}]}`, `true`, []string{"does not match any of anyOf alternatives"}},
+		{"oneOf single match", `{"oneOf": [{"type": "string"}, {"type": "number"}]}`, `5`, nil},
+		{"oneOf no match", `{"oneOf": [{"type": "string"}, {"type": "number"}]}`, `true`, []string{"matches 0 of oneOf alternatives"}},
+		{"oneOf several match", `{"oneOf": [{"type": "number"}, {"minimum": 0}]}`, `5`, []string{"matches 2 of oneOf alternatives, want exactly one"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mustParse(t, tt.schema).ValidateJSON([]byte(tt.value))
+			if tt.problems == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("err = %v, want ValidationError", err)
+			}
+			if len(verr.Problems) != len(tt.problems) {
+				t.Fatalf("problems = %q, want %d", verr.Problems, len(tt.problems))
+			}
+			for i, want := range tt.problems {
+				if !strings.Contains(verr.Problems[i], want) {
+					t.Errorf("problem %d = %q, want %q", i, verr.Problems[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateLimitsProblems(t *testing.T) {
+	s := mustParse(t, `{"type": "array", "items": {"type": "string"}}`)
+	err := s.Validate(make([]interface{}, 30))
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("err = %v", err)
+	}
+	if len(verr.Problems) != maxProblems+1 || verr.Problems[maxProblems] != "... and 10 more" {
+		t.Errorf("problems = %d, last %q", len(verr.Problems), verr.Problems[len(verr.Problems)-1])
+	}
+}
+
+func TestParseRejectsMalformedSchema(t *testing.T) {
+	for _, schema := range []string{
+		`not json`,
+		`{}`,
+		`{"properties": []}`,
+		`{"properties": {"a": "string"}}`,
+		`{"items": [{"type": "string"}]}`,
+		`{"required": "name"}`,
+		`{"properties": {"a": {"pattern": "("}}}`,
+	} {
+		if _, err := Parse([]byte(schema)); err == nil {
+			t.Errorf("Parse(%s) accepted malformed schema", schema)
+		}
+	}
+}
+
+func TestRootHelpers(t *testing.T) {
+	if !mustParse(t, `{"type": "array"}`).IsArray() || mustParse(t, `{"type": "object"}`).IsArray() {
+		t.Error("IsArray")
+	}
+	for schema, want := range map[string]bool{
+		`{"type": "string"}`:             true,
+		`{"type": ["object", "string"]}`: true,
+		`{"enum": ["a", "b"]}`:           true,
+		`{"type": "object"}`:             false,
+	} {
+		if got := mustParse(t, schema).AcceptsString(); got != want {
+			t.Errorf("AcceptsString(%s) = %v", schema, got)
+		}
+	}
+}
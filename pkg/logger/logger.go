@@ -73,7 +73,7 @@ func InitWithFile(levelStr string, asJSON bool, logFile string, config *LoggerCo
 		}
 
 		zapLogger := zap.New(
-			zapcore.NewTee(cores...),
+			RedactCore{Core: zapcore.NewTee(cores...)},
 			zap.AddCaller(),
 			zap.AddCallerSkip(1), // Skip 1 уровень для правильного отображения caller
 		)
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// redactor функция, скрывающая секреты; nil - логи пишутся как есть
+var redactor atomic.Pointer[func(string) string]
+
+// SetRedactor задаёт функцию, которой проходят сообщение и строковые поля каждой записи
+// (пароли, токены). Можно вызвать после Init: применяется к последующим записям
+func SetRedactor(fn func(string) string) {
+	if fn == nil {
+		redactor.Store(nil)
+		return
+	}
+	redactor.Store(&fn)
+}
+
+// RedactCore пропускает записи вложенного core через redactor
+type RedactCore struct {
+	zapcore.Core
+}
+
+func (c RedactCore) With(fields []zapcore.Field) zapcore.Core {
+	return RedactCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c RedactCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c RedactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if fn := redactor.Load(); fn != nil {
+		entry.Message = (*fn)(entry.Message)
+	}
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+// redactFields копия полей со скрытыми секретами в строках, ошибках и Stringer
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	fn := redactor.Load()
+	if fn == nil || len(fields) == 0 {
+		return fields
+	}
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		switch f.Type {
+		case zapcore.StringType:
+			f.String = (*fn)(f.String)
+		case zapcore.ErrorType:
+			if err, ok := f.Interface.(error); ok && err != nil {
+				f = zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: (*fn)(err.Error())}
+			}
+		case zapcore.StringerType:
+			if s, ok := f.Interface.(fmt.Stringer); ok && s != nil {
+				f = zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: (*fn)(s.String())}
+			}
+		}
+		out[i] = f
+	}
+	return out
+}
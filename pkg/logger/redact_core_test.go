@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type stringer string
+
+func (s stringer) String() string { return string(s) }
+
+func TestRedactCore(t *testing.T) {
+	SetRedactor(func(s string) string { return strings.ReplaceAll(s, "hunter2", "{{secret:pass}}") })
+	defer SetRedactor(nil)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := zap.New(RedactCore{Core: core}).With(zap.String("task", "login with hunter2"))
+
+	log.Info("typed hunter2",
+		zap.String("text", "hunter2"),
+		zap.Error(errors.New("field rejected hunter2")),
+		zap.Stringer("value", stringer("hunter2")),
+		zap.Int("step", 2))
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	e := entries[0]
+	if e.Message != "typed {{secret:pass}}" {
+		t.Errorf("message = %q", e.Message)
+	}
+	fields := e.ContextMap()
+	for key, want := range map[string]interface{}{
+		"task":  "login with {{secret:pass}}",
+		"text":  "{{secret:pass}}",
+		"error": "field rejected {{secret:pass}}",
+		"value": "{{secret:pass}}",
+		"step":  int64(2),
+	} {
+		if fields[key] != want {
+			t.Errorf("field %s = %#v, want %#v", key, fields[key], want)
+		}
+	}
+}
+
+func TestRedactCoreWithoutRedactor(t *testing.T) {
+	SetRedactor(nil)
+	core, logs := observer.New(zapcore.DebugLevel)
+	zap.New(RedactCore{Core: core}).Info("typed hunter2", zap.String("text", "hunter2"))
+
+	e := logs.All()[0]
+	if e.Message != "typed hunter2" || e.ContextMap()["text"] != "hunter2" {
+		t.Errorf("entry changed without redactor: %q %v", e.Message, e.ContextMap())
+	}
+}